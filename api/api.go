@@ -1,45 +1,88 @@
 package api
 
 import (
-	"fmt"
-	"os"
-	"os/signal"
+	"context"
+	"time"
 
+	apiGraphQL "github.com/danilotadeu/products/api/graphql"
+	apiHealth "github.com/danilotadeu/products/api/health"
+	"github.com/danilotadeu/products/api/media"
+	"github.com/danilotadeu/products/api/middleware/auth"
+	"github.com/danilotadeu/products/api/middleware/disconnect"
+	"github.com/danilotadeu/products/api/middleware/locale"
+	"github.com/danilotadeu/products/api/middleware/ratelimit"
+	"github.com/danilotadeu/products/api/middleware/shutdown"
+	"github.com/danilotadeu/products/api/middleware/version"
+	"github.com/danilotadeu/products/api/problem"
 	"github.com/danilotadeu/products/api/product"
+	productV2 "github.com/danilotadeu/products/api/v2/product"
 	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/config"
 	_ "github.com/danilotadeu/products/docs"
+	"github.com/danilotadeu/products/health"
+	"github.com/danilotadeu/products/metrics"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/danilotadeu/products/validation"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
 	"github.com/sirupsen/logrus"
 )
 
+// bodyOverhead is the room of the multipart boundaries and fields around an upload
+const bodyOverhead = 64 << 10
+
 // validate is a validator package
 var validate *validator.Validate
 
+// Register builds the routes, ctx is the parent of the request contexts and is cancelled to
+// interrupt the requests still running at the end of the shutdown. rateLimit holds the
+// budgets, shared with the grpc server
+//
 // @title		Star Wars API
 // @version		1.0
 // @BasePath	/api
-func Register(apps *app.Container, port string) {
-	fiberRoute := fiber.New()
+func Register(ctx context.Context, apps *app.Container, checker *health.Checker, cfg *config.Config, rateLimit ratelimit.Config) *fiber.App {
+	fiberRoute := fiber.New(fiber.Config{
+		ErrorHandler: problem.Handler,
+		// the uploads are bounded by the media size, the rest of the multipart has some room
+		BodyLimit: int(cfg.Media.MaxSize()) + bodyOverhead,
+	})
+	fiberRoute.Use(shutdown.New(ctx), disconnect.New(), metrics.Middleware(), tracing.Middleware(), locale.New(), version.Negotiate("/api"))
 
-	gracefulShutdown := make(chan os.Signal, 1)
-	signal.Notify(gracefulShutdown, os.Interrupt)
-	go func() {
-		<-gracefulShutdown
-		fmt.Println("Gracefully shutting down...")
-		_ = fiberRoute.Shutdown()
-	}()
+	// the rest and graphql clients share the same keys and budgets, the keys sent are
+	// limited by ip before they are looked up
+	authLimiter := ratelimit.Authentications(rateLimit)
+	authenticate := auth.New(apps.APIKey, cfg.Auth.Required)
+	limiter := ratelimit.New(rateLimit)
+	baseAPI := fiberRoute.Group("/api", authLimiter, authenticate, limiter)
 
-	baseAPI := fiberRoute.Group("/api")
+	validate = validation.New()
 
-	validate = validator.New(validator.WithRequiredStructEnabled())
+	// Planets: /api is v1, also served from /api/v1, until the sunset
+	deprecated := deprecateV1(cfg.Server)
+	for _, group := range []fiber.Router{baseAPI.Group("/products", deprecated), baseAPI.Group("/v1/products", deprecated)} {
+		product.NewAPI(group, apps, validate)
+		media.NewAPI(group, apps)
+	}
+	v2 := baseAPI.Group("/v2/products")
+	productV2.NewAPI(v2, apps, validate)
+	media.NewAPI(v2, apps)
+	apiGraphQL.NewAPI(fiberRoute.Group("/graphql", authLimiter, authenticate, limiter), apps, validate)
 
-	// Planets
-	product.NewAPI(baseAPI.Group("/products"), apps, validate)
+	apiHealth.NewAPI(fiberRoute, checker)
+	problem.NewAPI(fiberRoute.Group("/problems"))
 
 	fiberRoute.Get("/swagger/*", swagger.HandlerDefault)
+	fiberRoute.Get("/metrics", metrics.Handler())
 
 	logrus.WithFields(logrus.Fields{"trace": "api"}).Infof("Registered - Api")
-	fiberRoute.Listen(":" + port)
+	return fiberRoute
+}
+
+// deprecateV1 announces the dates of the server settings, validated by config.Load
+func deprecateV1(cfg config.Server) fiber.Handler {
+	deprecation, _ := time.Parse("2006-01-02", cfg.V1Deprecation)
+	sunset, _ := time.Parse("2006-01-02", cfg.V1Sunset)
+	return version.Deprecate(deprecation, sunset, "/api/v2/products")
 }
@@ -0,0 +1,130 @@
+// Package graphql serves the products through a GraphQL schema at /graphql
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/danilotadeu/products/app"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/sirupsen/logrus"
+)
+
+type apiImpl struct {
+	apps      *app.Container
+	validator *validator.Validate
+	schema    graphql.Schema
+}
+
+// request is the body of a GraphQL request
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewAPI serves the schema on POST and on GET, where browsers get the playground
+func NewAPI(g fiber.Router, apps *app.Container, validate *validator.Validate) {
+	api := &apiImpl{
+		apps:      apps,
+		validator: validate,
+	}
+
+	schema, err := newSchema(api)
+	if err != nil {
+		panic(err)
+	}
+	api.schema = schema
+
+	g.Get("/", api.get)
+	g.Post("/", api.post)
+}
+
+// get answers the playground to browsers and runs the queries sent in the url
+func (p *apiImpl) get(c *fiber.Ctx) error {
+	if c.Query("query") == "" && strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+		c.Type("html")
+		return c.SendString(playground)
+	}
+
+	req := request{
+		Query:         c.Query("query"),
+		OperationName: c.Query("operationName"),
+	}
+	if variables := c.Query("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			logrus.WithContext(c.UserContext()).WithFields(logrus.Fields{"trace": "api.graphql.get.Unmarshal"}).WithError(err).Error(err)
+			return errorsP.ErrInvalidQuery.WithDetail("detail.graphql_variables")
+		}
+	}
+
+	// a GET must be safe to repeat, so the mutations only run on POST
+	if isMutation(req) {
+		c.Set(fiber.HeaderAllow, http.MethodPost)
+		return errorsP.ErrMethodNotAllowed.WithDetail("detail.graphql_mutation_over_get")
+	}
+
+	return p.execute(c, req)
+}
+
+func (p *apiImpl) post(c *fiber.Ctx) error {
+	req := request{}
+	if err := c.BodyParser(&req); err != nil {
+		logrus.WithContext(c.UserContext()).WithFields(logrus.Fields{"trace": "api.graphql.post.BodyParser"}).WithError(err).Error(err)
+		return errorsP.ErrBadRequest.WithDetail("%s", err.Error())
+	}
+
+	return p.execute(c, req)
+}
+
+// execute runs req with a loader of its own, so the batches never mix requests
+func (p *apiImpl) execute(c *fiber.Ctx, req request) error {
+	if req.Query == "" {
+		return errorsP.ErrBadRequest.WithDetail("detail.graphql_missing_query")
+	}
+
+	ctx := c.UserContext()
+	result := graphql.Do(graphql.Params{
+		Schema:         p.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        withLoader(ctx, newLoader(p.apps.Product)),
+	})
+	for idx, err := range result.Errors {
+		if err.Extensions == nil {
+			result.Errors[idx].Extensions = extensionsOf(err)
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(result)
+}
+
+// isMutation reports whether the operation req runs is a mutation, the queries that
+// do not parse are left for graphql to report
+func isMutation(req request) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName != "" && (operation.Name == nil || operation.Name.Value != req.OperationName) {
+			continue
+		}
+		if operation.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/api/problem"
+	"github.com/danilotadeu/products/app"
+	mockAppProduct "github.com/danilotadeu/products/mock/app/product"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+var createdAt = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func product(id int64) *productModel.ProductDB {
+	return &productModel.ProductDB{ID: id, Name: fmt.Sprintf("Product %d", id), Quantity: id * 10, CreatedAt: createdAt}
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code   string `json:"code"`
+			Reason string `json:"reason"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func TestHandler(t *testing.T) {
+	cases := map[string]struct {
+		Method             string
+		Query              string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+		ExpectedData       string
+		ExpectedCodes      []string
+		ExpectedReasons    []string
+	}{
+		"should batch the products of a query in one read": {
+			Method: http.MethodPost,
+			Query:  `{ a: product(id: "1") { name } b: product(id: "2") { name quantity } c: product(id: "1") { id } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByIDs(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+					assert.Equal(t, 2, len(ids))
+					return []*productModel.ProductDB{product(1), product(2)}, nil
+				})
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"a":{"name":"Product 1"},"b":{"name":"Product 2","quantity":20},"c":{"id":"1"}}`,
+		},
+		"should answer not found for a missing product": {
+			Method: http.MethodGet,
+			Query:  `{ product(id: "404") { name } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByIDs(gomock.Any(), []int64{404}).Return(nil, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"product":null}`,
+			ExpectedCodes:      []string{CodeNotFound},
+		},
+		"should throw bad user input with an invalid id": {
+			Method:             http.MethodPost,
+			Query:              `{ product(id: "xpto") { name } }`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"product":null}`,
+			ExpectedCodes:      []string{CodeBadUserInput},
+		},
+		"should hide the internal errors": {
+			Method: http.MethodPost,
+			Query:  `{ product(id: "1") { name } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByIDs(gomock.Any(), []int64{1}).Return(nil, fmt.Errorf("error"))
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"product":null}`,
+			ExpectedCodes:      []string{CodeInternal},
+		},
+		"should answer timeout when the statement expires": {
+			Method: http.MethodPost,
+			Query:  `{ product(id: "1") { name } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByIDs(gomock.Any(), []int64{1}).Return(nil, fmt.Errorf("%w: interrupted", context.DeadlineExceeded))
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"product":null}`,
+			ExpectedCodes:      []string{CodeTimeout},
+		},
+		"should map the errors by the catalogue": {
+			Method: http.MethodPost,
+			Query:  `mutation { createProduct(input: {name: "Product 1", quantity: 1}) { id } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorBarcodeAlreadyExists)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `null`,
+			ExpectedCodes:      []string{CodeAlreadyExists},
+			ExpectedReasons:    []string{"BARCODE_ALREADY_EXISTS"},
+		},
+		"should answer bad user input for the invalid requests of the catalogue": {
+			Method: http.MethodPost,
+			Query:  `{ products { edges { node { id } } } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_min", "limit", 1))
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `null`,
+			ExpectedCodes:      []string{CodeBadUserInput},
+			ExpectedReasons:    []string{"INVALID_QUERY_PARAMETER"},
+		},
+		"should list a page of products": {
+			Method: http.MethodGet,
+			Query:  `{ products(first: 2, name: "Product") { edges { cursor node { id } } pageInfo { hasNextPage hasPreviousPage endCursor } totalCount } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(0), int64(3), "Product").Return([]*productModel.ProductDB{product(1), product(2), product(3)}, nil)
+				mockProductApp.EXPECT().GetTotalProducts(gomock.Any()).Return(func() *int64 { total := int64(3); return &total }(), nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData: `{"products":{"edges":[{"cursor":"b2Zmc2V0OjA=","node":{"id":"1"}},{"cursor":"b2Zmc2V0OjE=","node":{"id":"2"}}],` +
+				`"pageInfo":{"endCursor":"b2Zmc2V0OjE=","hasNextPage":true,"hasPreviousPage":false},"totalCount":3}}`,
+		},
+		"should list the page after a cursor": {
+			Method: http.MethodPost,
+			Query:  `{ products(first: 2, after: "b2Zmc2V0OjE=") { edges { node { id } } pageInfo { hasNextPage hasPreviousPage } } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(2), int64(3), "").Return([]*productModel.ProductDB{product(3)}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"products":{"edges":[{"node":{"id":"3"}}],"pageInfo":{"hasNextPage":false,"hasPreviousPage":true}}}`,
+		},
+		"should list an empty page": {
+			Method: http.MethodPost,
+			Query:  `{ products { edges { node { id } } pageInfo { hasNextPage endCursor } } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(0), int64(defaultFirst+1), "").Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"products":{"edges":[],"pageInfo":{"endCursor":null,"hasNextPage":false}}}`,
+		},
+		"should throw bad user input with an invalid cursor": {
+			Method:             http.MethodPost,
+			Query:              `{ products(after: "xpto") { edges { cursor } } }`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `null`,
+			ExpectedCodes:      []string{CodeBadUserInput},
+		},
+		"should create a product": {
+			Method: http.MethodPost,
+			Query:  `mutation { createProduct(input: {name: "Product 1", quantity: 10}) { id name createdAt } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				id := int64(1)
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+				mockProductApp.EXPECT().GetByIDs(gomock.Any(), []int64{1}).Return([]*productModel.ProductDB{product(1)}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"createProduct":{"createdAt":"2023-01-02T03:04:05Z","id":"1","name":"Product 1"}}`,
+		},
+		"should throw already exists when the name is taken": {
+			Method: http.MethodPost,
+			Query:  `mutation { createProduct(input: {name: "Product 1", quantity: 10}) { id } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorProductAlreadyExists)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `null`,
+			ExpectedCodes:      []string{CodeAlreadyExists},
+		},
+		"should throw bad user input when the product is invalid": {
+			Method:             http.MethodPost,
+			Query:              `mutation { createProduct(input: {name: "", quantity: 10}) { id } }`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `null`,
+			ExpectedCodes:      []string{CodeBadUserInput},
+		},
+		"should read the updated product again": {
+			Method: http.MethodPost,
+			Query:  `mutation { before: createProduct(input: {name: "Product 1", quantity: 10}) { name } after: updateProduct(id: "1", input: {name: "Product 2", quantity: 20}) { name } }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				id := int64(1)
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(&id, nil)
+				mockProductApp.EXPECT().UpdateProduct(gomock.Any(), productModel.ProductDB{ID: 1, Name: "Product 2", Quantity: 20}).Return(nil)
+				gomock.InOrder(
+					mockProductApp.EXPECT().GetByIDs(gomock.Any(), []int64{1}).Return([]*productModel.ProductDB{product(1)}, nil),
+					mockProductApp.EXPECT().GetByIDs(gomock.Any(), []int64{1}).Return([]*productModel.ProductDB{{ID: 1, Name: "Product 2", Quantity: 20}}, nil),
+				)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"after":{"name":"Product 2"},"before":{"name":"Product 1"}}`,
+		},
+		"should delete a product": {
+			Method: http.MethodPost,
+			Query:  `mutation { deleteProduct(id: "1") }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().Delete(gomock.Any(), int64(1)).Return(nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"deleteProduct":"1"}`,
+		},
+		"should throw not found when deleting a missing product": {
+			Method: http.MethodPost,
+			Query:  `mutation { deleteProduct(id: "1") }`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().Delete(gomock.Any(), int64(1)).Return(productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `null`,
+			ExpectedCodes:      []string{CodeNotFound},
+		},
+		"should not run mutations on get": {
+			Method:             http.MethodGet,
+			Query:              `mutation { deleteProduct(id: "1") }`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusMethodNotAllowed,
+		},
+		"should throw error without a query": {
+			Method:             http.MethodPost,
+			Query:              "",
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			mockProductApp := mockAppProduct.NewMockApp(ctrl)
+			cs.PrepareMockApp(mockProductApp)
+
+			fiberApp := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+			NewAPI(fiberApp.Group("/graphql"), &app.Container{Product: mockProductApp}, validation.New())
+
+			var req *http.Request
+			if cs.Method == http.MethodGet {
+				req = httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(cs.Query), nil)
+			} else {
+				body, _ := json.Marshal(request{Query: cs.Query})
+				req = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			}
+
+			resp, err := fiberApp.Test(req.WithContext(ctx), -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			if cs.ExpectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var body response
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, cs.ExpectedData, string(body.Data))
+
+			codes := []string{}
+			for _, err := range body.Errors {
+				codes = append(codes, err.Extensions.Code)
+			}
+			if cs.ExpectedCodes == nil {
+				cs.ExpectedCodes = []string{}
+			}
+			assert.DeepEqual(t, cs.ExpectedCodes, codes)
+
+			for idx, reason := range cs.ExpectedReasons {
+				assert.Equal(t, reason, body.Errors[idx].Extensions.Reason)
+			}
+		})
+	}
+}
+
+func TestPlayground(t *testing.T) {
+	fiberApp := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+	NewAPI(fiberApp.Group("/graphql"), &app.Container{}, validation.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set(fiber.HeaderAccept, "text/html,application/xhtml+xml")
+	resp, err := fiberApp.Test(req, -1)
+	assert.NilError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(body), "GraphiQL"))
+}
@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	productApp "github.com/danilotadeu/products/app/product"
+	productModel "github.com/danilotadeu/products/model/product"
+)
+
+type loaderKey struct{}
+
+// loaded is the outcome of a product once its batch ran
+type loaded struct {
+	product *productModel.ProductDB
+	err     error
+}
+
+// loader collects the product ids asked by the root product fields of a query, as its
+// aliases, and reads them with a single GetByIDs once the first of them is needed
+type loader struct {
+	app     productApp.App
+	mu      sync.Mutex
+	pending map[int64]struct{}
+	cache   map[int64]loaded
+}
+
+func newLoader(app productApp.App) *loader {
+	return &loader{
+		app:     app,
+		pending: map[int64]struct{}{},
+		cache:   map[int64]loaded{},
+	}
+}
+
+func withLoader(ctx context.Context, l *loader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFrom(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+// Load queues id and returns the thunk graphql calls once the sibling fields are queued too
+func (l *loader) Load(ctx context.Context, id int64) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[id]; !ok {
+		l.pending[id] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.pending[id]; ok {
+			l.dispatch(ctx)
+		}
+		result := l.cache[id]
+		return result.product, result.err
+	}
+}
+
+// Prime keeps product, already read by other means, for the next loads
+func (l *loader) Prime(product *productModel.ProductDB) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.pending[product.ID]; !ok {
+		l.cache[product.ID] = loaded{product: product}
+	}
+}
+
+// Clear forgets id after a mutation changed it
+func (l *loader) Clear(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.cache, id)
+}
+
+// dispatch reads every pending id, the ids left out of the result do not exist
+func (l *loader) dispatch(ctx context.Context) {
+	ids := make([]int64, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	l.pending = map[int64]struct{}{}
+
+	products, err := l.app.GetByIDs(ctx, ids)
+	found := make(map[int64]*productModel.ProductDB, len(products))
+	for _, product := range products {
+		found[product.ID] = product
+	}
+
+	for _, id := range ids {
+		switch product, ok := found[id]; {
+		case err != nil:
+			l.cache[id] = loaded{err: err}
+		case !ok:
+			l.cache[id] = loaded{err: productModel.ErrorProductNotFound}
+		default:
+			l.cache[id] = loaded{product: product}
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danilotadeu/products/i18n"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultFirst is the page size of products when first is not sent
+	defaultFirst = 10
+	// maxFirst bounds the page size of products
+	maxFirst = 100
+
+	cursorPrefix = "offset:"
+)
+
+// Codes sent in the extensions of the errors
+const (
+	CodeNotFound        = "NOT_FOUND"
+	CodeAlreadyExists   = "ALREADY_EXISTS"
+	CodeBadUserInput    = "BAD_USER_INPUT"
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeCancelled       = "CANCELLED"
+	CodeTimeout         = "TIMEOUT"
+	CodeInternal        = "INTERNAL"
+)
+
+// resolverError is an error the client can tell apart by its code, reason is the code of the
+// catalogue entry, as PRODUCT_ALREADY_EXISTS or BARCODE_ALREADY_EXISTS
+type resolverError struct {
+	code    string
+	reason  string
+	message string
+	fields  []errorsP.FieldError
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	if e.reason != "" {
+		extensions["reason"] = e.reason
+	}
+	if e.fields != nil {
+		extensions["fields"] = e.fields
+	}
+	return extensions
+}
+
+// badUserInput translates the i18n key in the locale of ctx
+func badUserInput(ctx context.Context, key string, args ...interface{}) error {
+	return &resolverError{code: CodeBadUserInput, message: i18n.T(ctx, key, args...)}
+}
+
+// invalidInput lists the invalid fields of a failed validation in the extensions
+func invalidInput(ctx context.Context, err error) error {
+	return &resolverError{code: CodeBadUserInput, message: validation.Message(ctx, err), fields: validation.Fields(ctx, err)}
+}
+
+// extensionsOf digs the resolverError out of the layers graphql wraps the errors of the thunks in
+func extensionsOf(err error) map[string]interface{} {
+	for err != nil {
+		switch e := err.(type) {
+		case *resolverError:
+			return e.Extensions()
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// resolveError maps the errors to the codes by the status of their entry of the catalogue, as
+// the rest api does, the message is the title and detail of the entry in the locale of ctx and
+// reason its code. The internal ones are hidden behind "graphql.INTERNAL"
+func resolveError(ctx context.Context, err error) error {
+	e := errorsP.From(err)
+	code := codeOf(e.Status)
+	if code == CodeInternal {
+		return &resolverError{code: code, message: i18n.T(ctx, "graphql."+code)}
+	}
+
+	problem := e.Problem(i18n.Locale(ctx), "")
+	message := problem.Title
+	if problem.Detail != "" {
+		message += ": " + problem.Detail
+	}
+	return &resolverError{code: code, reason: e.Code, message: message, fields: e.Fields}
+}
+
+// codeOf is the code of the errors answered with status by the rest api
+func codeOf(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeAlreadyExists
+	case status == errorsP.StatusClientClosedRequest:
+		return CodeCancelled
+	case status == http.StatusGatewayTimeout:
+		return CodeTimeout
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		return CodeBadUserInput
+	}
+	return CodeInternal
+}
+
+// connection is a page of products and where it starts
+type connection struct {
+	products    []*productModel.ProductDB
+	offset      int64
+	hasNextPage bool
+}
+
+func encodeCursor(offset int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(offset, 10)))
+}
+
+func decodeCursor(ctx context.Context, cursor string) (int64, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, badUserInput(ctx, "graphql.invalid_cursor", cursor)
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimPrefix(string(raw), cursorPrefix), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, badUserInput(ctx, "graphql.invalid_cursor", cursor)
+	}
+	return offset, nil
+}
+
+func parseID(p graphql.ResolveParams) (int64, error) {
+	id, err := strconv.ParseInt(fmt.Sprint(p.Args["id"]), 10, 64)
+	if err != nil {
+		return 0, badUserInput(p.Context, "graphql.invalid_id", p.Args["id"])
+	}
+	return id, nil
+}
+
+func parseInput(p graphql.ResolveParams) productModel.ProductDB {
+	input := p.Args["input"].(map[string]interface{})
+	return productModel.ProductDB{
+		Name:     input["name"].(string),
+		Quantity: int64(input["quantity"].(int)),
+	}
+}
+
+// newSchema builds the products schema, resolved through the product app of api
+func newSchema(api *apiImpl) (graphql.Schema, error) {
+	productType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return strconv.FormatInt(p.Source.(*productModel.ProductDB).ID, 10), nil
+				},
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"quantity": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*productModel.ProductDB).CreatedAt, nil
+				},
+			},
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(productType)},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductConnection",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					conn := p.Source.(*connection)
+					edges := make([]map[string]interface{}, len(conn.products))
+					for idx, product := range conn.products {
+						edges[idx] = map[string]interface{}{
+							"cursor": encodeCursor(conn.offset + int64(idx)),
+							"node":   product,
+						}
+					}
+					return edges, nil
+				},
+			},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(pageInfoType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					conn := p.Source.(*connection)
+					pageInfo := map[string]interface{}{
+						"hasNextPage":     conn.hasNextPage,
+						"hasPreviousPage": conn.offset > 0,
+					}
+					if len(conn.products) > 0 {
+						pageInfo["startCursor"] = encodeCursor(conn.offset)
+						pageInfo["endCursor"] = encodeCursor(conn.offset + int64(len(conn.products)) - 1)
+					}
+					return pageInfo, nil
+				},
+			},
+			"totalCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Total of products in the catalogue, regardless of the filters",
+				Resolve:     api.totalCount,
+			},
+		},
+	})
+
+	inputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ProductInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"quantity": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"product": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: api.product,
+			},
+			"products": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultFirst},
+					"after": &graphql.ArgumentConfig{Type: graphql.String},
+					"name":  &graphql.ArgumentConfig{Type: graphql.String, Description: "Filters the products whose name contains it"},
+				},
+				Resolve: api.products,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createProduct": &graphql.Field{
+				Type: graphql.NewNonNull(productType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(inputType)},
+				},
+				Resolve: api.createProduct,
+			},
+			"updateProduct": &graphql.Field{
+				Type: graphql.NewNonNull(productType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(inputType)},
+				},
+				Resolve: api.updateProduct,
+			},
+			"deleteProduct": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: api.deleteProduct,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+// load resolves id through the loader of the request. The mutations call the thunk
+// right away: their fields run one after the other and must see the previous ones
+func (p *apiImpl) load(ctx context.Context, id int64, trace string) func() (interface{}, error) {
+	thunk := loaderFrom(ctx).Load(ctx, id)
+	return func() (interface{}, error) {
+		product, err := thunk()
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace}).WithError(err).Error(err)
+			return nil, resolveError(ctx, err)
+		}
+		return product, nil
+	}
+}
+
+func (p *apiImpl) product(params graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(params)
+	if err != nil {
+		return nil, err
+	}
+	return p.load(params.Context, id, "api.graphql.product.Load"), nil
+}
+
+func (p *apiImpl) products(params graphql.ResolveParams) (interface{}, error) {
+	ctx := params.Context
+
+	first := params.Args["first"].(int)
+	if first < 1 || first > maxFirst {
+		return nil, badUserInput(ctx, "graphql.invalid_first", maxFirst)
+	}
+
+	var offset int64
+	if after, ok := params.Args["after"].(string); ok {
+		cursor, err := decodeCursor(ctx, after)
+		if err != nil {
+			return nil, err
+		}
+		offset = cursor + 1
+	}
+	name, _ := params.Args["name"].(string)
+
+	// one product past the page tells whether there is a next one
+	products, err := p.apps.Product.GetAllProducts(ctx, offset, int64(first)+1, name)
+	if err != nil && !errors.Is(err, productModel.ErrorProductNotFound) {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.products.GetAllProducts"}).WithError(err).Error(err)
+		return nil, resolveError(ctx, err)
+	}
+
+	conn := &connection{offset: offset}
+	if len(products) > first {
+		products, conn.hasNextPage = products[:first], true
+	}
+	conn.products = products
+
+	loader := loaderFrom(ctx)
+	for _, product := range products {
+		loader.Prime(product)
+	}
+	return conn, nil
+}
+
+func (p *apiImpl) totalCount(params graphql.ResolveParams) (interface{}, error) {
+	ctx := params.Context
+	total, err := p.apps.Product.GetTotalProducts(ctx)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.totalCount.GetTotalProducts"}).WithError(err).Error(err)
+		return nil, resolveError(ctx, err)
+	}
+	return *total, nil
+}
+
+func (p *apiImpl) createProduct(params graphql.ResolveParams) (interface{}, error) {
+	ctx := params.Context
+
+	product := parseInput(params)
+	if err := p.validator.Struct(product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.createProduct.validator.Struct"}).WithError(err).Error(err)
+		return nil, invalidInput(ctx, err)
+	}
+
+	id, err := p.apps.Product.SaveProduct(ctx, product)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.createProduct.SaveProduct"}).WithError(err).Error(err)
+		return nil, resolveError(ctx, err)
+	}
+
+	return p.load(ctx, *id, "api.graphql.createProduct.Load")()
+}
+
+func (p *apiImpl) updateProduct(params graphql.ResolveParams) (interface{}, error) {
+	ctx := params.Context
+
+	id, err := parseID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	product := parseInput(params)
+	if err := p.validator.Struct(product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.updateProduct.validator.Struct"}).WithError(err).Error(err)
+		return nil, invalidInput(ctx, err)
+	}
+
+	product.ID = id
+	if err := p.apps.Product.UpdateProduct(ctx, product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.updateProduct.UpdateProduct"}).WithError(err).Error(err)
+		return nil, resolveError(ctx, err)
+	}
+
+	loaderFrom(ctx).Clear(id)
+	return p.load(ctx, id, "api.graphql.updateProduct.Load")()
+}
+
+func (p *apiImpl) deleteProduct(params graphql.ResolveParams) (interface{}, error) {
+	ctx := params.Context
+
+	id, err := parseID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.apps.Product.Delete(ctx, id); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.graphql.deleteProduct.Delete"}).WithError(err).Error(err)
+		return nil, resolveError(ctx, err)
+	}
+
+	loaderFrom(ctx).Clear(id)
+	return strconv.FormatInt(id, 10), nil
+}
@@ -0,0 +1,68 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/danilotadeu/products/health"
+	healthModel "github.com/danilotadeu/products/model/health"
+	"github.com/gofiber/fiber/v2"
+)
+
+type apiImpl struct {
+	checker *health.Checker
+}
+
+// NewAPI health function..
+func NewAPI(g fiber.Router, checker *health.Checker) {
+	api := apiImpl{
+		checker: checker,
+	}
+
+	g.Get("/healthz", api.liveness)
+	g.Get("/readyz", api.readiness)
+	g.Get("/health", api.health)
+}
+
+// Liveness godoc
+// @Summary      Liveness probe
+// @Description  the process is alive
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  healthModel.Report
+// @Router       /healthz [get]
+func (h *apiImpl) liveness(c *fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(healthModel.Report{Status: healthModel.StatusOK})
+}
+
+// Readiness godoc
+// @Summary      Readiness probe
+// @Description  the dependencies are healthy and the service is not shutting down
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  healthModel.Report
+// @Failure      503  {object}  healthModel.Report
+// @Router       /readyz [get]
+func (h *apiImpl) readiness(c *fiber.Ctx) error {
+	report := h.checker.Report(c.UserContext())
+	return c.Status(statusCode(report)).JSON(healthModel.Report{Status: report.Status})
+}
+
+// Health godoc
+// @Summary      Health report
+// @Description  status and latency of every dependency
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  healthModel.Report
+// @Failure      503  {object}  healthModel.Report
+// @Router       /health [get]
+func (h *apiImpl) health(c *fiber.Ctx) error {
+	report := h.checker.Report(c.UserContext())
+	return c.Status(statusCode(report)).JSON(report)
+}
+
+func statusCode(report healthModel.Report) int {
+	if report.Status != healthModel.StatusOK {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,266 @@
+// Package media serves the files attached to a product, under the routes of the product
+package media
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/danilotadeu/products/app"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// formField is the multipart field of the uploaded file
+	formField = "file"
+	// cacheControl lets the clients keep the content, a media is never changed, only deleted
+	cacheControl = "public, max-age=31536000, immutable"
+)
+
+type apiImpl struct {
+	apps *app.Container
+}
+
+// NewAPI registers the media routes in g, the group of the products
+func NewAPI(g fiber.Router, apps *app.Container) {
+	api := apiImpl{
+		apps: apps,
+	}
+
+	g.Get("/:id/media", api.medias)
+	g.Post("/:id/media", api.mediaUpload)
+	g.Get("/:id/media/:mediaID", api.mediaContent)
+	g.Delete("/:id/media/:mediaID", api.mediaDelete)
+}
+
+// UploadMedia godoc
+// @Summary      Attach a file to a product
+// @Description  upload an image (jpeg, png, gif, webp) or a pdf, the type is detected from the content
+// @Tags         media
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path      int   true  "Product ID"
+// @Param        file  formData  file  true  "File"
+// @Success      201  {object}  mediaModel.MediaDB
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      413  {object}  errorsP.Problem
+// @Failure      415  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Router       /api/products/{id}/media [post]
+func (p *apiImpl) mediaUpload(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	productID, err := parseID(c, "id")
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaUpload.parseID"}).WithError(err).Error(err)
+		return err
+	}
+
+	header, err := c.FormFile(formField)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaUpload.FormFile"}).WithError(err).Error(err)
+		return errorsP.ErrBadRequest.WithDetail("detail.media_missing_file", formField)
+	}
+	file, err := header.Open()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaUpload.Open"}).WithError(err).Error(err)
+		return err
+	}
+	defer file.Close()
+
+	media, err := p.apps.Media.Upload(ctx, productID, header.Filename, file)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaUpload.Upload"}).WithError(err).Error(err)
+		return notFound(err, productID, 0)
+	}
+
+	c.Location(strings.TrimSuffix(c.Path(), "/") + "/" + strconv.FormatInt(media.ID, 10))
+	return c.Status(http.StatusCreated).JSON(media)
+}
+
+// ListMedia godoc
+// @Summary      List the files of a product
+// @Description  the files attached to a product, ordered by id
+// @Tags         media
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  mediaModel.ResponseMedia
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Router       /api/products/{id}/media [get]
+func (p *apiImpl) medias(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	productID, err := parseID(c, "id")
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.medias.parseID"}).WithError(err).Error(err)
+		return err
+	}
+
+	medias, err := p.apps.Media.List(ctx, productID)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.medias.List"}).WithError(err).Error(err)
+		return notFound(err, productID, 0)
+	}
+
+	return c.Status(http.StatusOK).JSON(mediaModel.ResponseMedia{Data: medias})
+}
+
+// ShowMedia godoc
+// @Summary      Download a file of a product
+// @Description  the content of the file, cached by the clients and revalidated by its ETag. With size
+// @Description  an image is resized to fit a square of size pixels, one of the configured renditions
+// @Tags         media
+// @Produce      image/jpeg,image/png,image/gif,image/webp,application/pdf
+// @Param        id       path      int  true   "Product ID"
+// @Param        mediaID  path      int  true   "Media ID"
+// @Param        size     query     int  false  "rendition size"
+// @Success      200
+// @Success      304
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Router       /api/products/{id}/media/{mediaID} [get]
+func (p *apiImpl) mediaContent(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	productID, mediaID, err := parseIDs(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaContent.parseIDs"}).WithError(err).Error(err)
+		return err
+	}
+
+	if c.Query("size") != "" {
+		return p.mediaRendition(c, productID, mediaID)
+	}
+
+	media, content, err := p.apps.Media.Get(ctx, productID, mediaID)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaContent.Get"}).WithError(err).Error(err)
+		return notFound(err, productID, mediaID)
+	}
+
+	if notModified(c, `"`+media.Checksum+`"`) {
+		content.Close()
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, media.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(media.Filename))
+	// the stream is closed by fasthttp once the body is sent
+	return c.Status(http.StatusOK).SendStream(content, int(media.Size))
+}
+
+// mediaRendition serves the image resized to the size query, the renditions are as
+// immutable as the media
+func (p *apiImpl) mediaRendition(c *fiber.Ctx, productID, mediaID int64) error {
+	ctx := c.UserContext()
+	size, err := strconv.Atoi(c.Query("size"))
+	if err != nil {
+		return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_number", "size", c.Query("size"))
+	}
+
+	media, rendition, err := p.apps.Media.GetRendition(ctx, productID, mediaID, size)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaRendition.GetRendition"}).WithError(err).Error(err)
+		return notFound(err, productID, mediaID)
+	}
+
+	if notModified(c, fmt.Sprintf(`"%s-%d"`, media.Checksum, size)) {
+		rendition.Content.Close()
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	extension := strings.TrimPrefix(rendition.ContentType, "image/")
+	filename := ""
+	if media.Filename != "" {
+		filename = fmt.Sprintf("%s-%d.%s", strings.TrimSuffix(media.Filename, path.Ext(media.Filename)), size, extension)
+	}
+	c.Set(fiber.HeaderContentType, rendition.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(filename))
+	return c.Status(http.StatusOK).SendStream(rendition.Content)
+}
+
+// DeleteMedia godoc
+// @Summary      Delete a file of a product
+// @Description  delete the file and its content
+// @Tags         media
+// @Param        id       path      int  true  "Product ID"
+// @Param        mediaID  path      int  true  "Media ID"
+// @Success      204
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Router       /api/products/{id}/media/{mediaID} [delete]
+func (p *apiImpl) mediaDelete(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	productID, mediaID, err := parseIDs(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaDelete.parseIDs"}).WithError(err).Error(err)
+		return err
+	}
+
+	if err := p.apps.Media.Delete(ctx, productID, mediaID); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.media.mediaDelete.Delete"}).WithError(err).Error(err)
+		return notFound(err, productID, mediaID)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func parseID(c *fiber.Ctx, param string) (int64, error) {
+	id, err := strconv.ParseInt(c.Params(param), 10, 64)
+	if err != nil {
+		return 0, errorsP.ErrInvalidID.WithDetail("detail.invalid_id", c.Params(param))
+	}
+	return id, nil
+}
+
+func parseIDs(c *fiber.Ctx) (int64, int64, error) {
+	productID, err := parseID(c, "id")
+	if err != nil {
+		return 0, 0, err
+	}
+	mediaID, err := parseID(c, "mediaID")
+	if err != nil {
+		return 0, 0, err
+	}
+	return productID, mediaID, nil
+}
+
+// notFound details the not found errors of the product or the media, the other errors
+// are returned as is
+func notFound(err error, productID, mediaID int64) error {
+	switch {
+	case errors.Is(err, productModel.ErrorProductNotFound):
+		return productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", productID)
+	case errors.Is(err, mediaModel.ErrorMediaNotFound):
+		return mediaModel.ErrorMediaNotFound.WithDetail("detail.media_not_found", mediaID)
+	}
+	return err
+}
+
+// notModified sets the caching headers of content tagged etag, reporting whether the
+// client already has it
+func notModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+	c.Set(fiber.HeaderETag, etag)
+	return c.Get(fiber.HeaderIfNoneMatch) == etag
+}
+
+// contentDisposition shows the content in the browser, saved with the name it was uploaded with
+func contentDisposition(filename string) string {
+	if filename == "" {
+		return "inline"
+	}
+	return mime.FormatMediaType("inline", map[string]string{"filename": filename})
+}
@@ -0,0 +1,279 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/api/problem"
+	"github.com/danilotadeu/products/app"
+	mockAppMedia "github.com/danilotadeu/products/mock/app/media"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+var media = &mediaModel.MediaDB{
+	ID:          2,
+	ProductID:   1,
+	BlobKey:     "products/1/abc",
+	Filename:    "foto é.png",
+	ContentType: "image/png",
+	Size:        4,
+	Checksum:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	CreatedAt:   time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC),
+}
+
+func newApp(t *testing.T, prepare func(mockMediaApp *mockAppMedia.MockApp)) (*fiber.App, context.Context) {
+	t.Helper()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	mockMediaApp := mockAppMedia.NewMockApp(ctrl)
+	prepare(mockMediaApp)
+
+	router := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+	NewAPI(router.Group("/api/products"), &app.Container{Media: mockMediaApp})
+	return router, ctx
+}
+
+func TestHandlerUpload(t *testing.T) {
+	cases := map[string]struct {
+		InputField         string
+		PrepareMockApp     func(mockMediaApp *mockAppMedia.MockApp)
+		ExpectedStatusCode int
+		ExpectedLocation   string
+	}{
+		"should answer the uploaded media with its location": {
+			InputField: "file",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Upload(gomock.Any(), int64(1), "foto.png", gomock.Any()).DoAndReturn(
+					func(ctx context.Context, productID int64, filename string, content io.Reader) (*mediaModel.MediaDB, error) {
+						body, err := io.ReadAll(content)
+						assert.NilError(t, err)
+						assert.Equal(t, "test", string(body))
+						return media, nil
+					})
+			},
+			ExpectedStatusCode: http.StatusCreated,
+			ExpectedLocation:   "/api/products/1/media/2",
+		},
+		"should require the file field": {
+			InputField:         "image",
+			PrepareMockApp:     func(mockMediaApp *mockAppMedia.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should return with unsupported media type": {
+			InputField: "file",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Upload(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return(nil, mediaModel.ErrorUnsupportedMediaType)
+			},
+			ExpectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		"should return with media too large": {
+			InputField: "file",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Upload(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return(nil, mediaModel.ErrorMediaTooLarge)
+			},
+			ExpectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		"should return with product not found": {
+			InputField: "file",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Upload(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile(cs.InputField, "foto.png")
+			assert.NilError(t, err)
+			_, err = part.Write([]byte("test"))
+			assert.NilError(t, err)
+			assert.NilError(t, form.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/products/1/media", &body).WithContext(ctx)
+			req.Header.Set(fiber.HeaderContentType, form.FormDataContentType())
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			assert.Equal(t, cs.ExpectedLocation, resp.Header.Get(fiber.HeaderLocation))
+		})
+	}
+}
+
+func TestHandlerMedias(t *testing.T) {
+	cases := map[string]struct {
+		InputParamID       string
+		PrepareMockApp     func(mockMediaApp *mockAppMedia.MockApp)
+		ExpectedStatusCode int
+		ExpectedBody       string
+	}{
+		"should list the media of the product": {
+			InputParamID: "1",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().List(gomock.Any(), int64(1)).Return([]*mediaModel.MediaDB{media}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       `{"data":[{"id":2,"product_id":1,"filename":"foto é.png","content_type":"image/png","size":4,"checksum":"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08","created_at":"2024-01-31T12:00:00Z"}]}`,
+		},
+		"should return with invalid id": {
+			InputParamID:       "xpto",
+			PrepareMockApp:     func(mockMediaApp *mockAppMedia.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should return with product not found": {
+			InputParamID: "1",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().List(gomock.Any(), int64(1)).Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/products/"+cs.InputParamID+"/media", nil).WithContext(ctx)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			if cs.ExpectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				assert.NilError(t, err)
+				assert.Equal(t, cs.ExpectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestHandlerContent(t *testing.T) {
+	cases := map[string]struct {
+		InputQuery         string
+		InputIfNoneMatch   string
+		PrepareMockApp     func(mockMediaApp *mockAppMedia.MockApp)
+		ExpectedStatusCode int
+		ExpectedHeaders    map[string]string
+		ExpectedBody       string
+	}{
+		"should serve the content with the cache headers": {
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Get(gomock.Any(), int64(1), int64(2)).Return(media, io.NopCloser(bytes.NewReader([]byte("test"))), nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				fiber.HeaderContentType:        "image/png",
+				fiber.HeaderContentLength:      "4",
+				fiber.HeaderCacheControl:       "public, max-age=31536000, immutable",
+				fiber.HeaderETag:               `"` + media.Checksum + `"`,
+				fiber.HeaderContentDisposition: "inline; filename*=utf-8''foto%20%C3%A9.png",
+			},
+			ExpectedBody: "test",
+		},
+		"should answer not modified to a client with the content": {
+			InputIfNoneMatch: `"` + media.Checksum + `"`,
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Get(gomock.Any(), int64(1), int64(2)).Return(media, io.NopCloser(bytes.NewReader([]byte("test"))), nil)
+			},
+			ExpectedStatusCode: http.StatusNotModified,
+			ExpectedHeaders: map[string]string{
+				fiber.HeaderETag: `"` + media.Checksum + `"`,
+			},
+		},
+		"should return with media not found": {
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Get(gomock.Any(), int64(1), int64(2)).Return(nil, nil, mediaModel.ErrorMediaNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		"should serve a rendition": {
+			InputQuery: "?size=512",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				rendition := &mediaModel.Rendition{Size: 512, ContentType: "image/jpeg", Content: io.NopCloser(bytes.NewReader([]byte("jpeg")))}
+				mockMediaApp.EXPECT().GetRendition(gomock.Any(), int64(1), int64(2), 512).Return(media, rendition, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				fiber.HeaderContentType:        "image/jpeg",
+				fiber.HeaderCacheControl:       "public, max-age=31536000, immutable",
+				fiber.HeaderETag:               `"` + media.Checksum + `-512"`,
+				fiber.HeaderContentDisposition: "inline; filename*=utf-8''foto%20%C3%A9-512.jpeg",
+			},
+			ExpectedBody: "jpeg",
+		},
+		"should return with invalid size": {
+			InputQuery:         "?size=xpto",
+			PrepareMockApp:     func(mockMediaApp *mockAppMedia.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/products/1/media/2"+cs.InputQuery, nil).WithContext(ctx)
+			if cs.InputIfNoneMatch != "" {
+				req.Header.Set(fiber.HeaderIfNoneMatch, cs.InputIfNoneMatch)
+			}
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			for key, value := range cs.ExpectedHeaders {
+				assert.Equal(t, value, resp.Header.Get(key), key)
+			}
+			if cs.ExpectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				assert.NilError(t, err)
+				assert.Equal(t, cs.ExpectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestHandlerDelete(t *testing.T) {
+	cases := map[string]struct {
+		InputPath          string
+		PrepareMockApp     func(mockMediaApp *mockAppMedia.MockApp)
+		ExpectedStatusCode int
+	}{
+		"should delete the media": {
+			InputPath: "/api/products/1/media/2",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Delete(gomock.Any(), int64(1), int64(2)).Return(nil)
+			},
+			ExpectedStatusCode: http.StatusNoContent,
+		},
+		"should return with invalid media id": {
+			InputPath:          "/api/products/1/media/xpto",
+			PrepareMockApp:     func(mockMediaApp *mockAppMedia.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should return with media not found": {
+			InputPath: "/api/products/1/media/2",
+			PrepareMockApp: func(mockMediaApp *mockAppMedia.MockApp) {
+				mockMediaApp.EXPECT().Delete(gomock.Any(), int64(1), int64(2)).Return(mediaModel.ErrorMediaNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodDelete, cs.InputPath, nil).WithContext(ctx)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+		})
+	}
+}
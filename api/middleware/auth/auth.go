@@ -0,0 +1,43 @@
+// Package auth validates the api key sent by the clients in the X-API-Key header
+package auth
+
+import (
+	"github.com/danilotadeu/products/app/apikey"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIKey carries the key created by the key and user commands
+const HeaderAPIKey = "X-API-Key"
+
+// localsAPIKey holds the validated key in the locals of the request
+const localsAPIKey = "auth.apikey"
+
+// New answers 401 (UNAUTHORIZED) for a key that does not exist or was revoked, and for the
+// requests without a key when required. The validated key is read back with APIKey
+func New(apps apikey.App, required bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderAPIKey)
+		if key == "" {
+			if required {
+				return errorsP.ErrUnauthorized.WithDetail("detail.missing_api_key", HeaderAPIKey)
+			}
+			return c.Next()
+		}
+
+		apiKey, err := apps.Authenticate(c.UserContext(), key)
+		if err != nil {
+			return err
+		}
+
+		c.Locals(localsAPIKey, apiKey)
+		return c.Next()
+	}
+}
+
+// APIKey is the key validated by New, nil for an anonymous request
+func APIKey(c *fiber.Ctx) *apiKeyModel.APIKeyDB {
+	apiKey, _ := c.Locals(localsAPIKey).(*apiKeyModel.APIKeyDB)
+	return apiKey
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danilotadeu/products/api/problem"
+	mockAppAPIKey "github.com/danilotadeu/products/mock/app/apikey"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		Required           bool
+		Key                string
+		PrepareMockApp     func(mockAPIKeyApp *mockAppAPIKey.MockApp)
+		ExpectedStatusCode int
+		ExpectedOwner      string
+	}{
+		"should let the anonymous requests through when the key is optional": {
+			PrepareMockApp:     func(mockAPIKeyApp *mockAppAPIKey.MockApp) {},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"should reject the requests without a key when it is required": {
+			Required:           true,
+			PrepareMockApp:     func(mockAPIKeyApp *mockAppAPIKey.MockApp) {},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		"should keep the validated key": {
+			Required: true,
+			Key:      "pk_valid",
+			PrepareMockApp: func(mockAPIKeyApp *mockAppAPIKey.MockApp) {
+				mockAPIKeyApp.EXPECT().Authenticate(gomock.Any(), "pk_valid").Return(&apiKeyModel.APIKeyDB{ID: 1, Owner: "ci"}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedOwner:      "ci",
+		},
+		"should reject an invalid key even when it is optional": {
+			Key: "pk_revoked",
+			PrepareMockApp: func(mockAPIKeyApp *mockAppAPIKey.MockApp) {
+				mockAPIKeyApp.EXPECT().Authenticate(gomock.Any(), "pk_revoked").Return(nil, apiKeyModel.ErrorAPIKeyInvalid)
+			},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		"should throw error when the key cannot be checked": {
+			Key: "pk_valid",
+			PrepareMockApp: func(mockAPIKeyApp *mockAppAPIKey.MockApp) {
+				mockAPIKeyApp.EXPECT().Authenticate(gomock.Any(), "pk_valid").Return(nil, fmt.Errorf("error"))
+			},
+			ExpectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, _ := gomock.WithContext(context.Background(), t)
+			mockAPIKeyApp := mockAppAPIKey.NewMockApp(ctrl)
+			cs.PrepareMockApp(mockAPIKeyApp)
+
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+			app.Use(New(mockAPIKeyApp, cs.Required))
+			app.Get("/products", func(c *fiber.Ctx) error {
+				if apiKey := APIKey(c); apiKey != nil {
+					return c.SendString(apiKey.Owner)
+				}
+				return c.SendStatus(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			if cs.Key != "" {
+				req.Header.Set(HeaderAPIKey, cs.Key)
+			}
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+
+			if cs.ExpectedOwner != "" {
+				body, err := io.ReadAll(resp.Body)
+				assert.NilError(t, err)
+				assert.Equal(t, cs.ExpectedOwner, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package disconnect cancels the context of a request when its client goes away. fasthttp never
+// does, so without it the queries of a request nobody waits for run until their timeout
+package disconnect
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New cancels the user context of the request, which then answers 499 (REQUEST_CANCELLED), once
+// the client closes its connection. It must run after the middlewares setting the user context.
+// The connections that cannot be watched, as the ones of app.Test, are never cancelled
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(c.UserContext())
+		defer cancel()
+
+		stop := watch(c.Context().Conn(), cancel)
+		c.SetUserContext(ctx)
+		err := c.Next()
+		// the connection is read by fasthttp again once the handler returns
+		stop()
+		return err
+	}
+}
@@ -0,0 +1,97 @@
+package disconnect
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gotest.tools/v3/assert"
+)
+
+// serve listens on a real tcp port, the connections of app.Test cannot be watched
+func serve(t *testing.T, handler fiber.Handler) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(New())
+	app.Get("/", handler)
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	return ln.Addr().String()
+}
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		CloseClient bool
+		ExpectedErr error
+	}{
+		"should cancel the request when the client goes away": {
+			CloseClient: true,
+			ExpectedErr: context.Canceled,
+		},
+		"should keep the request of a client still waiting": {},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			started := make(chan struct{}, 1)
+			result := make(chan error, 1)
+			addr := serve(t, func(c *fiber.Ctx) error {
+				started <- struct{}{}
+				// as a query interrupted by its context
+				select {
+				case <-c.UserContext().Done():
+					result <- c.UserContext().Err()
+				case <-time.After(200 * time.Millisecond):
+					result <- nil
+				}
+				return c.SendStatus(http.StatusOK)
+			})
+
+			conn, err := net.Dial("tcp", addr)
+			assert.NilError(t, err)
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: products\r\n\r\n"))
+			assert.NilError(t, err)
+			<-started
+			if cs.CloseClient {
+				conn.Close()
+			}
+
+			select {
+			case err := <-result:
+				assert.Equal(t, cs.ExpectedErr, err)
+			case <-time.After(time.Second):
+				t.Fatal("the handler did not end")
+			}
+			if cs.CloseClient {
+				return
+			}
+
+			// the connection is still served after the watch
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, nil)
+			assert.NilError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			_, err = io.Copy(io.Discard, resp.Body)
+			assert.NilError(t, err)
+
+			_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: products\r\n\r\n"))
+			assert.NilError(t, err)
+			<-started
+			assert.NilError(t, <-result)
+			resp, err = http.ReadResponse(reader, nil)
+			assert.NilError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
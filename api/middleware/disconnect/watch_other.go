@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package disconnect
+
+import "net"
+
+// watch is a no-op where the socket cannot be peeked, the requests run until they end
+func watch(conn net.Conn, closed func()) (stop func()) {
+	return func() {}
+}
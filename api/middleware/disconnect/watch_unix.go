@@ -0,0 +1,57 @@
+//go:build linux || darwin
+
+package disconnect
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// watch peeks the socket of conn while the handler runs, nothing is read from it. An end of
+// file or a reset calls closed; a pipelined request ends the watch, since the client is
+// still there. stop ends the watch and waits for it
+func watch(conn net.Conn, closed func()) (stop func()) {
+	sysConn, ok := conn.(interface {
+		syscall.Conn
+		SetReadDeadline(t time.Time) error
+	})
+	if !ok {
+		return func() {}
+	}
+	rawConn, err := sysConn.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		buf := make([]byte, 1)
+		gone := false
+		// the callback answers false to wait until the socket is readable, the read deadline set
+		// by stop interrupts the wait
+		_ = rawConn.Read(func(fd uintptr) bool {
+			n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+			switch {
+			case errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR):
+				return false
+			case err != nil || n == 0:
+				gone = true
+			}
+			return true
+		})
+		if gone {
+			closed()
+		}
+	}()
+
+	return func() {
+		// fasthttp sets its own deadline before reading the next request
+		_ = sysConn.SetReadDeadline(time.Now())
+		<-done
+		_ = sysConn.SetReadDeadline(time.Time{})
+	}
+}
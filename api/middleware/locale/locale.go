@@ -0,0 +1,19 @@
+// Package locale negotiates the language of each request from its Accept-Language header
+package locale
+
+import (
+	"github.com/danilotadeu/products/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// New stores the negotiated locale in the user context and answers it in Content-Language,
+// the requests asking for no supported language get i18n.Default
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+		c.SetUserContext(i18n.WithLocale(c.UserContext(), locale))
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package locale
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danilotadeu/products/i18n"
+	"github.com/gofiber/fiber/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		AcceptLanguage string
+		ExpectedLocale string
+	}{
+		"should answer the default without the header": {
+			ExpectedLocale: i18n.PortugueseBR,
+		},
+		"should match the region to the language": {
+			AcceptLanguage: "en-US",
+			ExpectedLocale: i18n.English,
+		},
+		"should follow the weights": {
+			AcceptLanguage: "fr;q=1, es-MX;q=0.8, en;q=0.5",
+			ExpectedLocale: i18n.Spanish,
+		},
+		"should fall back to the default for the unsupported languages": {
+			AcceptLanguage: "ja, de",
+			ExpectedLocale: i18n.PortugueseBR,
+		},
+		"should fall back to the default for an invalid header": {
+			AcceptLanguage: ";;q=x",
+			ExpectedLocale: i18n.PortugueseBR,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(New())
+			app.Get("/", func(c *fiber.Ctx) error {
+				return c.SendString(i18n.Locale(c.UserContext()))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if cs.AcceptLanguage != "" {
+				req.Header.Set(fiber.HeaderAcceptLanguage, cs.AcceptLanguage)
+			}
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedLocale, resp.Header.Get(fiber.HeaderContentLanguage))
+			assert.Equal(t, fiber.HeaderAcceptLanguage, resp.Header.Get(fiber.HeaderVary))
+		})
+	}
+}
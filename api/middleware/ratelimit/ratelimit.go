@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danilotadeu/products/api/middleware/auth"
+	"github.com/danilotadeu/products/config"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// the api key is the one validated by the auth middleware, which must run first, the
+// requests without one fall back to ip
+const (
+	// KeyByIP identifies clients by their remote address
+	KeyByIP = "ip"
+	// KeyByAPIKey identifies clients by their api key
+	KeyByAPIKey = "apikey"
+	// KeyByTenant identifies clients by the owner of their api key, sharing the budget of its keys
+	KeyByTenant = "tenant"
+)
+
+// Config is the rate limit configuration, Auth is the budget per ip of the requests sending
+// an api key, taken before the key is looked up
+type Config struct {
+	Enabled bool
+	KeyBy   string
+	Read    Limit
+	Write   Limit
+	Auth    Limit
+	Store   Store
+}
+
+// NewConfig maps the rate limit settings to the budgets, the limiters of a Config share its store
+func NewConfig(cfg config.RateLimit) Config {
+	return Config{
+		Enabled: cfg.Enabled,
+		KeyBy:   cfg.KeyBy,
+		Read:    Limit{Rate: cfg.ReadRate, Burst: cfg.ReadBurst},
+		Write:   Limit{Rate: cfg.WriteRate, Burst: cfg.WriteBurst},
+		Auth:    Limit{Rate: cfg.AuthRate, Burst: cfg.AuthBurst},
+		Store:   NewMemoryStore(),
+	}
+}
+
+// New creates the rate limit middleware
+func New(cfg Config) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+
+	return func(c *fiber.Ctx) error {
+		class, limit := "write", cfg.Write
+		if isRead(c.Method()) {
+			class, limit = "read", cfg.Read
+		}
+
+		return take(c, cfg.Store, class+":"+ClientKey(cfg.KeyBy, auth.APIKey(c), c.IP()), limit)
+	}
+}
+
+// Authentications limits per ip the requests sending an api key, it runs before the auth
+// middleware so the made-up keys are throttled without reaching the database
+func Authentications(cfg Config) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Get(auth.HeaderAPIKey) == "" {
+			return c.Next()
+		}
+
+		return take(c, cfg.Store, AuthenticationKey(c.IP()), cfg.Auth)
+	}
+}
+
+// take answers 429 (RATE_LIMITED) when the bucket of key is empty, a failing store lets the
+// request through
+func take(c *fiber.Ctx, store Store, key string, limit Limit) error {
+	result, err := store.Take(c.Context(), key, limit)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"trace": "api.middleware.ratelimit.Store.Take"}).WithError(err).Error(err)
+		return c.Next()
+	}
+
+	c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+		return errorsP.ErrRateLimited.WithDetail("detail.rate_limited", retryAfter)
+	}
+
+	return c.Next()
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// ClientKey trusts only the keys validated by the auth middleware, a header anyone can make
+// up would give each request a fresh budget. apiKey is nil for the anonymous requests
+func ClientKey(keyBy string, apiKey *apiKeyModel.APIKeyDB, ip string) string {
+	if apiKey != nil {
+		switch keyBy {
+		case KeyByAPIKey:
+			return "apikey:" + strconv.FormatInt(apiKey.ID, 10)
+		case KeyByTenant:
+			return "tenant:" + apiKey.Owner
+		}
+	}
+
+	return "ip:" + ip
+}
+
+// AuthenticationKey is the bucket of the Auth budget of ip
+func AuthenticationKey(ip string) string {
+	return "auth:ip:" + ip
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,232 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/api/middleware/auth"
+	"github.com/danilotadeu/products/api/problem"
+	mockAppAPIKey "github.com/danilotadeu/products/mock/app/apikey"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	cases := map[string]struct {
+		Limit             Limit
+		Takes             int
+		Elapsed           time.Duration
+		ExpectedAllowed   bool
+		ExpectedRemaining int
+	}{
+		"should allow while there are tokens": {
+			Limit:             Limit{Rate: 1, Burst: 3},
+			Takes:             2,
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 0,
+		},
+		"should deny when the bucket is empty": {
+			Limit:             Limit{Rate: 1, Burst: 3},
+			Takes:             3,
+			ExpectedAllowed:   false,
+			ExpectedRemaining: 0,
+		},
+		"should refill the bucket over time": {
+			Limit:             Limit{Rate: 1, Burst: 3},
+			Takes:             3,
+			Elapsed:           2 * time.Second,
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 1,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			store := &memoryStore{
+				buckets: map[string]*bucket{},
+				now:     func() time.Time { return now },
+			}
+
+			for i := 0; i < cs.Takes; i++ {
+				_, err := store.Take(context.Background(), "client", cs.Limit)
+				assert.NilError(t, err)
+			}
+
+			now = now.Add(cs.Elapsed)
+			result, err := store.Take(context.Background(), "client", cs.Limit)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedAllowed, result.Allowed)
+			assert.Equal(t, cs.ExpectedRemaining, result.Remaining)
+			if !result.Allowed {
+				assert.Assert(t, result.RetryAfter > 0)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cases := map[string]struct {
+		Method             string
+		Requests           int
+		Headers            map[string]string
+		ExpectedStatusCode int
+		ExpectedRemaining  string
+	}{
+		"should allow reads inside the read budget": {
+			Method:             http.MethodGet,
+			Requests:           2,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedRemaining:  "0",
+		},
+		"should reject reads above the read budget": {
+			Method:             http.MethodGet,
+			Requests:           3,
+			ExpectedStatusCode: http.StatusTooManyRequests,
+			ExpectedRemaining:  "0",
+		},
+		"should reject writes above the write budget": {
+			Method:             http.MethodPost,
+			Requests:           2,
+			ExpectedStatusCode: http.StatusTooManyRequests,
+			ExpectedRemaining:  "0",
+		},
+		"should keep a budget per api key": {
+			Method:             http.MethodPost,
+			Requests:           1,
+			Headers:            map[string]string{auth.HeaderAPIKey: "pk_valid"},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedRemaining:  "0",
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, _ := gomock.WithContext(context.Background(), t)
+			mockAPIKeyApp := mockAppAPIKey.NewMockApp(ctrl)
+			mockAPIKeyApp.EXPECT().Authenticate(gomock.Any(), "pk_valid").Return(&apiKeyModel.APIKeyDB{ID: 1, Owner: "ci"}, nil).AnyTimes()
+
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+			app.Use(auth.New(mockAPIKeyApp, false), New(Config{
+				Enabled: true,
+				KeyBy:   KeyByAPIKey,
+				Read:    Limit{Rate: 0.01, Burst: 2},
+				Write:   Limit{Rate: 0.01, Burst: 1},
+			}))
+			app.All("/products", func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// the budget is shared by clients without api key
+			if len(cs.Headers) > 0 {
+				req := httptest.NewRequest(cs.Method, "/products", nil)
+				_, err := app.Test(req, -1)
+				assert.NilError(t, err)
+			}
+
+			var resp *http.Response
+			for i := 0; i < cs.Requests; i++ {
+				req := httptest.NewRequest(cs.Method, "/products", nil)
+				for key, value := range cs.Headers {
+					req.Header.Set(key, value)
+				}
+				var err error
+				resp, err = app.Test(req, -1)
+				assert.NilError(t, err)
+			}
+
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			assert.Equal(t, cs.ExpectedRemaining, resp.Header.Get("RateLimit-Remaining"))
+			if cs.ExpectedStatusCode == http.StatusTooManyRequests {
+				assert.Assert(t, resp.Header.Get(fiber.HeaderRetryAfter) != "")
+			}
+		})
+	}
+}
+
+func TestAuthentications(t *testing.T) {
+	ctrl, _ := gomock.WithContext(context.Background(), t)
+	mockAPIKeyApp := mockAppAPIKey.NewMockApp(ctrl)
+	// the third key is refused before it is looked up
+	mockAPIKeyApp.EXPECT().Authenticate(gomock.Any(), gomock.Any()).Return(nil, apiKeyModel.ErrorAPIKeyInvalid).Times(2)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+	app.Use(Authentications(Config{Enabled: true, Auth: Limit{Rate: 0.01, Burst: 2}}), auth.New(mockAPIKeyApp, false))
+	app.Get("/products", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	for idx, expected := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set(auth.HeaderAPIKey, fmt.Sprintf("pk_made_up_%d", idx))
+		resp, err := app.Test(req, -1)
+		assert.NilError(t, err)
+		assert.Equal(t, expected, resp.StatusCode)
+	}
+
+	// the anonymous requests are left to the budgets of New
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/products", nil), -1)
+	assert.NilError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestClientKey(t *testing.T) {
+	cases := map[string]struct {
+		KeyBy         string
+		Authenticated bool
+		ExpectedKey   string
+	}{
+		"should key by ip": {
+			KeyBy:         KeyByIP,
+			Authenticated: true,
+			ExpectedKey:   "ip:0.0.0.0",
+		},
+		"should key by the validated api key": {
+			KeyBy:         KeyByAPIKey,
+			Authenticated: true,
+			ExpectedKey:   "apikey:1",
+		},
+		"should key by the owner of the validated api key": {
+			KeyBy:         KeyByTenant,
+			Authenticated: true,
+			ExpectedKey:   "tenant:ci",
+		},
+		"should not trust an api key that was not validated": {
+			KeyBy:       KeyByAPIKey,
+			ExpectedKey: "ip:0.0.0.0",
+		},
+		"should not trust a tenant that was not validated": {
+			KeyBy:       KeyByTenant,
+			ExpectedKey: "ip:0.0.0.0",
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, _ := gomock.WithContext(context.Background(), t)
+			mockAPIKeyApp := mockAppAPIKey.NewMockApp(ctrl)
+			mockAPIKeyApp.EXPECT().Authenticate(gomock.Any(), "pk_valid").Return(&apiKeyModel.APIKeyDB{ID: 1, Owner: "ci"}, nil).AnyTimes()
+
+			app := fiber.New()
+			if cs.Authenticated {
+				app.Use(auth.New(mockAPIKeyApp, false))
+			}
+			app.Get("/", func(c *fiber.Ctx) error {
+				return c.SendString(ClientKey(cs.KeyBy, auth.APIKey(c), c.IP()))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(auth.HeaderAPIKey, "pk_valid")
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedKey, string(body))
+		})
+	}
+}
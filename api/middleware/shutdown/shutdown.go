@@ -0,0 +1,19 @@
+// Package shutdown interrupts the requests still running when the server gives up draining them.
+// fasthttp never cancels the context of a request, the disconnect middleware does it when its
+// client goes away
+package shutdown
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New makes ctx the parent of the user context of every request, cancelling it stops the
+// queries of the handlers still running, which answer 499 (REQUEST_CANCELLED)
+func New(ctx context.Context) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package shutdown
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danilotadeu/products/api/problem"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/gofiber/fiber/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestNew(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+	app.Use(New(ctx))
+	app.Get("/", func(c *fiber.Ctx) error {
+		close(started)
+		// as a query interrupted by its context
+		<-c.UserContext().Done()
+		return c.UserContext().Err()
+	})
+
+	go func() {
+		<-started
+		cancel()
+	}()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil), -1)
+	assert.NilError(t, err)
+	assert.Equal(t, errorsP.StatusClientClosedRequest, resp.StatusCode)
+}
@@ -0,0 +1,68 @@
+// Package version selects the version of the api by path or Accept header and announces
+// the deprecation of the old ones
+package version
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// MIMEV1 and MIMEV2 select the version of an unversioned path in the Accept header
+	MIMEV1 = "application/vnd.products.v1+json"
+	MIMEV2 = "application/vnd.products.v2+json"
+
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+)
+
+// Negotiate serves the requests to prefix without a version, which are v1, from prefix/v2
+// when their Accept header asks for MIMEV2. An explicit version in the path always wins
+func Negotiate(prefix string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !strings.HasPrefix(c.Path(), prefix+"/") {
+			return c.Next()
+		}
+		rest := strings.TrimPrefix(c.Path(), prefix+"/")
+		if strings.HasPrefix(rest, "v1/") || strings.HasPrefix(rest, "v2/") {
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderAccept)
+		if accepts(c.Get(fiber.HeaderAccept), MIMEV2) {
+			// the versions share the routes of prefix, so the stack goes on from here
+			c.Path(prefix + "/v2/" + rest)
+		}
+		return c.Next()
+	}
+}
+
+// Deprecate announces in every response that the version is deprecated since deprecation
+// (RFC 9745), stops answering at sunset (RFC 8594) and is replaced by successor
+func Deprecate(deprecation, sunset time.Time, successor string) fiber.Handler {
+	deprecationValue := "@" + strconv.FormatInt(deprecation.Unix(), 10)
+	sunsetValue := sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+	return func(c *fiber.Ctx) error {
+		c.Set(HeaderDeprecation, deprecationValue)
+		c.Set(HeaderSunset, sunsetValue)
+		c.Append(fiber.HeaderLink, link)
+		return c.Next()
+	}
+}
+
+// accepts tells whether one of the media ranges of the Accept header is mime
+func accepts(accept, mime string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), mime) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package version
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]struct {
+		Path            string
+		Accept          string
+		ExpectedVersion string
+	}{
+		"should serve v1 without a version": {
+			Path:            "/api/products",
+			Accept:          fiber.MIMEApplicationJSON,
+			ExpectedVersion: "v1",
+		},
+		"should serve v2 when accepted": {
+			Path:            "/api/products/1",
+			Accept:          "text/html, " + MIMEV2 + ";q=0.9",
+			ExpectedVersion: "v2",
+		},
+		"should serve v1 when accepted": {
+			Path:            "/api/products",
+			Accept:          MIMEV1,
+			ExpectedVersion: "v1",
+		},
+		"should prefer the version of the path": {
+			Path:            "/api/v1/products",
+			Accept:          MIMEV2,
+			ExpectedVersion: "v1",
+		},
+		"should serve v2 by path": {
+			Path:            "/api/v2/products",
+			ExpectedVersion: "v2",
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(Negotiate("/api"))
+			v1 := func(c *fiber.Ctx) error { return c.SendString("v1") }
+			v2 := func(c *fiber.Ctx) error { return c.SendString("v2") }
+			app.Get("/api/products", v1)
+			app.Get("/api/products/:id", v1)
+			app.Get("/api/v1/products", v1)
+			app.Get("/api/v2/products", v2)
+			app.Get("/api/v2/products/:id", v2)
+
+			req := httptest.NewRequest(http.MethodGet, cs.Path, nil)
+			req.Header.Set(fiber.HeaderAccept, cs.Accept)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedVersion, string(body))
+		})
+	}
+}
+
+func TestDeprecate(t *testing.T) {
+	deprecation := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	app := fiber.New()
+	app.Use(Deprecate(deprecation, sunset, "/api/v2/products"))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil), -1)
+	assert.NilError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "@1792368000", resp.Header.Get(HeaderDeprecation))
+	assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", resp.Header.Get(HeaderSunset))
+	assert.Equal(t, `</api/v2/products>; rel="successor-version"`, resp.Header.Get(fiber.HeaderLink))
+}
@@ -0,0 +1,76 @@
+// Package problem answers the errors returned by the handlers as application/problem+json (RFC 7807)
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/danilotadeu/products/i18n"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// byStatus maps the errors raised by fiber itself to the catalogue
+var byStatus = map[int]*errorsP.Error{
+	http.StatusBadRequest:       errorsP.ErrBadRequest,
+	http.StatusNotFound:         errorsP.ErrRouteNotFound,
+	http.StatusMethodNotAllowed: errorsP.ErrMethodNotAllowed,
+}
+
+// NewAPI describes the types of the problems
+func NewAPI(g fiber.Router) {
+	g.Get("/:type", describe)
+}
+
+// Handler is the fiber.ErrorHandler of the api, the only place an error becomes a status.
+// The problem is answered in the locale negotiated for the request
+func Handler(c *fiber.Ctx, err error) error {
+	ctx := c.UserContext()
+	e := From(err)
+	if e.Status >= http.StatusInternalServerError {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.problem.Handler", "code": e.Code}).WithError(err).Error(err)
+	}
+
+	err = c.Status(e.Status).JSON(e.Problem(i18n.Locale(ctx), c.OriginalURL()))
+	c.Set(fiber.HeaderContentType, errorsP.MIMEProblemJSON)
+	return err
+}
+
+// From finds the entry of the catalogue of err with errorsP.From, the errors raised by fiber
+// itself keep their status
+func From(err error) *errorsP.Error {
+	var e *errorsP.Error
+	var fiberErr *fiber.Error
+	if !errors.As(err, &e) && errors.As(err, &fiberErr) {
+		if e, ok := byStatus[fiberErr.Code]; ok {
+			return e.WithDetail("%s", fiberErr.Message)
+		}
+		return &errorsP.Error{
+			Code:   strings.ToUpper(strings.ReplaceAll(http.StatusText(fiberErr.Code), " ", "_")),
+			Status: fiberErr.Code,
+			Title:  http.StatusText(fiberErr.Code),
+			Detail: fiberErr.Message,
+		}
+	}
+	return errorsP.From(err)
+}
+
+// Describe problem
+// @Summary      Describe a problem type
+// @Description  the code, status and title of an error of the catalogue
+// @Tags         problems
+// @Produce      json
+// @Param        type   path      string  true  "Problem type"
+// @Success      200  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Router       /problems/{type} [get]
+func describe(c *fiber.Ctx) error {
+	for _, e := range errorsP.Catalogue {
+		if e.Type() == c.Params("type") {
+			return c.Status(http.StatusOK).JSON(e.Problem(i18n.Locale(c.UserContext()), ""))
+		}
+	}
+	return errorsP.ErrProblemNotFound.WithDetail("detail.problem_not_found", errorsP.TypeBase+c.Params("type"))
+}
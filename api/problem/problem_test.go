@@ -0,0 +1,182 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danilotadeu/products/api/middleware/locale"
+	"github.com/danilotadeu/products/i18n"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/gofiber/fiber/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestHandler(t *testing.T) {
+	cases := map[string]struct {
+		Path            string
+		AcceptLanguage  string
+		Err             error
+		ExpectedProblem errorsP.Problem
+	}{
+		"should answer the entry of the error": {
+			Path: "/products/1",
+			Err:  productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", 1),
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/product-not-found",
+				Title:    "Produto não encontrado",
+				Status:   http.StatusNotFound,
+				Code:     "PRODUCT_NOT_FOUND",
+				Detail:   "o produto 1 não existe",
+				Instance: "/products/1",
+			},
+		},
+		"should answer in the language of the client": {
+			Path:           "/products/1",
+			AcceptLanguage: "en-US,en;q=0.9",
+			Err:            productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", 1),
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/product-not-found",
+				Title:    "Product not found",
+				Status:   http.StatusNotFound,
+				Code:     "PRODUCT_NOT_FOUND",
+				Detail:   "the product 1 does not exist",
+				Instance: "/products/1",
+			},
+		},
+		"should find the entry of a wrapped error": {
+			Path: "/products",
+			Err:  fmt.Errorf("save: %w", productModel.ErrorProductAlreadyExists),
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/product-already-exists",
+				Title:    "Produto já cadastrado",
+				Status:   http.StatusConflict,
+				Code:     "PRODUCT_ALREADY_EXISTS",
+				Instance: "/products",
+			},
+		},
+		"should answer client closed request when cancelled": {
+			Path: "/products",
+			Err:  context.Canceled,
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/request-cancelled",
+				Title:    "A requisição foi cancelada",
+				Status:   errorsP.StatusClientClosedRequest,
+				Code:     "REQUEST_CANCELLED",
+				Instance: "/products",
+			},
+		},
+		"should answer timeout when the statement expires": {
+			Path: "/products",
+			Err:  fmt.Errorf("%w: interrupted", context.DeadlineExceeded),
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/query-timeout",
+				Title:    "O tempo limite da consulta foi excedido",
+				Status:   http.StatusGatewayTimeout,
+				Code:     "QUERY_TIMEOUT",
+				Instance: "/products",
+			},
+		},
+		"should map the errors of fiber": {
+			Path: "/products",
+			Err:  fiber.ErrMethodNotAllowed,
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/method-not-allowed",
+				Title:    "Método não permitido",
+				Status:   http.StatusMethodNotAllowed,
+				Code:     "METHOD_NOT_ALLOWED",
+				Detail:   "Method Not Allowed",
+				Instance: "/products",
+			},
+		},
+		"should derive the code of the other statuses": {
+			Path: "/products",
+			Err:  fiber.ErrRequestEntityTooLarge,
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/request-entity-too-large",
+				Title:    "Request Entity Too Large",
+				Status:   http.StatusRequestEntityTooLarge,
+				Code:     "REQUEST_ENTITY_TOO_LARGE",
+				Detail:   "Request Entity Too Large",
+				Instance: "/products",
+			},
+		},
+		"should hide the unexpected errors": {
+			Path: "/products?page=1",
+			Err:  fmt.Errorf("dial tcp: connection refused"),
+			ExpectedProblem: errorsP.Problem{
+				Type:     "/problems/internal",
+				Title:    "Aconteceu um erro interno",
+				Status:   http.StatusInternalServerError,
+				Code:     "INTERNAL",
+				Instance: "/products?page=1",
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: Handler})
+			app.Use(locale.New(), func(c *fiber.Ctx) error {
+				return cs.Err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, cs.Path, nil)
+			req.Header.Set(fiber.HeaderAcceptLanguage, cs.AcceptLanguage)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedProblem.Status, resp.StatusCode)
+			assert.Equal(t, errorsP.MIMEProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+
+			var problem errorsP.Problem
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&problem))
+			assert.DeepEqual(t, cs.ExpectedProblem, problem)
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: Handler})
+	NewAPI(app.Group("/problems"))
+
+	for _, e := range errorsP.Catalogue {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, errorsP.TypeBase+e.Type(), nil), -1)
+		assert.NilError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var problem errorsP.Problem
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, e.Code, problem.Code)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, errorsP.TypeBase+"xpto", nil), -1)
+	assert.NilError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCatalogueIsTranslated(t *testing.T) {
+	for _, locale := range i18n.Locales {
+		keys := map[string]bool{}
+		for _, key := range i18n.Keys(locale) {
+			keys[key] = true
+		}
+		for _, e := range errorsP.Catalogue {
+			assert.Assert(t, keys["error."+e.Code], "%s has no title in %s", e.Code, locale)
+		}
+	}
+	for _, e := range errorsP.Catalogue {
+		title, _ := i18n.Lookup(i18n.PortugueseBR, "error."+e.Code)
+		assert.Equal(t, e.Title, title)
+	}
+}
+
+func TestCatalogueCodesAreUnique(t *testing.T) {
+	codes := map[string]bool{}
+	for _, e := range errorsP.Catalogue {
+		assert.Assert(t, !codes[e.Code], "%s is repeated", e.Code)
+		codes[e.Code] = true
+	}
+}
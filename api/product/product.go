@@ -2,7 +2,6 @@ package product
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -10,6 +9,7 @@ import (
 	errorsP "github.com/danilotadeu/products/model/errors_handler"
 	genericModel "github.com/danilotadeu/products/model/generic"
 	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
@@ -28,6 +28,7 @@ func NewAPI(g fiber.Router, apps *app.Container, validate *validator.Validate) {
 	}
 
 	g.Get("/", api.products)
+	g.Get("/by-barcode/:code", api.productByBarcode)
 	g.Get("/:id", api.product)
 	g.Delete("/:id", api.productDelete)
 	g.Post("/", api.productCreate)
@@ -42,35 +43,30 @@ func NewAPI(g fiber.Router, apps *app.Container, validate *validator.Validate) {
 // @Produce      json
 // @Param product   body productModel.ProductDB true "Request Product"
 // @Success      200  {object}  productModel.ProductDB
-// @Failure      400  {object}  errorsP.ErrorsResponse
-// @Failure      404  {object}  errorsP.ErrorsResponse
-// @Failure      500  {object}  errorsP.ErrorsResponse
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      409  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
 // @Router       /api/products [post]
 // productCreate is a handle to create products
 func (p *apiImpl) productCreate(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	request := productModel.ProductDB{}
 	if err := c.BodyParser(&request); err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.create.BodyParser"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: err.Error(),
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.create.BodyParser"}).WithError(err).Error(err)
+		return errorsP.ErrBadRequest.WithDetail("%s", err.Error())
 	}
 
 	err := p.validator.Struct(request)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.create.validator.Struct"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: err.Error(),
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.create.validator.Struct"}).WithError(err).Error(err)
+		return validation.Error(ctx, err)
 	}
 
 	result, err := p.apps.Product.SaveProduct(ctx, request)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.create.Create"}).Error(err)
-		return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-			Message: "aconteceu um erro interno",
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.create.Create"}).WithError(err).Error(err)
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(productModel.ProductDB{ID: *result})
@@ -84,44 +80,38 @@ func (p *apiImpl) productCreate(c *fiber.Ctx) error {
 // @Produce      json
 // @Param product   body productModel.ProductDB true "Request Product"
 // @Success      200  {object}  productModel.ProductDB
-// @Failure      400  {object}  errorsP.ErrorsResponse
-// @Failure      404  {object}  errorsP.ErrorsResponse
-// @Failure      500  {object}  errorsP.ErrorsResponse
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      409  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
 // @Router       /api/products/{id} [put]
 // productUpdate is a handle to update products
 func (p *apiImpl) productUpdate(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.ParseInt"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: "aconteceu um erro interno",
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.ParseInt"}).WithError(err).Error(err)
+		return errorsP.ErrInvalidID.WithDetail("detail.invalid_id", c.Params("id"))
 	}
 
 	request := productModel.ProductDB{}
 	if err := c.BodyParser(&request); err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.BodyParser"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: err.Error(),
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.BodyParser"}).WithError(err).Error(err)
+		return errorsP.ErrBadRequest.WithDetail("%s", err.Error())
 	}
 
 	err = p.validator.Struct(request)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.validator.Struct"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: err.Error(),
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.validator.Struct"}).WithError(err).Error(err)
+		return validation.Error(ctx, err)
 	}
 
 	request.ID = id
 	err = p.apps.Product.UpdateProduct(ctx, request)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.UpdateProduct"}).Error(err)
-		return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-			Message: "aconteceu um erro interno",
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.productUpdate.UpdateProduct"}).WithError(err).Error(err)
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(productModel.ProductDB{ID: request.ID})
@@ -135,37 +125,62 @@ func (p *apiImpl) productUpdate(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      int  true  "Product ID"
 // @Success      200  {object}  productModel.ProductDB
-// @Failure      400  {object}  errorsP.ErrorsResponse
-// @Failure      404  {object}  errorsP.ErrorsResponse
-// @Failure      500  {object}  errorsP.ErrorsResponse
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
 // @Router       /api/products/{id} [get]
 func (p *apiImpl) product(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	id := c.Params("id")
 	iid, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.ParseInt"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: "Por favor envie o id",
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.ParseInt"}).WithError(err).Error(err)
+		return errorsP.ErrInvalidID.WithDetail("detail.invalid_id", id)
 	}
 
-	ctx := c.Context()
 	planet, err := p.apps.Product.GetOneByID(ctx, iid)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.product.GetOneByID"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.product.GetOneByID"}).WithError(err).Error(err)
 		if errors.Is(err, productModel.ErrorProductNotFound) {
-			return c.Status(http.StatusNotFound).JSON(errorsP.ErrorsResponse{
-				Message: fmt.Sprintf("Planeta (%d) não encontrado", iid),
-			})
+			return productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", iid)
 		}
-		return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-			Message: "Aconteceu um erro interno..",
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(planet)
 }
 
+// ShowProductByBarcode godoc
+// @Summary      Show a product by barcode
+// @Description  get product by a UPC-A, EAN-13 or GTIN-14, compared by its GTIN-14 so
+// @Description  036000291452 and 0036000291452 find the same product
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        code  path      string  true  "Barcode"
+// @Success      200  {object}  productModel.ProductDB
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/products/by-barcode/{code} [get]
+func (p *apiImpl) productByBarcode(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	code := c.Params("code")
+
+	product, err := p.apps.Product.GetByBarcode(ctx, code)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.productByBarcode.GetByBarcode"}).WithError(err).Error(err)
+		if errors.Is(err, productModel.ErrorProductNotFound) {
+			return productModel.ErrorProductNotFound.WithDetail("detail.barcode_not_found", code)
+		}
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(product)
+}
+
 // DeleteProduct godoc
 // @Summary      Delete a products
 // @Description  delete products by ID
@@ -174,32 +189,27 @@ func (p *apiImpl) product(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      int  true  "Product ID"
 // @Success      204
-// @Failure      400  {object}  errorsP.ErrorsResponse
-// @Failure      404  {object}  errorsP.ErrorsResponse
-// @Failure      500  {object}  errorsP.ErrorsResponse
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
 // @Router       /api/products/{id} [delete]
 func (p *apiImpl) productDelete(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	id := c.Params("id")
 	iid, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.productDelete.ParseInt"}).Error(err)
-		return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-			Message: "Por favor envie o id",
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.productDelete.ParseInt"}).WithError(err).Error(err)
+		return errorsP.ErrInvalidID.WithDetail("detail.invalid_id", id)
 	}
 
-	ctx := c.Context()
 	err = p.apps.Product.Delete(ctx, iid)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.productDelete.Delete"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.productDelete.Delete"}).WithError(err).Error(err)
 		if errors.Is(err, productModel.ErrorProductNotFound) {
-			return c.Status(http.StatusNotFound).JSON(errorsP.ErrorsResponse{
-				Message: fmt.Sprintf("Planeta (%d) não encontrado", iid),
-			})
+			return productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", iid)
 		}
-		return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-			Message: "Aconteceu um erro interno..",
-		})
+		return err
 	}
 
 	return c.Status(http.StatusNoContent).JSON(true)
@@ -215,22 +225,24 @@ func (p *apiImpl) productDelete(c *fiber.Ctx) error {
 // @Param limit query int false "limit"
 // @Param name query string false "name"
 // @Success      200  {object}  productModel.ResponseProducts
-// @Failure      400  {object}  errorsP.ErrorsResponse
-// @Failure      404  {object}  errorsP.ErrorsResponse
-// @Failure      500  {object}  errorsP.ErrorsResponse
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
 // @Router       /api/products [get]
 func (p *apiImpl) products(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 
 	limit := c.Query("limit")
 	var ilimit int64 = 10
 	if len(limit) > 0 {
 		limitConv, err := strconv.ParseInt(limit, 10, 64)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{"trace": "api.product.products.ParseInt.limit"}).Error(err)
-			return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-				Message: "Por favor envie o limit corretamente.",
-			})
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.products.ParseInt.limit"}).WithError(err).Error(err)
+			return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_number", "limit", limit)
+		}
+		if limitConv < 0 {
+			return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_min", "limit", 0)
 		}
 		ilimit = limitConv
 	}
@@ -241,10 +253,11 @@ func (p *apiImpl) products(c *fiber.Ctx) error {
 	if len(page) > 0 {
 		pageConv, err := strconv.ParseInt(page, 10, 64)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{"trace": "api.product.products.ParseInt.page"}).Error(err)
-			return c.Status(http.StatusBadRequest).JSON(errorsP.ErrorsResponse{
-				Message: "Por favor envie o page corretamente.",
-			})
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.products.ParseInt.page"}).WithError(err).Error(err)
+			return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_number", "page", page)
+		}
+		if pageConv < 0 {
+			return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_min", "page", 0)
 		}
 		ipage = pageConv
 	}
@@ -253,16 +266,11 @@ func (p *apiImpl) products(c *fiber.Ctx) error {
 
 	planets, err := p.apps.Product.GetAllProducts(ctx, ipage, ilimit, name)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.products.GetAllPlanets"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.products.GetAllPlanets"}).WithError(err).Error(err)
 		if errors.Is(err, productModel.ErrorProductNotFound) {
-			return c.Status(http.StatusNotFound).JSON(errorsP.ErrorsResponse{
-				Message: "Dados nao encontrados",
-			})
+			return productModel.ErrorProductNotFound.WithDetail("detail.empty_page")
 		}
-
-		return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-			Message: "Aconteceu um erro interno..",
-		})
+		return err
 	}
 
 	nextPage, previousPage := genericModel.MakePagination(ipage)
@@ -270,20 +278,16 @@ func (p *apiImpl) products(c *fiber.Ctx) error {
 	_, err = p.apps.Product.GetAllProducts(ctx, *nextPage, ilimit, name)
 	if err != nil {
 		if !errors.Is(err, productModel.ErrorProductNotFound) {
-			logrus.WithFields(logrus.Fields{"trace": "api.product.products.GetAllPlanets_1"}).Error(err)
-			return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-				Message: "Aconteceu um erro interno..",
-			})
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.products.GetAllPlanets_1"}).WithError(err).Error(err)
+			return err
 		}
 		nextPage = nil
 	}
 
 	total, err := p.apps.Product.GetTotalProducts(ctx)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "api.product.products.GetTotalPlanets"}).Error(err)
-		return c.Status(http.StatusInternalServerError).JSON(errorsP.ErrorsResponse{
-			Message: "Aconteceu um erro interno..",
-		})
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.product.products.GetTotalPlanets"}).WithError(err).Error(err)
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(productModel.ResponseProducts{
@@ -2,15 +2,21 @@ package product
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/danilotadeu/products/api/middleware/locale"
+	"github.com/danilotadeu/products/api/problem"
 	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/i18n"
 	mockAppProduct "github.com/danilotadeu/products/mock/app/product"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
 	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang/mock/gomock"
 	"gotest.tools/v3/assert"
@@ -55,6 +61,14 @@ func TestHandlerDelete(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusInternalServerError,
 		},
+		"should return timeout when the statement expires": {
+			InputParamID: "1",
+			ExpectedErr:  nil,
+			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
+				mockPlanetApp.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(fmt.Errorf("%w: interrupted", context.DeadlineExceeded))
+			},
+			ExpectedStatusCode: http.StatusGatewayTimeout,
+		},
 	}
 	for name, cs := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -68,7 +82,7 @@ func TestHandlerDelete(t *testing.T) {
 				},
 			}
 
-			app := fiber.New()
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
 			app.Delete(endpoint, h.productDelete)
 			req := httptest.NewRequest(http.MethodDelete, strings.ReplaceAll(endpoint, ":id", cs.InputParamID), nil).WithContext(ctx)
 			req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
@@ -127,6 +141,22 @@ func TestHandlerGetPlanetByID(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusInternalServerError,
 		},
+		"should return client closed request when cancelled": {
+			InputParamID: "1",
+			ExpectedErr:  nil,
+			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
+				mockPlanetApp.EXPECT().GetOneByID(gomock.Any(), gomock.Any()).Return(nil, context.Canceled)
+			},
+			ExpectedStatusCode: errorsP.StatusClientClosedRequest,
+		},
+		"should return timeout when the statement expires": {
+			InputParamID: "1",
+			ExpectedErr:  nil,
+			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
+				mockPlanetApp.EXPECT().GetOneByID(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: interrupted", context.DeadlineExceeded))
+			},
+			ExpectedStatusCode: http.StatusGatewayTimeout,
+		},
 	}
 	for name, cs := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -140,7 +170,7 @@ func TestHandlerGetPlanetByID(t *testing.T) {
 				},
 			}
 
-			app := fiber.New()
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
 			app.Get(endpoint, h.product)
 			req := httptest.NewRequest(http.MethodGet, strings.ReplaceAll(endpoint, ":id", cs.InputParamID), nil).WithContext(ctx)
 			req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
@@ -235,6 +265,20 @@ func TestHandlerGetPlanets(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusBadRequest,
 		},
+		"should throw error with a negative page": {
+			ExpectedErr: nil,
+			InputPage:   "-1",
+			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should throw error with a negative limit": {
+			ExpectedErr: nil,
+			InputLimit:  "-1",
+			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
 		"should return with planet not found": {
 			ExpectedErr: nil,
 			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
@@ -249,6 +293,13 @@ func TestHandlerGetPlanets(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusInternalServerError,
 		},
+		"should return timeout when the statement expires": {
+			ExpectedErr: nil,
+			PrepareMockApp: func(mockPlanetApp *mockAppProduct.MockApp) {
+				mockPlanetApp.EXPECT().GetAllProducts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, context.DeadlineExceeded)
+			},
+			ExpectedStatusCode: http.StatusGatewayTimeout,
+		},
 	}
 	for name, cs := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -262,7 +313,7 @@ func TestHandlerGetPlanets(t *testing.T) {
 				},
 			}
 			endpoint := "/planets"
-			app := fiber.New()
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
 			app.Get(endpoint, h.products)
 
 			if len(cs.InputPage) > 0 && len(cs.InputLimit) > 0 {
@@ -286,3 +337,150 @@ func TestHandlerGetPlanets(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlerCreate(t *testing.T) {
+	cases := map[string]struct {
+		InputBody          string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+		ExpectedCode       string
+		ExpectedFields     []string
+	}{
+		"should create the product": {
+			InputBody: `{"name": "Product 1", "quantity": 10}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"should throw error with an invalid body": {
+			InputBody:          `{"name":`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCode:       errorsP.ErrBadRequest.Code,
+		},
+		"should list the invalid fields": {
+			InputBody:          `{"name": " Product 1", "quantity": -1}`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCode:       errorsP.ErrValidation.Code,
+			ExpectedFields:     []string{"name", "quantity"},
+		},
+		"should return conflict when the name is taken": {
+			InputBody: `{"name": "Product 1", "quantity": 10}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorProductAlreadyExists)
+			},
+			ExpectedStatusCode: http.StatusConflict,
+			ExpectedCode:       errorsP.ErrProductAlreadyExists.Code,
+		},
+		"should create the product with its barcodes": {
+			InputBody: `{"name": "Product 1", "quantity": 10, "barcodes": ["036000291452"]}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10, Barcodes: []string{"036000291452"}}).Return(&id, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"should list the barcodes with a wrong check digit": {
+			InputBody:          `{"name": "Product 1", "quantity": 10, "barcodes": ["036000291452", "036000291453"]}`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCode:       errorsP.ErrValidation.Code,
+			ExpectedFields:     []string{"barcodes[1]"},
+		},
+		"should return conflict when the barcode belongs to another product": {
+			InputBody: `{"name": "Product 1", "quantity": 10, "barcodes": ["036000291452"]}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorBarcodeAlreadyExists.WithDetail("detail.barcode_already_exists", "036000291452"))
+			},
+			ExpectedStatusCode: http.StatusConflict,
+			ExpectedCode:       errorsP.ErrBarcodeAlreadyExists.Code,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			mockProductApp := mockAppProduct.NewMockApp(ctrl)
+			cs.PrepareMockApp(mockProductApp)
+
+			h := apiImpl{
+				apps: &app.Container{
+					Product: mockProductApp,
+				},
+				validator: validation.New(),
+			}
+
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+			app.Post("/products", h.productCreate)
+			req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(cs.InputBody)).WithContext(ctx)
+			req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			if cs.ExpectedCode == "" {
+				return
+			}
+
+			var body errorsP.Problem
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, cs.ExpectedCode, body.Code)
+			fields := []string{}
+			for _, field := range body.Errors {
+				fields = append(fields, field.Field)
+			}
+			if cs.ExpectedFields == nil {
+				cs.ExpectedFields = []string{}
+			}
+			assert.DeepEqual(t, cs.ExpectedFields, fields)
+		})
+	}
+}
+
+func TestHandlerLocale(t *testing.T) {
+	cases := map[string]struct {
+		AcceptLanguage   string
+		ExpectedLanguage string
+		ExpectedTitle    string
+		ExpectedDetail   string
+	}{
+		"should answer in portuguese by default": {
+			ExpectedLanguage: i18n.PortugueseBR,
+			ExpectedTitle:    "Id inválido",
+			ExpectedDetail:   `o id "abc" não é um número`,
+		},
+		"should answer in english": {
+			AcceptLanguage:   "en-US",
+			ExpectedLanguage: i18n.English,
+			ExpectedTitle:    "Invalid id",
+			ExpectedDetail:   `the id "abc" is not a number`,
+		},
+		"should answer in spanish": {
+			AcceptLanguage:   "es-AR,es;q=0.9",
+			ExpectedLanguage: i18n.Spanish,
+			ExpectedTitle:    "Id inválido",
+			ExpectedDetail:   `el id "abc" no es un número`,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := apiImpl{apps: &app.Container{}, validator: validation.New()}
+
+			app := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+			app.Use(locale.New())
+			app.Get("/products/:id", h.product)
+			req := httptest.NewRequest(http.MethodGet, "/products/abc", nil)
+			req.Header.Set(fiber.HeaderAcceptLanguage, cs.AcceptLanguage)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, cs.ExpectedLanguage, resp.Header.Get(fiber.HeaderContentLanguage))
+
+			var body errorsP.Problem
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, cs.ExpectedTitle, body.Title)
+			assert.Equal(t, cs.ExpectedDetail, body.Detail)
+		})
+	}
+}
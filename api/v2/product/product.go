@@ -0,0 +1,315 @@
+// Package product serves the v2 product resource, with its own request and response
+// models and every body wrapped in an envelope
+package product
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danilotadeu/products/app"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultLimit is the page size when limit is not sent
+	defaultLimit = 10
+	// maxLimit bounds the page size
+	maxLimit = 100
+)
+
+type apiImpl struct {
+	apps      *app.Container
+	validator *validator.Validate
+}
+
+// NewAPI registers the v2 product resource
+func NewAPI(g fiber.Router, apps *app.Container, validate *validator.Validate) {
+	api := apiImpl{
+		apps:      apps,
+		validator: validate,
+	}
+
+	g.Get("/", api.products)
+	g.Get("/by-barcode/:code", api.productByBarcode)
+	g.Get("/:id", api.product)
+	g.Delete("/:id", api.productDelete)
+	g.Post("/", api.productCreate)
+	g.Put("/:id", api.productUpdate)
+}
+
+// CreateProduct godoc
+// @Summary      Create a product
+// @Description  create a product, answering it with its location
+// @Tags         products v2
+// @Accept       json
+// @Produce      json
+// @Param        product  body      productModel.ProductRequest true "Product"
+// @Success      201  {object}  productModel.ProductEnvelope
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      409  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/v2/products [post]
+func (p *apiImpl) productCreate(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	request, err := p.parseRequest(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productCreate.parseRequest"}).WithError(err).Error(err)
+		return err
+	}
+
+	id, err := p.apps.Product.SaveProduct(ctx, request.ProductDB(0))
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productCreate.SaveProduct"}).WithError(err).Error(err)
+		return err
+	}
+
+	product, err := p.apps.Product.GetOneByID(ctx, *id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productCreate.GetOneByID"}).WithError(err).Error(err)
+		return err
+	}
+
+	c.Location(strings.TrimSuffix(c.Path(), "/") + "/" + strconv.FormatInt(*id, 10))
+	return c.Status(http.StatusCreated).JSON(productModel.ProductEnvelope{Data: productModel.NewProductResponse(product)})
+}
+
+// UpdateProduct godoc
+// @Summary      Update a product
+// @Description  replace the name and quantity of a product, and its barcodes when sent
+// @Tags         products v2
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int  true  "Product ID"
+// @Param        product  body      productModel.ProductRequest true "Product"
+// @Success      200  {object}  productModel.ProductEnvelope
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      409  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/v2/products/{id} [put]
+func (p *apiImpl) productUpdate(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	id, err := parseID(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productUpdate.parseID"}).WithError(err).Error(err)
+		return err
+	}
+
+	request, err := p.parseRequest(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productUpdate.parseRequest"}).WithError(err).Error(err)
+		return err
+	}
+
+	if err := p.apps.Product.UpdateProduct(ctx, request.ProductDB(id)); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productUpdate.UpdateProduct"}).WithError(err).Error(err)
+		return notFound(err, id)
+	}
+
+	product, err := p.apps.Product.GetOneByID(ctx, id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productUpdate.GetOneByID"}).WithError(err).Error(err)
+		return notFound(err, id)
+	}
+
+	return c.Status(http.StatusOK).JSON(productModel.ProductEnvelope{Data: productModel.NewProductResponse(product)})
+}
+
+// ShowProduct godoc
+// @Summary      Show a product
+// @Description  get a product by ID
+// @Tags         products v2
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  productModel.ProductEnvelope
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/v2/products/{id} [get]
+func (p *apiImpl) product(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	id, err := parseID(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.product.parseID"}).WithError(err).Error(err)
+		return err
+	}
+
+	product, err := p.apps.Product.GetOneByID(ctx, id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.product.GetOneByID"}).WithError(err).Error(err)
+		return notFound(err, id)
+	}
+
+	return c.Status(http.StatusOK).JSON(productModel.ProductEnvelope{Data: productModel.NewProductResponse(product)})
+}
+
+// ShowProductByBarcode godoc
+// @Summary      Show a product by barcode
+// @Description  get a product by a UPC-A, EAN-13 or GTIN-14, compared by its GTIN-14 so
+// @Description  036000291452 and 0036000291452 find the same product
+// @Tags         products v2
+// @Produce      json
+// @Param        code  path      string  true  "Barcode"
+// @Success      200  {object}  productModel.ProductEnvelope
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/v2/products/by-barcode/{code} [get]
+func (p *apiImpl) productByBarcode(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	code := c.Params("code")
+
+	product, err := p.apps.Product.GetByBarcode(ctx, code)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productByBarcode.GetByBarcode"}).WithError(err).Error(err)
+		if errors.Is(err, productModel.ErrorProductNotFound) {
+			return productModel.ErrorProductNotFound.WithDetail("detail.barcode_not_found", code)
+		}
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(productModel.ProductEnvelope{Data: productModel.NewProductResponse(product)})
+}
+
+// DeleteProduct godoc
+// @Summary      Delete a product
+// @Description  delete a product by ID
+// @Tags         products v2
+// @Param        id   path      int  true  "Product ID"
+// @Success      204
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      404  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/v2/products/{id} [delete]
+func (p *apiImpl) productDelete(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	id, err := parseID(c)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productDelete.parseID"}).WithError(err).Error(err)
+		return err
+	}
+
+	if err := p.apps.Product.Delete(ctx, id); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.productDelete.Delete"}).WithError(err).Error(err)
+		return notFound(err, id)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// ListProducts godoc
+// @Summary      List products
+// @Description  get a page of products, an empty page is not an error
+// @Tags         products v2
+// @Produce      json
+// @Param        page   query     int     false  "page, from 1"
+// @Param        limit  query     int     false  "page size, up to 100"
+// @Param        name   query     string  false  "name"
+// @Success      200  {object}  productModel.ProductsEnvelope
+// @Failure      400  {object}  errorsP.Problem
+// @Failure      500  {object}  errorsP.Problem
+// @Failure      504  {object}  errorsP.Problem
+// @Router       /api/v2/products [get]
+func (p *apiImpl) products(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	page, err := queryInt(c, "page", 1)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.products.queryInt.page"}).WithError(err).Error(err)
+		return err
+	}
+	if page < 1 {
+		return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_min", "page", 1)
+	}
+
+	limit, err := queryInt(c, "limit", defaultLimit)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.products.queryInt.limit"}).WithError(err).Error(err)
+		return err
+	}
+	if limit < 1 || limit > maxLimit {
+		return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_range", "limit", 1, maxLimit)
+	}
+	// past it the offset of the page does not fit an int64
+	if page > math.MaxInt64/limit {
+		return errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_range", "page", 1, math.MaxInt64/limit)
+	}
+
+	// one product past the page tells whether there is a next one
+	products, err := p.apps.Product.GetAllProducts(ctx, (page-1)*limit, limit+1, c.Query("name"))
+	if err != nil && !errors.Is(err, productModel.ErrorProductNotFound) {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "api.v2.product.products.GetAllProducts"}).WithError(err).Error(err)
+		return err
+	}
+
+	meta := productModel.PageMeta{Page: page, Limit: limit}
+	if int64(len(products)) > limit {
+		products = products[:limit]
+		nextPage := page + 1
+		meta.NextPage = &nextPage
+	}
+	if page > 1 {
+		previousPage := page - 1
+		meta.PreviousPage = &previousPage
+	}
+
+	data := make([]productModel.ProductResponse, len(products))
+	for idx, product := range products {
+		data[idx] = productModel.NewProductResponse(product)
+	}
+	return c.Status(http.StatusOK).JSON(productModel.ProductsEnvelope{Data: data, Meta: meta})
+}
+
+// parseRequest reads and validates the body of a create or update
+func (p *apiImpl) parseRequest(c *fiber.Ctx) (productModel.ProductRequest, error) {
+	request := productModel.ProductRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return request, errorsP.ErrBadRequest.WithDetail("%s", err.Error())
+	}
+	if err := p.validator.Struct(request); err != nil {
+		return request, validation.Error(c.UserContext(), err)
+	}
+	return request, nil
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, errorsP.ErrInvalidID.WithDetail("detail.invalid_id", c.Params("id"))
+	}
+	return id, nil
+}
+
+// queryInt is the number in the query parameter key, or fallback when it is not sent
+func queryInt(c *fiber.Ctx, key string, fallback int64) (int64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_number", key, raw)
+	}
+	return value, nil
+}
+
+// notFound details the ErrorProductNotFound of id, the other errors are returned as is
+func notFound(err error, id int64) error {
+	if errors.Is(err, productModel.ErrorProductNotFound) {
+		return productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", id)
+	}
+	return err
+}
@@ -0,0 +1,374 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/api/problem"
+	"github.com/danilotadeu/products/app"
+	mockAppProduct "github.com/danilotadeu/products/mock/app/product"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+var createdAt = time.Date(2024, time.January, 31, 9, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+func newApp(t *testing.T, prepare func(mockProductApp *mockAppProduct.MockApp)) (*fiber.App, context.Context) {
+	t.Helper()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	mockProductApp := mockAppProduct.NewMockApp(ctrl)
+	prepare(mockProductApp)
+
+	router := fiber.New(fiber.Config{ErrorHandler: problem.Handler})
+	NewAPI(router.Group("/api/v2/products"), &app.Container{Product: mockProductApp}, validation.New())
+	return router, ctx
+}
+
+func TestHandlerCreate(t *testing.T) {
+	cases := map[string]struct {
+		InputBody          string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+		ExpectedLocation   string
+		ExpectedBody       string
+	}{
+		"should answer the created product with its location": {
+			InputBody: `{"name": "Product 1", "quantity": 10}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+				mockProductApp.EXPECT().GetOneByID(gomock.Any(), id).Return(&productModel.ProductDB{ID: 1, Name: "Product 1", Quantity: 10, CreatedAt: createdAt}, nil)
+			},
+			ExpectedStatusCode: http.StatusCreated,
+			ExpectedLocation:   "/api/v2/products/1",
+			ExpectedBody:       `{"data":{"id":1,"name":"Product 1","quantity":10,"barcodes":[],"created_at":"2024-01-31T12:00:00Z"}}`,
+		},
+		"should not take the id from the body": {
+			InputBody: `{"id": 7, "name": "Product 1", "quantity": 10}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+				mockProductApp.EXPECT().GetOneByID(gomock.Any(), id).Return(&productModel.ProductDB{ID: 1, Name: "Product 1", Quantity: 10, CreatedAt: createdAt}, nil)
+			},
+			ExpectedStatusCode: http.StatusCreated,
+			ExpectedLocation:   "/api/v2/products/1",
+			ExpectedBody:       `{"data":{"id":1,"name":"Product 1","quantity":10,"barcodes":[],"created_at":"2024-01-31T12:00:00Z"}}`,
+		},
+		"should list the invalid fields": {
+			InputBody:          `{"name": "", "quantity": -1}`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should return conflict when the name is taken": {
+			InputBody: `{"name": "Product 1", "quantity": 10}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorProductAlreadyExists)
+			},
+			ExpectedStatusCode: http.StatusConflict,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/products", strings.NewReader(cs.InputBody)).WithContext(ctx)
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			assert.Equal(t, cs.ExpectedLocation, resp.Header.Get(fiber.HeaderLocation))
+			if cs.ExpectedBody == "" {
+				return
+			}
+
+			var body json.RawMessage
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, cs.ExpectedBody, string(body))
+		})
+	}
+}
+
+func TestHandlerProducts(t *testing.T) {
+	one, two := int64(1), int64(2)
+	page := func(ids ...int64) []*productModel.ProductDB {
+		products := make([]*productModel.ProductDB, len(ids))
+		for idx, id := range ids {
+			products[idx] = &productModel.ProductDB{ID: id, Name: fmt.Sprintf("Product %d", id), CreatedAt: createdAt}
+		}
+		return products
+	}
+	cases := map[string]struct {
+		Query              string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+		ExpectedIDs        []int64
+		ExpectedMeta       productModel.PageMeta
+	}{
+		"should answer the first page": {
+			Query: "?limit=2",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(0), int64(3), "").Return(page(1, 2, 3), nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedIDs:        []int64{1, 2},
+			ExpectedMeta:       productModel.PageMeta{Page: 1, Limit: 2, NextPage: &two},
+		},
+		"should answer the last page": {
+			Query: "?page=2&limit=2&name=Product",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(2), int64(3), "Product").Return(page(3), nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedIDs:        []int64{3},
+			ExpectedMeta:       productModel.PageMeta{Page: 2, Limit: 2, PreviousPage: &one},
+		},
+		"should answer an empty page": {
+			Query: "?page=3",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(20), int64(11), "").Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedIDs:        []int64{},
+			ExpectedMeta:       productModel.PageMeta{Page: 3, Limit: defaultLimit, PreviousPage: &two},
+		},
+		"should reject the page zero": {
+			Query:              "?page=0",
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should bound the page to an offset that fits": {
+			Query:              "?page=9223372036854775807&limit=2",
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should bound the limit": {
+			Query:              "?limit=101",
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		"should throw error": {
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("error"))
+			},
+			ExpectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/products"+cs.Query, nil).WithContext(ctx)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+			if cs.ExpectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var body productModel.ProductsEnvelope
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			ids := []int64{}
+			for _, product := range body.Data {
+				ids = append(ids, product.ID)
+			}
+			assert.DeepEqual(t, cs.ExpectedIDs, ids)
+			assert.DeepEqual(t, cs.ExpectedMeta, body.Meta)
+		})
+	}
+}
+
+func TestHandlerProduct(t *testing.T) {
+	deletedAt := createdAt.Add(time.Hour)
+	cases := map[string]struct {
+		InputParamID       string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+		ExpectedBody       string
+		ExpectedCode       string
+	}{
+		"should answer the product with iso timestamps": {
+			InputParamID: "1",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(&productModel.ProductDB{ID: 1, Name: "Product 1", Quantity: 10, CreatedAt: createdAt, DeletedAt: &deletedAt}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       `{"data":{"id":1,"name":"Product 1","quantity":10,"barcodes":[],"created_at":"2024-01-31T12:00:00Z","deleted_at":"2024-01-31T13:00:00Z"}}`,
+		},
+		"should throw error with parse int": {
+			InputParamID:       "xpto",
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCode:       errorsP.ErrInvalidID.Code,
+		},
+		"should return with product not found": {
+			InputParamID: "1",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+			ExpectedCode:       errorsP.ErrProductNotFound.Code,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/products/"+cs.InputParamID, nil).WithContext(ctx)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+
+			var body json.RawMessage
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if cs.ExpectedCode != "" {
+				var problem errorsP.Problem
+				assert.NilError(t, json.Unmarshal(body, &problem))
+				assert.Equal(t, cs.ExpectedCode, problem.Code)
+				return
+			}
+			assert.Equal(t, cs.ExpectedBody, string(body))
+		})
+	}
+}
+
+func TestHandlerProductByBarcode(t *testing.T) {
+	cases := map[string]struct {
+		InputCode          string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+		ExpectedBody       string
+		ExpectedCode       string
+	}{
+		"should answer the product of the barcode": {
+			InputCode: "0036000291452",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByBarcode(gomock.Any(), "0036000291452").Return(&productModel.ProductDB{ID: 1, Name: "Product 1", Quantity: 10, Barcodes: []string{"036000291452"}, CreatedAt: createdAt}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       `{"data":{"id":1,"name":"Product 1","quantity":10,"barcodes":["036000291452"],"created_at":"2024-01-31T12:00:00Z"}}`,
+		},
+		"should throw error with an invalid barcode": {
+			InputCode: "036000291453",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByBarcode(gomock.Any(), "036000291453").Return(nil, errorsP.ErrInvalidBarcode.WithDetail("detail.invalid_barcode", "036000291453"))
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCode:       errorsP.ErrInvalidBarcode.Code,
+		},
+		"should return with product not found": {
+			InputCode: "036000291452",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetByBarcode(gomock.Any(), "036000291452").Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+			ExpectedCode:       errorsP.ErrProductNotFound.Code,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/products/by-barcode/"+cs.InputCode, nil).WithContext(ctx)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+
+			var body json.RawMessage
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if cs.ExpectedCode != "" {
+				var problem errorsP.Problem
+				assert.NilError(t, json.Unmarshal(body, &problem))
+				assert.Equal(t, cs.ExpectedCode, problem.Code)
+				return
+			}
+			assert.Equal(t, cs.ExpectedBody, string(body))
+		})
+	}
+}
+
+func TestHandlerUpdate(t *testing.T) {
+	cases := map[string]struct {
+		InputParamID       string
+		InputBody          string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+	}{
+		"should answer the updated product": {
+			InputParamID: "1",
+			InputBody:    `{"name": "Product 2", "quantity": 5}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().UpdateProduct(gomock.Any(), productModel.ProductDB{ID: 1, Name: "Product 2", Quantity: 5}).Return(nil)
+				mockProductApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(&productModel.ProductDB{ID: 1, Name: "Product 2", Quantity: 5, CreatedAt: createdAt}, nil)
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"should return with product not found": {
+			InputParamID: "1",
+			InputBody:    `{"name": "Product 2", "quantity": 5}`,
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		"should list the invalid fields": {
+			InputParamID:       "1",
+			InputBody:          `{"name": " Product 2"}`,
+			PrepareMockApp:     func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v2/products/"+cs.InputParamID, strings.NewReader(cs.InputBody)).WithContext(ctx)
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandlerDelete(t *testing.T) {
+	cases := map[string]struct {
+		InputParamID       string
+		PrepareMockApp     func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedStatusCode int
+	}{
+		"should delete the product": {
+			InputParamID: "1",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().Delete(gomock.Any(), int64(1)).Return(nil)
+			},
+			ExpectedStatusCode: http.StatusNoContent,
+		},
+		"should return with product not found": {
+			InputParamID: "1",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().Delete(gomock.Any(), int64(1)).Return(productModel.ErrorProductNotFound)
+			},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			app, ctx := newApp(t, cs.PrepareMockApp)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v2/products/"+cs.InputParamID, nil).WithContext(ctx)
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+		})
+	}
+}
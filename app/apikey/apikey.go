@@ -0,0 +1,85 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// keyPrefix makes the keys easy to recognise in configs and secret scanners
+const keyPrefix = "pk_"
+
+//go:generate mockgen -destination ../../mock/app/apikey/apikey_app_mock.go -package mockAppAPIKey . App
+type App interface {
+	CreateAPIKey(ctx context.Context, owner string) (*apiKeyModel.CreatedAPIKey, error)
+	// Authenticate finds the key sent by a client, ErrorAPIKeyInvalid when it does not exist
+	// or was revoked
+	Authenticate(ctx context.Context, key string) (*apiKeyModel.APIKeyDB, error)
+}
+
+type appImpl struct {
+	store *store.Container
+}
+
+// NewApp init an api key
+func NewApp(store *store.Container) App {
+	return &appImpl{
+		store: store,
+	}
+}
+
+// CreateAPIKey generates a random key, only its sha256 is stored
+func (a *appImpl) CreateAPIKey(ctx context.Context, owner string) (*apiKeyModel.CreatedAPIKey, error) {
+	ctx, span := tracing.Start(ctx, "app.apikey.CreateAPIKey")
+	defer span.End()
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.apikey.CreateAPIKey.rand.Read"}).WithError(err).Error(err)
+		return nil, err
+	}
+	key := keyPrefix + hex.EncodeToString(secret)
+
+	id, err := a.store.APIKey.SaveAPIKey(ctx, apiKeyModel.APIKeyDB{
+		Owner:   owner,
+		KeyHash: HashKey(key),
+	})
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.apikey.CreateAPIKey.Store.APIKey.SaveAPIKey"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &apiKeyModel.CreatedAPIKey{
+		ID:    *id,
+		Owner: owner,
+		Key:   key,
+	}, nil
+}
+
+func (a *appImpl) Authenticate(ctx context.Context, key string) (*apiKeyModel.APIKeyDB, error) {
+	ctx, span := tracing.Start(ctx, "app.apikey.Authenticate")
+	defer span.End()
+
+	apiKey, err := a.store.APIKey.GetByHash(ctx, HashKey(key))
+	if err != nil {
+		if !errors.Is(err, apiKeyModel.ErrorAPIKeyInvalid) {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.apikey.Authenticate.Store.APIKey.GetByHash"}).WithError(err).Error(err)
+		}
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// HashKey is how a key is kept in the database
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,7 +1,11 @@
 package app
 
 import (
+	"github.com/danilotadeu/products/app/apikey"
+	"github.com/danilotadeu/products/app/media"
 	"github.com/danilotadeu/products/app/product"
+	"github.com/danilotadeu/products/app/user"
+	"github.com/danilotadeu/products/config"
 	"github.com/danilotadeu/products/store"
 	"github.com/sirupsen/logrus"
 )
@@ -9,12 +13,19 @@ import (
 // Container ...
 type Container struct {
 	Product product.App
+	APIKey  apikey.App
+	User    user.App
+	Media   media.App
 }
 
 // Register app container
-func Register(store *store.Container) *Container {
+func Register(store *store.Container, cfg config.Media) *Container {
+	keys := apikey.NewApp(store)
 	container := &Container{
 		Product: product.NewApp(store),
+		APIKey:  keys,
+		User:    user.NewApp(store, keys),
+		Media:   media.NewApp(store, cfg),
 	}
 
 	logrus.WithFields(logrus.Fields{"trace": "app"}).Infof("Registered - App")
@@ -0,0 +1,247 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/danilotadeu/products/config"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"github.com/danilotadeu/products/store"
+	"github.com/danilotadeu/products/store/blob"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// sniffLen is how much of the content http.DetectContentType looks at
+const sniffLen = 512
+
+// ContentTypes are the types accepted by Upload, detected from the content and not
+// from the name or the headers sent by the client
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"}
+
+//go:generate mockgen -destination ../../mock/app/media/media_app_mock.go -package mockAppMedia . App
+type App interface {
+	// Upload keeps content as a media of the product, up to the max size of the App
+	Upload(ctx context.Context, productID int64, filename string, content io.Reader) (*mediaModel.MediaDB, error)
+	List(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error)
+	// Get opens the content of the media, the caller closes it
+	Get(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, io.ReadCloser, error)
+	Delete(ctx context.Context, productID, id int64) error
+	// GetRendition opens the image resized to size, rendering it when missing
+	GetRendition(ctx context.Context, productID, id int64, size int) (*mediaModel.MediaDB, *mediaModel.Rendition, error)
+	// Work renders the renditions of the uploaded images in the background until ctx is done
+	Work(ctx context.Context)
+}
+
+type appImpl struct {
+	store      *store.Container
+	maxSize    int64
+	renditions []int
+	format     string
+	jobs       chan mediaModel.MediaDB
+	// renders collapses the renders on demand of the same rendition
+	renders singleflight.Group
+}
+
+// NewApp init a media
+func NewApp(store *store.Container, cfg config.Media) App {
+	return &appImpl{
+		store:      store,
+		maxSize:    cfg.MaxSize(),
+		renditions: cfg.Renditions,
+		format:     cfg.RenditionFormat,
+		jobs:       make(chan mediaModel.MediaDB, jobsBuffer),
+	}
+}
+
+func (a *appImpl) Upload(ctx context.Context, productID int64, filename string, content io.Reader) (*mediaModel.MediaDB, error) {
+	ctx, span := tracing.Start(ctx, "app.media.Upload")
+	defer span.End()
+
+	if _, err := a.store.Product.GetOneByID(ctx, productID); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Upload.Store.Product.GetOneByID"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Upload.ReadFull"}).WithError(err).Error(err)
+		return nil, err
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !accepted(contentType) {
+		return nil, mediaModel.ErrorUnsupportedMediaType.WithDetail("detail.unsupported_media_type", contentType, strings.Join(ContentTypes, ", "))
+	}
+
+	key, err := newKey(productID)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Upload.newKey"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	body := &limitedReader{
+		r:    io.MultiReader(bytes.NewReader(head[:n]), content),
+		max:  a.maxSize,
+		hash: sha256.New(),
+	}
+	if err := a.store.Blob.Put(ctx, key, body); err != nil {
+		if errors.Is(err, mediaModel.ErrorMediaTooLarge) {
+			return nil, mediaModel.ErrorMediaTooLarge.WithDetail("detail.media_too_large", a.maxSize)
+		}
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Upload.Store.Blob.Put"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	media := mediaModel.MediaDB{
+		ProductID:   productID,
+		BlobKey:     key,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        body.read,
+		Checksum:    hex.EncodeToString(body.hash.Sum(nil)),
+	}
+	id, err := a.store.Media.SaveMedia(ctx, media)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Upload.Store.Media.SaveMedia"}).WithError(err).Error(err)
+		a.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	saved, err := a.store.Media.GetByID(ctx, productID, *id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Upload.Store.Media.GetByID"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	a.enqueue(ctx, *saved)
+	return saved, nil
+}
+
+func (a *appImpl) List(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	ctx, span := tracing.Start(ctx, "app.media.List")
+	defer span.End()
+
+	if _, err := a.store.Product.GetOneByID(ctx, productID); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.List.Store.Product.GetOneByID"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	medias, err := a.store.Media.ListByProduct(ctx, productID)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.List.Store.Media.ListByProduct"}).WithError(err).Error(err)
+		return nil, err
+	}
+	return medias, nil
+}
+
+func (a *appImpl) Get(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "app.media.Get")
+	defer span.End()
+
+	media, err := a.store.Media.GetByID(ctx, productID, id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Get.Store.Media.GetByID"}).WithError(err).Error(err)
+		return nil, nil, err
+	}
+
+	content, err := a.store.Blob.Get(ctx, media.BlobKey)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Get.Store.Blob.Get"}).WithError(err).Error(err)
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, nil, mediaModel.ErrorMediaNotFound
+		}
+		return nil, nil, err
+	}
+	return media, content, nil
+}
+
+// Delete removes the media before its blobs, a failure to delete them leaves
+// unreachable files instead of a media without content
+func (a *appImpl) Delete(ctx context.Context, productID, id int64) error {
+	ctx, span := tracing.Start(ctx, "app.media.Delete")
+	defer span.End()
+
+	media, err := a.store.Media.GetByID(ctx, productID, id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Delete.Store.Media.GetByID"}).WithError(err).Error(err)
+		return err
+	}
+
+	if err := a.store.Media.Delete(ctx, productID, id); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.Delete.Store.Media.Delete"}).WithError(err).Error(err)
+		return err
+	}
+
+	a.deleteBlob(ctx, media.BlobKey)
+	return nil
+}
+
+// deleteBlob removes the content of a media and its renditions
+func (a *appImpl) deleteBlob(ctx context.Context, key string) {
+	if err := a.store.Blob.Delete(ctx, key); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.deleteBlob.Delete", "key": key}).WithError(err).Error(err)
+	}
+	if err := a.store.Blob.DeletePrefix(ctx, mediaModel.RenditionPrefix(key)); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.deleteBlob.DeletePrefix", "key": key}).WithError(err).Error(err)
+	}
+}
+
+func accepted(contentType string) bool {
+	for _, accepted := range ContentTypes {
+		if contentType == accepted {
+			return true
+		}
+	}
+	return false
+}
+
+// newKey is a random key under the product, the name sent by the client is never part of it
+func newKey(productID int64) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("products/%d/%s", productID, hex.EncodeToString(random)), nil
+}
+
+// cleanFilename drops the directories of the name sent by the client
+func cleanFilename(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[:255], "")
+	}
+	return name
+}
+
+// limitedReader counts and hashes what is read, failing with ErrorMediaTooLarge past max
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+	hash hash.Hash
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		return 0, mediaModel.ErrorMediaTooLarge
+	}
+	l.hash.Write(p[:n])
+	return n, err
+}
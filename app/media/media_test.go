@@ -0,0 +1,309 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/app/product"
+	"github.com/danilotadeu/products/config"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store"
+	"github.com/danilotadeu/products/store/blob"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func TestApp(t *testing.T) {
+	pngImage := encodePNG(t, 300, 200)
+
+	cases := map[string]struct {
+		Run func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64)
+	}{
+		"should upload and get a media": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				media, err := app.Upload(ctx, productID, `C:\photos\front.png`, bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+				assert.Equal(t, "front.png", media.Filename)
+				assert.Equal(t, "image/png", media.ContentType)
+				assert.Equal(t, int64(len(pngImage)), media.Size)
+				assert.Equal(t, 64, len(media.Checksum))
+
+				got, content, err := app.Get(ctx, productID, media.ID)
+				assert.NilError(t, err)
+				defer content.Close()
+				body, err := io.ReadAll(content)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, pngImage, body)
+				assert.Equal(t, media.Checksum, got.Checksum)
+
+				medias, err := app.List(ctx, productID)
+				assert.NilError(t, err)
+				assert.Equal(t, 1, len(medias))
+			},
+		},
+		"should throw error when the type is not accepted": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				_, err := app.Upload(ctx, productID, "photo.png", strings.NewReader("<html><script></script></html>"))
+				assert.ErrorIs(t, err, mediaModel.ErrorUnsupportedMediaType)
+			},
+		},
+		"should throw error when the file is too large": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				content := append(append([]byte{}, pngImage...), make([]byte, 1<<20)...)
+				_, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(content))
+				assert.ErrorIs(t, err, mediaModel.ErrorMediaTooLarge)
+
+				medias, err := app.List(ctx, productID)
+				assert.NilError(t, err)
+				assert.Equal(t, 0, len(medias))
+			},
+		},
+		"should throw error not found when the product does not exist": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				_, err := app.Upload(ctx, productID+1, "photo.png", bytes.NewReader(pngImage))
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+				_, err = app.List(ctx, productID+1)
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+			},
+		},
+		"should render an image on demand": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				media, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+
+				_, rendition, err := app.GetRendition(ctx, productID, media.ID, 128)
+				assert.NilError(t, err)
+				defer rendition.Content.Close()
+				assert.Equal(t, "image/jpeg", rendition.ContentType)
+
+				img, format, err := image.Decode(rendition.Content)
+				assert.NilError(t, err)
+				assert.Equal(t, "jpeg", format)
+				assert.Equal(t, image.Rect(0, 0, 128, 85), img.Bounds())
+			},
+		},
+		"should render an image as webp": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, _ App, productID int64) {
+				app := NewApp(store, config.Media{MaxSizeMB: 1, Renditions: []int{128}, RenditionFormat: "webp"})
+				media, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+
+				_, rendition, err := app.GetRendition(ctx, productID, media.ID, 128)
+				assert.NilError(t, err)
+				defer rendition.Content.Close()
+				assert.Equal(t, "image/webp", rendition.ContentType)
+
+				img, format, err := image.Decode(rendition.Content)
+				assert.NilError(t, err)
+				assert.Equal(t, "webp", format)
+				assert.Equal(t, image.Rect(0, 0, 128, 85), img.Bounds())
+			},
+		},
+		"should render the uploaded images in the background": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+				go app.Work(ctx)
+
+				media, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+				saved, err := store.Media.GetByID(ctx, productID, media.ID)
+				assert.NilError(t, err)
+
+				key := mediaModel.RenditionKey(saved.BlobKey, 128, "jpeg")
+				poll.WaitOn(t, func(poll.LogT) poll.Result {
+					if _, err := store.Blob.Get(ctx, key); err != nil {
+						return poll.Continue("%s", err)
+					}
+					return poll.Success()
+				})
+			},
+		},
+		"should throw error for a size not rendered": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				media, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+
+				_, _, err = app.GetRendition(ctx, productID, media.ID, 256)
+				assert.ErrorIs(t, err, errorsP.ErrInvalidQuery)
+			},
+		},
+		"should throw error when rendering a pdf": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				media, err := app.Upload(ctx, productID, "manual.pdf", strings.NewReader("%PDF-1.4\n%%EOF\n"))
+				assert.NilError(t, err)
+				assert.Equal(t, "application/pdf", media.ContentType)
+
+				_, _, err = app.GetRendition(ctx, productID, media.ID, 128)
+				assert.ErrorIs(t, err, errorsP.ErrInvalidQuery)
+			},
+		},
+		"should delete a media and its blobs": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				media, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+				_, rendition, err := app.GetRendition(ctx, productID, media.ID, 128)
+				assert.NilError(t, err)
+				rendition.Content.Close()
+				saved, err := store.Media.GetByID(ctx, productID, media.ID)
+				assert.NilError(t, err)
+
+				assert.NilError(t, app.Delete(ctx, productID, media.ID))
+
+				_, _, err = app.Get(ctx, productID, media.ID)
+				assert.ErrorIs(t, err, mediaModel.ErrorMediaNotFound)
+				_, err = store.Blob.Get(ctx, mediaModel.RenditionKey(saved.BlobKey, 128, "jpeg"))
+				assert.ErrorIs(t, err, blob.ErrNotFound)
+				assert.ErrorIs(t, app.Delete(ctx, productID, media.ID), mediaModel.ErrorMediaNotFound)
+			},
+		},
+		"should delete the blobs with the product": {
+			Run: func(t *testing.T, ctx context.Context, store *store.Container, app App, productID int64) {
+				media, err := app.Upload(ctx, productID, "photo.png", bytes.NewReader(pngImage))
+				assert.NilError(t, err)
+				_, rendition, err := app.GetRendition(ctx, productID, media.ID, 128)
+				assert.NilError(t, err)
+				rendition.Content.Close()
+				saved, err := store.Media.GetByID(ctx, productID, media.ID)
+				assert.NilError(t, err)
+
+				assert.NilError(t, product.NewApp(store).Delete(ctx, productID))
+
+				_, err = store.Media.GetByID(ctx, productID, media.ID)
+				assert.ErrorIs(t, err, mediaModel.ErrorMediaNotFound)
+				_, err = store.Blob.Get(ctx, saved.BlobKey)
+				assert.ErrorIs(t, err, blob.ErrNotFound)
+				_, err = store.Blob.Get(ctx, mediaModel.RenditionKey(saved.BlobKey, 128, "jpeg"))
+				assert.ErrorIs(t, err, blob.ErrNotFound)
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := store.NewMemory()
+			id, err := store.Product.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+			assert.NilError(t, err)
+
+			cs.Run(t, ctx, store, NewApp(store, config.Media{MaxSizeMB: 1, Renditions: []int{128}, RenditionFormat: "jpeg"}), *id)
+		})
+	}
+}
+
+// countingStorage counts the reads of the originals and holds them until release is closed
+type countingStorage struct {
+	blob.Storage
+	originals atomic.Int64
+	release   chan struct{}
+}
+
+func (s *countingStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(key, "renditions/") {
+		s.originals.Add(1)
+		<-s.release
+	}
+	return s.Storage.Get(ctx, key)
+}
+
+func TestGetRenditionRendersOnce(t *testing.T) {
+	ctx := context.Background()
+	store := store.NewMemory()
+	id, err := store.Product.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+	assert.NilError(t, err)
+	app := NewApp(store, config.Media{MaxSizeMB: 1, Renditions: []int{128}, RenditionFormat: "jpeg"})
+	media, err := app.Upload(ctx, *id, "photo.png", bytes.NewReader(encodePNG(t, 300, 200)))
+	assert.NilError(t, err)
+
+	counting := &countingStorage{Storage: store.Blob, release: make(chan struct{})}
+	store.Blob = counting
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 10; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, rendition, err := app.GetRendition(ctx, *id, media.ID, 128)
+			if assert.Check(t, err) {
+				rendition.Content.Close()
+			}
+		}()
+	}
+
+	// lets the requests pile up behind the first render
+	time.Sleep(50 * time.Millisecond)
+	close(counting.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), counting.originals.Load())
+}
+
+func TestResize(t *testing.T) {
+	cases := map[string]struct {
+		Width, Height  int
+		Size           int
+		ExpectedBounds image.Rectangle
+	}{
+		"should fit a landscape image by its width": {
+			Width: 1000, Height: 500, Size: 128,
+			ExpectedBounds: image.Rect(0, 0, 128, 64),
+		},
+		"should fit a portrait image by its height": {
+			Width: 500, Height: 1000, Size: 128,
+			ExpectedBounds: image.Rect(0, 0, 64, 128),
+		},
+		"should not enlarge a small image": {
+			Width: 100, Height: 50, Size: 512,
+			ExpectedBounds: image.Rect(0, 0, 100, 50),
+		},
+		"should keep at least a pixel": {
+			Width: 2000, Height: 1, Size: 128,
+			ExpectedBounds: image.Rect(0, 0, 128, 1),
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			img := resize(image.NewRGBA(image.Rect(0, 0, cs.Width, cs.Height)), cs.Size, true)
+			assert.Equal(t, cs.ExpectedBounds, img.Bounds())
+		})
+	}
+}
+
+func TestDecodeRefusesTooManyPixels(t *testing.T) {
+	// the header claims 10000x10000 pixels, more than maxPixels
+	var content bytes.Buffer
+	assert.NilError(t, png.Encode(&content, image.NewGray(image.Rect(0, 0, 1, 1))))
+	header := content.Bytes()
+	binary.BigEndian.PutUint32(header[16:], 10000)
+	binary.BigEndian.PutUint32(header[20:], 10000)
+	binary.BigEndian.PutUint32(header[29:], crc32.ChecksumIEEE(header[12:29]))
+
+	_, err := decode(bytes.NewReader(header))
+	assert.ErrorIs(t, err, errTooManyPixels)
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var content bytes.Buffer
+	assert.NilError(t, png.Encode(&content, img))
+	return content.Bytes()
+}
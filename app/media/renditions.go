@@ -0,0 +1,241 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decodes the gif uploads
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"time"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"github.com/danilotadeu/products/store/blob"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/danilotadeu/products/webp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decodes the webp uploads
+)
+
+const (
+	// jobsBuffer is how many uploads wait for the worker, past it the renditions are made on demand
+	jobsBuffer = 100
+	// maxPixels refuses the images that would take too much memory to decode
+	maxPixels = 50_000_000
+	// jpegQuality is the quality of the jpeg renditions
+	jpegQuality = 85
+)
+
+// errTooManyPixels is returned by decode for the images past maxPixels
+var errTooManyPixels = errors.New("media: image too large to render")
+
+// Work renders the images uploaded to the App until ctx is done, the uploads still
+// waiting are dropped and rendered on demand
+func (a *appImpl) Work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case media := <-a.jobs:
+			a.renderUpload(ctx, media)
+		}
+	}
+}
+
+func (a *appImpl) GetRendition(ctx context.Context, productID, id int64, size int) (*mediaModel.MediaDB, *mediaModel.Rendition, error) {
+	ctx, span := tracing.Start(ctx, "app.media.GetRendition")
+	defer span.End()
+
+	if !a.rendered(size) {
+		return nil, nil, errorsP.ErrInvalidQuery.WithDetail("detail.invalid_rendition_size", size, a.sizes())
+	}
+
+	media, err := a.store.Media.GetByID(ctx, productID, id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.GetRendition.Store.Media.GetByID"}).WithError(err).Error(err)
+		return nil, nil, err
+	}
+	if !isImage(media.ContentType) {
+		return nil, nil, errorsP.ErrInvalidQuery.WithDetail("detail.rendition_not_image", id)
+	}
+
+	key := mediaModel.RenditionKey(media.BlobKey, size, a.format)
+	content, err := a.store.Blob.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		// not rendered yet or lost, made now for the next requests as well
+		if err := a.renderOnce(ctx, *media, size, key); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.GetRendition.renderOnce"}).WithError(err).Error(err)
+			return nil, nil, err
+		}
+		content, err = a.store.Blob.Get(ctx, key)
+	}
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.GetRendition.Store.Blob.Get"}).WithError(err).Error(err)
+		return nil, nil, err
+	}
+
+	return media, &mediaModel.Rendition{
+		Size:        size,
+		ContentType: "image/" + a.format,
+		Content:     content,
+	}, nil
+}
+
+// renderOnce renders size for the concurrent requests of the rendition key at once, the render
+// outlives the request that started it as the others still wait for it
+func (a *appImpl) renderOnce(ctx context.Context, media mediaModel.MediaDB, size int, key string) error {
+	result := a.renders.DoChan(key, func() (interface{}, error) {
+		return nil, a.render(detached{ctx}, media, size)
+	})
+
+	select {
+	case res := <-result:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// detached keeps the values of the caller, as its span, but not its deadline nor cancellation
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detached) Done() <-chan struct{}               { return nil }
+func (detached) Err() error                          { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+// enqueue hands the upload to the worker, without waiting for it
+func (a *appImpl) enqueue(ctx context.Context, media mediaModel.MediaDB) {
+	if len(a.renditions) == 0 || !isImage(media.ContentType) {
+		return
+	}
+	select {
+	case a.jobs <- media:
+	default:
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.enqueue", "media": media.ID}).Warn("the renditions queue is full, rendering on demand")
+	}
+}
+
+// renderUpload makes every rendition of the upload, removing them when the media was
+// deleted while they were made
+func (a *appImpl) renderUpload(ctx context.Context, media mediaModel.MediaDB) {
+	ctx, span := tracing.Start(ctx, "app.media.renderUpload")
+	defer span.End()
+
+	if err := a.render(ctx, media, a.renditions...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.media.renderUpload.render", "media": media.ID}).WithError(err).Error(err)
+		return
+	}
+
+	if _, err := a.store.Media.GetByID(ctx, media.ProductID, media.ID); errors.Is(err, mediaModel.ErrorMediaNotFound) {
+		a.deleteBlob(ctx, media.BlobKey)
+	}
+}
+
+// render decodes the image once and keeps its renditions of sizes
+func (a *appImpl) render(ctx context.Context, media mediaModel.MediaDB, sizes ...int) error {
+	original, err := a.store.Blob.Get(ctx, media.BlobKey)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	img, err := decode(original)
+	if err != nil {
+		return err
+	}
+
+	for _, size := range sizes {
+		var content bytes.Buffer
+		if err := encode(&content, resize(img, size, a.format == "jpeg"), a.format); err != nil {
+			return err
+		}
+		if err := a.store.Blob.Put(ctx, mediaModel.RenditionKey(media.BlobKey, size, a.format), &content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *appImpl) rendered(size int) bool {
+	for _, rendered := range a.renditions {
+		if size == rendered {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *appImpl) sizes() string {
+	sizes := make([]string, len(a.renditions))
+	for idx, size := range a.renditions {
+		sizes[idx] = fmt.Sprint(size)
+	}
+	return strings.Join(sizes, ", ")
+}
+
+func isImage(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// decode reads the dimensions before the pixels, so a small file can not claim a huge image
+func decode(r io.Reader) (image.Image, error) {
+	var head bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, err
+	}
+	if int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, errTooManyPixels
+	}
+
+	img, _, err := image.Decode(io.MultiReader(&head, r))
+	return img, err
+}
+
+// resize fits img in a square of size, never enlarging it. The jpeg renditions have no
+// transparency, so opaque lays the image over white
+func resize(img image.Image, size int, opaque bool) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, atLeastOne(height*size/width)
+		} else {
+			width, height = atLeastOne(width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if opaque {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+func encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "webp":
+		return webp.Encode(w, img)
+	case "png":
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,58 @@
+package product
+
+import (
+	"sync"
+
+	productModel "github.com/danilotadeu/products/model/product"
+)
+
+// eventBuffer is how many events a watcher may fall behind before it is dropped
+const eventBuffer = 64
+
+// events fans out the product changes to the watchers of this instance
+type events struct {
+	mu       sync.Mutex
+	watchers map[chan productModel.Event]struct{}
+}
+
+func newEvents() *events {
+	return &events{
+		watchers: map[chan productModel.Event]struct{}{},
+	}
+}
+
+func (e *events) subscribe() chan productModel.Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ch := make(chan productModel.Event, eventBuffer)
+	e.watchers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe closes ch unless it was already dropped
+func (e *events) unsubscribe(ch chan productModel.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.watchers[ch]; ok {
+		delete(e.watchers, ch)
+		close(ch)
+	}
+}
+
+// publish never blocks the writer, a watcher with a full buffer is closed so it
+// knows it missed events instead of silently skipping them
+func (e *events) publish(event productModel.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.watchers {
+		select {
+		case ch <- event:
+		default:
+			delete(e.watchers, ch)
+			close(ch)
+		}
+	}
+}
@@ -2,9 +2,15 @@ package product
 
 import (
 	"context"
+	"errors"
 
+	"github.com/danilotadeu/products/barcode"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	mediaModel "github.com/danilotadeu/products/model/media"
 	productModel "github.com/danilotadeu/products/model/product"
 	"github.com/danilotadeu/products/store"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,56 +19,168 @@ type App interface {
 	SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error)
 	UpdateProduct(ctx context.Context, product productModel.ProductDB) error
 	GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error)
+	// GetByBarcode is the product of a UPC-A, EAN-13 or GTIN-14, the code is compared by
+	// its GTIN-14 so a UPC-A finds the product saved with the same EAN-13
+	GetByBarcode(ctx context.Context, code string) (*productModel.ProductDB, error)
+	// GetByIDs loads the existing products of ids in one query, ordered by id
+	GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error)
 	GetAllProducts(ctx context.Context, page, offset int64, name string) ([]*productModel.ProductDB, error)
 	Delete(ctx context.Context, productID int64) error
 	GetTotalProducts(ctx context.Context) (*int64, error)
+	GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error)
+	// Watch streams the committed changes made through this App until ctx is done,
+	// the channel is closed then or earlier when the reader falls behind
+	Watch(ctx context.Context) <-chan productModel.Event
 }
 
 type appImpl struct {
-	store *store.Container
+	store  *store.Container
+	events *events
 }
 
 // NewApp init a planet
 func NewApp(store *store.Container) App {
 	return &appImpl{
-		store: store,
+		store:  store,
+		events: newEvents(),
 	}
 }
 
 func (a *appImpl) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
-	id, err := a.store.Product.SaveProduct(ctx, product)
+	ctx, span := tracing.Start(ctx, "app.product.SaveProduct")
+	defer span.End()
+
+	barcodes, err := a.barcodes(ctx, 0, product.Barcodes)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.SaveProduct.Store.Product.SaveProduct"}).Error(err)
 		return nil, err
 	}
 
+	var id *int64
+	err = a.store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		id, err = a.store.Product.SaveProduct(ctx, product)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.SaveProduct.Store.Product.SaveProduct"}).WithError(err).Error(err)
+			return err
+		}
+
+		if len(barcodes) > 0 {
+			if err := a.store.Barcode.Replace(ctx, *id, barcodes); err != nil {
+				logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.SaveProduct.Store.Barcode.Replace"}).WithError(err).Error(err)
+				return err
+			}
+		}
+
+		product.ID = *id
+		product.Barcodes = codes(barcodes)
+		a.publish(ctx, productModel.EventCreated, product)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return id, nil
 }
 
+// UpdateProduct replaces the barcodes only when the product has them, a missing product
+// is not found then
 func (a *appImpl) UpdateProduct(ctx context.Context, product productModel.ProductDB) error {
-	err := a.store.Product.Update(ctx, product)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.UpdateProduct.Store.Product.Update"}).Error(err)
-		return err
+	ctx, span := tracing.Start(ctx, "app.product.UpdateProduct")
+	defer span.End()
+
+	// without barcodes the saved ones are kept
+	var barcodes []productModel.BarcodeDB
+	if product.Barcodes != nil {
+		var err error
+		barcodes, err = a.barcodes(ctx, product.ID, product.Barcodes)
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return a.store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// the update of a missing id touches no row, so it is checked first to not publish it
+		if _, err := a.store.Product.GetOneByID(ctx, product.ID); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.UpdateProduct.Store.Product.GetOneByID"}).WithError(err).Error(err)
+			return err
+		}
+
+		if err := a.store.Product.Update(ctx, product); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.UpdateProduct.Store.Product.Update"}).WithError(err).Error(err)
+			return err
+		}
+
+		if barcodes != nil {
+			if err := a.store.Barcode.Replace(ctx, product.ID, barcodes); err != nil {
+				logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.UpdateProduct.Store.Barcode.Replace"}).WithError(err).Error(err)
+				return err
+			}
+			product.Barcodes = codes(barcodes)
+		}
+
+		a.publish(ctx, productModel.EventUpdated, product)
+		return nil
+	})
 }
 
 func (a *appImpl) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	ctx, span := tracing.Start(ctx, "app.product.GetOneByID")
+	defer span.End()
+
 	product, err := a.store.Product.GetOneByID(ctx, id)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.GetOneByID.Store.Product.GetOneByID"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetOneByID.Store.Product.GetOneByID"}).WithError(err).Error(err)
 		return nil, err
 	}
 
+	if err := a.withBarcodes(ctx, product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetOneByID.withBarcodes"}).WithError(err).Error(err)
+		return nil, err
+	}
 	return product, nil
 }
 
+func (a *appImpl) GetByBarcode(ctx context.Context, code string) (*productModel.ProductDB, error) {
+	ctx, span := tracing.Start(ctx, "app.product.GetByBarcode")
+	defer span.End()
+
+	gtin, err := barcode.GTIN(code)
+	if err != nil {
+		return nil, errorsP.ErrInvalidBarcode.WithDetail("detail.invalid_barcode", code)
+	}
+
+	id, err := a.store.Barcode.GetProductID(ctx, gtin)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetByBarcode.Store.Barcode.GetProductID"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return a.GetOneByID(ctx, *id)
+}
+
+func (a *appImpl) GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+	ctx, span := tracing.Start(ctx, "app.product.GetByIDs")
+	defer span.End()
+
+	products, err := a.store.Product.GetByIDs(ctx, ids)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetByIDs.Store.Product.GetByIDs"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	if err := a.withBarcodes(ctx, products...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetByIDs.withBarcodes"}).WithError(err).Error(err)
+		return nil, err
+	}
+	return products, nil
+}
+
 func (a *appImpl) GetAllProducts(ctx context.Context, page, offset int64, name string) ([]*productModel.ProductDB, error) {
+	ctx, span := tracing.Start(ctx, "app.product.GetAllProducts")
+	defer span.End()
+
 	planets, err := a.store.Product.GetAll(ctx, page, offset, name)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.GetAllPlanets.Store.Planet.GetAll"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetAllPlanets.Store.Planet.GetAll"}).WithError(err).Error(err)
 		return nil, err
 	}
 
@@ -70,34 +188,153 @@ func (a *appImpl) GetAllProducts(ctx context.Context, page, offset int64, name s
 		return nil, productModel.ErrorProductNotFound
 	}
 
-	planetIDs := make([]int64, len(planets))
-	for idx, planet := range planets {
-		planetIDs[idx] = planet.ID
+	if err := a.withBarcodes(ctx, planets...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetAllProducts.withBarcodes"}).WithError(err).Error(err)
+		return nil, err
 	}
-
 	return planets, nil
 }
 
 func (a *appImpl) Delete(ctx context.Context, productID int64) error {
-	product, err := a.store.Product.GetOneByID(ctx, productID)
+	ctx, span := tracing.Start(ctx, "app.product.Delete")
+	defer span.End()
+
+	return a.store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		product, err := a.store.Product.GetOneByID(ctx, productID)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Product.GetOneByID"}).WithError(err).Error(err)
+			return err
+		}
+
+		err = a.store.Product.Delete(ctx, product.ID)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Product.Delete"}).WithError(err).Error(err)
+			return err
+		}
+
+		// the barcodes of a deleted product are free for the live ones
+		if err := a.store.Barcode.DeleteByProduct(ctx, product.ID); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Barcode.DeleteByProduct"}).WithError(err).Error(err)
+			return err
+		}
+
+		medias, err := a.store.Media.DeleteByProduct(ctx, product.ID)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Media.DeleteByProduct"}).WithError(err).Error(err)
+			return err
+		}
+		// a rollback must not lose the content of the media, so the blobs wait for the commit
+		tx.AfterCommit(ctx, func() {
+			for _, media := range medias {
+				if err := a.store.Blob.Delete(ctx, media.BlobKey); err != nil {
+					logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Blob.Delete", "key": media.BlobKey}).WithError(err).Error(err)
+				}
+				if err := a.store.Blob.DeletePrefix(ctx, mediaModel.RenditionPrefix(media.BlobKey)); err != nil {
+					logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Blob.DeletePrefix", "key": media.BlobKey}).WithError(err).Error(err)
+				}
+			}
+		})
+
+		a.publish(ctx, productModel.EventDeleted, *product)
+		return nil
+	})
+}
+
+func (a *appImpl) GetTotalProducts(ctx context.Context) (*int64, error) {
+	ctx, span := tracing.Start(ctx, "app.product.GetTotalProducts")
+	defer span.End()
+
+	total, err := a.store.Product.GetTotalProducts(ctx)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Product.GetOneByID"}).Error(err)
-		return err
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetTotalProducts.Store.Planet.GetTotalProducts"}).WithError(err).Error(err)
+		return nil, err
+	}
+	return total, nil
+}
+
+func (a *appImpl) GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error) {
+	ctx, span := tracing.Start(ctx, "app.product.GetStockStats")
+	defer span.End()
+
+	stats, err := a.store.Product.GetStockStats(ctx, reorderPoint)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.GetStockStats.Store.Product.GetStockStats"}).WithError(err).Error(err)
+		return nil, err
 	}
+	return stats, nil
+}
+
+func (a *appImpl) Watch(ctx context.Context) <-chan productModel.Event {
+	ch := a.events.subscribe()
+	go func() {
+		<-ctx.Done()
+		a.events.unsubscribe(ch)
+	}()
+	return ch
+}
+
+// publish notifies the watchers once the transaction of ctx, if any, commits
+func (a *appImpl) publish(ctx context.Context, eventType productModel.EventType, product productModel.ProductDB) {
+	tx.AfterCommit(ctx, func() {
+		a.events.publish(productModel.Event{Type: eventType, Product: product})
+	})
+}
 
-	err = a.store.Product.Delete(ctx, product.ID)
+// barcodes normalizes codes to their GTIN-14, dropping the repeated ones, and checks
+// none belongs to a product other than productID
+func (a *appImpl) barcodes(ctx context.Context, productID int64, codes []string) ([]productModel.BarcodeDB, error) {
+	barcodes := []productModel.BarcodeDB{}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		gtin, err := barcode.GTIN(code)
+		if err != nil {
+			return nil, errorsP.ErrInvalidBarcode.WithDetail("detail.invalid_barcode", code)
+		}
+		if seen[gtin] {
+			continue
+		}
+		seen[gtin] = true
+
+		owner, err := a.store.Barcode.GetProductID(ctx, gtin)
+		switch {
+		case errors.Is(err, productModel.ErrorProductNotFound):
+		case err != nil:
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.product.barcodes.Store.Barcode.GetProductID"}).WithError(err).Error(err)
+			return nil, err
+		case *owner != productID:
+			return nil, productModel.ErrorBarcodeAlreadyExists.WithDetail("detail.barcode_already_exists", code)
+		}
+		barcodes = append(barcodes, productModel.BarcodeDB{ProductID: productID, Code: code, GTIN: gtin})
+	}
+	return barcodes, nil
+}
+
+// withBarcodes loads the barcodes of products in one query
+func (a *appImpl) withBarcodes(ctx context.Context, products ...*productModel.ProductDB) error {
+	ids := make([]int64, len(products))
+	for idx, product := range products {
+		ids[idx] = product.ID
+	}
+
+	barcodes, err := a.store.Barcode.ListByProducts(ctx, ids)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.Delete.Store.Product.Delete"}).Error(err)
 		return err
 	}
+
+	byProduct := map[int64][]string{}
+	for _, saved := range barcodes {
+		byProduct[saved.ProductID] = append(byProduct[saved.ProductID], saved.Code)
+	}
+	for _, product := range products {
+		product.Barcodes = byProduct[product.ID]
+	}
 	return nil
 }
 
-func (a *appImpl) GetTotalProducts(ctx context.Context) (*int64, error) {
-	total, err := a.store.Product.GetTotalProducts(ctx)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "app.product.GetTotalProducts.Store.Planet.GetTotalProducts"}).Error(err)
-		return nil, err
+func codes(barcodes []productModel.BarcodeDB) []string {
+	codes := make([]string, len(barcodes))
+	for idx, saved := range barcodes {
+		codes[idx] = saved.Code
 	}
-	return total, nil
+	return codes
 }
@@ -1 +1,234 @@
 package product
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store"
+	"gotest.tools/v3/assert"
+)
+
+func TestApp(t *testing.T) {
+	cases := map[string]struct {
+		Run func(t *testing.T, ctx context.Context, app App)
+	}{
+		"should save and get a product": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 10})
+				assert.NilError(t, err)
+
+				product, err := app.GetOneByID(ctx, *id)
+				assert.NilError(t, err)
+				assert.Equal(t, "Product 1", product.Name)
+				assert.Equal(t, int64(10), product.Quantity)
+			},
+		},
+		"should throw error when the name is taken": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				_, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 10})
+				assert.NilError(t, err)
+
+				_, err = app.SaveProduct(ctx, productModel.ProductDB{Name: "product 1", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+			},
+		},
+		"should update a product": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 10})
+				assert.NilError(t, err)
+
+				assert.NilError(t, app.UpdateProduct(ctx, productModel.ProductDB{ID: *id, Name: "Product 2", Quantity: 3}))
+
+				product, err := app.GetOneByID(ctx, *id)
+				assert.NilError(t, err)
+				assert.Equal(t, "Product 2", product.Name)
+				assert.Equal(t, int64(3), product.Quantity)
+			},
+		},
+		"should get a batch of products": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				var ids []int64
+				for _, name := range []string{"Apple", "Banana"} {
+					id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: name, Quantity: 1})
+					assert.NilError(t, err)
+					ids = append(ids, *id)
+				}
+
+				products, err := app.GetByIDs(ctx, []int64{ids[1], 404, ids[0]})
+				assert.NilError(t, err)
+				assert.Equal(t, 2, len(products))
+				assert.Equal(t, "Apple", products[0].Name)
+				assert.Equal(t, "Banana", products[1].Name)
+			},
+		},
+		"should throw error not found when there are no products": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				_, err := app.GetAllProducts(ctx, 0, 10, "")
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+			},
+		},
+		"should list the products by name": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				for _, name := range []string{"Apple", "Banana", "Pineapple"} {
+					_, err := app.SaveProduct(ctx, productModel.ProductDB{Name: name, Quantity: 1})
+					assert.NilError(t, err)
+				}
+
+				products, err := app.GetAllProducts(ctx, 0, 10, "APPLE")
+				assert.NilError(t, err)
+				assert.Equal(t, 2, len(products))
+				assert.Equal(t, "Apple", products[0].Name)
+				assert.Equal(t, "Pineapple", products[1].Name)
+			},
+		},
+		"should delete a product": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 10})
+				assert.NilError(t, err)
+
+				assert.NilError(t, app.Delete(ctx, *id))
+
+				_, err = app.GetOneByID(ctx, *id)
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+				total, err := app.GetTotalProducts(ctx)
+				assert.NilError(t, err)
+				assert.Equal(t, int64(0), *total)
+			},
+		},
+		"should throw error not found when deleting a missing product": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				err := app.Delete(ctx, 1)
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+			},
+		},
+		"should save the barcodes and find the product by any of their forms": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: []string{"036000291452", "0036000291452", "4006381333931"}})
+				assert.NilError(t, err)
+
+				product, err := app.GetOneByID(ctx, *id)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"036000291452", "4006381333931"}, product.Barcodes)
+
+				for _, code := range []string{"036000291452", "0036000291452", "00036000291452", "4006381333931"} {
+					product, err := app.GetByBarcode(ctx, code)
+					assert.NilError(t, err)
+					assert.Equal(t, *id, product.ID)
+				}
+
+				_, err = app.GetByBarcode(ctx, "4006381333900")
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+				_, err = app.GetByBarcode(ctx, "4006381333932")
+				assert.ErrorIs(t, err, errorsP.ErrInvalidBarcode)
+			},
+		},
+		"should throw error when the barcode belongs to another product": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				_, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: []string{"036000291452"}})
+				assert.NilError(t, err)
+
+				_, err = app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 2", Quantity: 1, Barcodes: []string{"0036000291452"}})
+				assert.ErrorIs(t, err, productModel.ErrorBarcodeAlreadyExists)
+				total, err := app.GetTotalProducts(ctx)
+				assert.NilError(t, err)
+				assert.Equal(t, int64(1), *total)
+			},
+		},
+		"should keep the barcodes on an update without them": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: []string{"036000291452"}})
+				assert.NilError(t, err)
+
+				assert.NilError(t, app.UpdateProduct(ctx, productModel.ProductDB{ID: *id, Name: "Product 1", Quantity: 2}))
+				product, err := app.GetOneByID(ctx, *id)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"036000291452"}, product.Barcodes)
+
+				assert.NilError(t, app.UpdateProduct(ctx, productModel.ProductDB{ID: *id, Name: "Product 1", Quantity: 2, Barcodes: []string{"4006381333931"}}))
+				product, err = app.GetOneByID(ctx, *id)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"4006381333931"}, product.Barcodes)
+
+				assert.NilError(t, app.UpdateProduct(ctx, productModel.ProductDB{ID: *id, Name: "Product 1", Quantity: 2, Barcodes: []string{}}))
+				product, err = app.GetOneByID(ctx, *id)
+				assert.NilError(t, err)
+				assert.Equal(t, 0, len(product.Barcodes))
+
+				err = app.UpdateProduct(ctx, productModel.ProductDB{ID: 404, Name: "Product 2", Quantity: 2, Barcodes: []string{"036000291452"}})
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+			},
+		},
+		"should free the barcodes of a deleted product": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: []string{"036000291452"}})
+				assert.NilError(t, err)
+				assert.NilError(t, app.Delete(ctx, *id))
+
+				_, err = app.GetByBarcode(ctx, "036000291452")
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+				_, err = app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 2", Quantity: 1, Barcodes: []string{"036000291452"}})
+				assert.NilError(t, err)
+			},
+		},
+		"should sum the stock": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				for name, quantity := range map[string]int64{"Product 1": 5, "Product 2": 20} {
+					_, err := app.SaveProduct(ctx, productModel.ProductDB{Name: name, Quantity: quantity})
+					assert.NilError(t, err)
+				}
+
+				stats, err := app.GetStockStats(ctx, 10)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, productModel.StockStats{Total: 2, Units: 25, BelowReorderPoint: 1}, *stats)
+			},
+		},
+		"should stream the changes to the watchers": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				ctx, cancel := context.WithCancel(ctx)
+				events := app.Watch(ctx)
+
+				id, err := app.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 10})
+				assert.NilError(t, err)
+				assert.NilError(t, app.UpdateProduct(ctx, productModel.ProductDB{ID: *id, Name: "Product 2", Quantity: 3}))
+				err = app.UpdateProduct(ctx, productModel.ProductDB{ID: 404, Name: "Product 3", Quantity: 3})
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+				assert.NilError(t, app.Delete(ctx, *id))
+				_, err = app.SaveProduct(ctx, productModel.ProductDB{Name: "product 2", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+
+				for _, expected := range []productModel.EventType{productModel.EventCreated, productModel.EventUpdated, productModel.EventDeleted} {
+					event := <-events
+					assert.Equal(t, expected, event.Type)
+					assert.Equal(t, *id, event.Product.ID)
+				}
+
+				cancel()
+				_, open := <-events
+				assert.Assert(t, !open)
+			},
+		},
+		"should drop a watcher that falls behind": {
+			Run: func(t *testing.T, ctx context.Context, app App) {
+				events := app.Watch(ctx)
+				for idx := 0; idx <= eventBuffer; idx++ {
+					_, err := app.SaveProduct(ctx, productModel.ProductDB{Name: fmt.Sprintf("Product %d", idx), Quantity: 1})
+					assert.NilError(t, err)
+				}
+
+				received := 0
+				for range events {
+					received++
+				}
+				assert.Equal(t, eventBuffer, received)
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			cs.Run(t, context.Background(), NewApp(store.NewMemory()))
+		})
+	}
+}
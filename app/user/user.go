@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+
+	"github.com/danilotadeu/products/app/apikey"
+	userModel "github.com/danilotadeu/products/model/user"
+	"github.com/danilotadeu/products/store"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+//go:generate mockgen -destination ../../mock/app/user/user_app_mock.go -package mockAppUser . App
+type App interface {
+	CreateUser(ctx context.Context, name string) (*userModel.CreatedUser, error)
+}
+
+type appImpl struct {
+	store *store.Container
+	keys  apikey.App
+}
+
+// NewApp init an user, keys issues the first api key of the users
+func NewApp(store *store.Container, keys apikey.App) App {
+	return &appImpl{
+		store: store,
+		keys:  keys,
+	}
+}
+
+// CreateUser saves the user with its first api key, owned by the user name, in the same
+// transaction
+func (a *appImpl) CreateUser(ctx context.Context, name string) (*userModel.CreatedUser, error) {
+	ctx, span := tracing.Start(ctx, "app.user.CreateUser")
+	defer span.End()
+
+	var created *userModel.CreatedUser
+	err := a.store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		id, err := a.store.User.SaveUser(ctx, userModel.UserDB{Name: name})
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "app.user.CreateUser.Store.User.SaveUser"}).WithError(err).Error(err)
+			return err
+		}
+
+		apiKey, err := a.keys.CreateAPIKey(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		created = &userModel.CreatedUser{
+			ID:     *id,
+			Name:   name,
+			APIKey: *apiKey,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danilotadeu/products/app/apikey"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	userModel "github.com/danilotadeu/products/model/user"
+	"github.com/danilotadeu/products/store"
+	"gotest.tools/v3/assert"
+)
+
+func TestApp(t *testing.T) {
+	cases := map[string]struct {
+		Run func(t *testing.T, ctx context.Context, app App, keys apikey.App)
+	}{
+		"should create a user with an api key": {
+			Run: func(t *testing.T, ctx context.Context, app App, keys apikey.App) {
+				created, err := app.CreateUser(ctx, "luke")
+				assert.NilError(t, err)
+				assert.Equal(t, "luke", created.Name)
+				assert.Equal(t, "luke", created.APIKey.Owner)
+
+				apiKey, err := keys.Authenticate(ctx, created.APIKey.Key)
+				assert.NilError(t, err)
+				assert.Equal(t, created.APIKey.ID, apiKey.ID)
+			},
+		},
+		"should throw error when the name is taken": {
+			Run: func(t *testing.T, ctx context.Context, app App, keys apikey.App) {
+				_, err := app.CreateUser(ctx, "luke")
+				assert.NilError(t, err)
+
+				_, err = app.CreateUser(ctx, "luke")
+				assert.ErrorIs(t, err, userModel.ErrorUserAlreadyExists)
+			},
+		},
+		"should refuse an unknown api key": {
+			Run: func(t *testing.T, ctx context.Context, app App, keys apikey.App) {
+				_, err := keys.Authenticate(ctx, "pk_unknown")
+				assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			storage := store.NewMemory()
+			keys := apikey.NewApp(storage)
+			cs.Run(t, context.Background(), NewApp(storage, keys), keys)
+		})
+	}
+}
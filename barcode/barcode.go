@@ -0,0 +1,76 @@
+// Package barcode parses the GS1 identifiers read by the scanners: UPC-A, EAN-13 and GTIN-14.
+// They are the same number padded with zeros on the left, so a code is compared by its GTIN-14
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	UPCA   = "UPC-A"
+	EAN13  = "EAN-13"
+	GTIN14 = "GTIN-14"
+)
+
+var (
+	// ErrInvalidLength is returned for a code that is not 12, 13 or 14 digits long
+	ErrInvalidLength = errors.New("barcode: a UPC-A, EAN-13 or GTIN-14 has 12, 13 or 14 digits")
+	// ErrInvalidDigits is returned for a code with anything but digits
+	ErrInvalidDigits = errors.New("barcode: only digits are allowed")
+	// ErrInvalidCheckDigit is returned when the last digit does not match the others
+	ErrInvalidCheckDigit = errors.New("barcode: invalid check digit")
+)
+
+// types are the codes by their length
+var types = map[int]string{
+	12: UPCA,
+	13: EAN13,
+	14: GTIN14,
+}
+
+// Type is the kind of code by its length, after checking its digits
+func Type(code string) (string, error) {
+	kind, ok := types[len(code)]
+	if !ok {
+		return "", ErrInvalidLength
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidDigits
+		}
+	}
+	if CheckDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", ErrInvalidCheckDigit
+	}
+	return kind, nil
+}
+
+// GTIN normalizes a valid code to its GTIN-14, so 036000291452 (UPC-A) and
+// 0036000291452 (EAN-13) are both 00036000291452
+func GTIN(code string) (string, error) {
+	if _, err := Type(code); err != nil {
+		return "", err
+	}
+	return strings.Repeat("0", 14-len(code)) + code, nil
+}
+
+// Valid reports whether code is a UPC-A, EAN-13 or GTIN-14
+func Valid(code string) bool {
+	_, err := Type(code)
+	return err == nil
+}
+
+// CheckDigit is the GS1 check digit of digits, the code without its last digit: from the
+// right, the digits are weighted 3 and 1 in turns and the check completes the sum to a multiple of 10
+func CheckDigit(digits string) byte {
+	sum := 0
+	for idx := 0; idx < len(digits); idx++ {
+		digit := int(digits[len(digits)-1-idx] - '0')
+		if idx%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
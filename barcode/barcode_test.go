@@ -0,0 +1,84 @@
+package barcode
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestType(t *testing.T) {
+	cases := map[string]struct {
+		Code          string
+		ExpectedType  string
+		ExpectedGTIN  string
+		ExpectedError error
+	}{
+		"should accept a UPC-A": {
+			Code:         "036000291452",
+			ExpectedType: UPCA,
+			ExpectedGTIN: "00036000291452",
+		},
+		"should accept an EAN-13": {
+			Code:         "4006381333931",
+			ExpectedType: EAN13,
+			ExpectedGTIN: "04006381333931",
+		},
+		"should normalize the EAN-13 of a UPC-A to the same GTIN": {
+			Code:         "0036000291452",
+			ExpectedType: EAN13,
+			ExpectedGTIN: "00036000291452",
+		},
+		"should accept a GTIN-14": {
+			Code:         "10012345000017",
+			ExpectedType: GTIN14,
+			ExpectedGTIN: "10012345000017",
+		},
+		"should accept a check digit of zero": {
+			Code:         "4006381333900",
+			ExpectedType: EAN13,
+			ExpectedGTIN: "04006381333900",
+		},
+		"should reject a wrong check digit": {
+			Code:          "4006381333932",
+			ExpectedError: ErrInvalidCheckDigit,
+		},
+		"should reject the letters": {
+			Code:          "40063813339A1",
+			ExpectedError: ErrInvalidDigits,
+		},
+		"should reject the spaces": {
+			Code:          " 036000291452",
+			ExpectedError: ErrInvalidDigits,
+		},
+		"should reject an EAN-8": {
+			Code:          "96385074",
+			ExpectedError: ErrInvalidLength,
+		},
+		"should reject an empty code": {
+			ExpectedError: ErrInvalidLength,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			kind, err := Type(cs.Code)
+			gtin, gtinErr := GTIN(cs.Code)
+			if cs.ExpectedError != nil {
+				assert.ErrorIs(t, err, cs.ExpectedError)
+				assert.ErrorIs(t, gtinErr, cs.ExpectedError)
+				assert.Assert(t, !Valid(cs.Code))
+				return
+			}
+			assert.NilError(t, err)
+			assert.NilError(t, gtinErr)
+			assert.Equal(t, cs.ExpectedType, kind)
+			assert.Equal(t, cs.ExpectedGTIN, gtin)
+			assert.Assert(t, Valid(cs.Code))
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	assert.Equal(t, byte('2'), CheckDigit("03600029145"))
+	assert.Equal(t, byte('1'), CheckDigit("400638133393"))
+	assert.Equal(t, byte('7'), CheckDigit("1001234500001"))
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/app"
+	mockAppProduct "github.com/danilotadeu/products/mock/app/product"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestImportProducts(t *testing.T) {
+	cases := map[string]struct {
+		InputCSV         string
+		PrepareMockApp   func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedImported int
+		ExpectedErr      bool
+		ExpectedErrOut   string
+	}{
+		"should import every line": {
+			InputCSV: "name,quantity\nProduct 1,10\n Product 2 , 3\n",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 2", Quantity: 3}).Return(&id, nil)
+			},
+			ExpectedImported: 2,
+		},
+		"should accept the columns in any order": {
+			InputCSV: "Quantity,Name\n10,Product 1\n",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+			},
+			ExpectedImported: 1,
+		},
+		"should report the invalid lines and keep importing": {
+			InputCSV: "name,quantity\nProduct 1,xpto\n,1\nProduct 3,3\nProduct 4,4\n",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 3", Quantity: 3}).Return(nil, fmt.Errorf("error"))
+				mockProductApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 4", Quantity: 4}).Return(&id, nil)
+			},
+			ExpectedImported: 1,
+			ExpectedErr:      true,
+			ExpectedErrOut:   "line 2: invalid quantity \"xpto\"",
+		},
+		"should throw error without the required columns": {
+			InputCSV:       "name\nProduct 1\n",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {},
+			ExpectedErr:    true,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			mockProductApp := mockAppProduct.NewMockApp(ctrl)
+			cs.PrepareMockApp(mockProductApp)
+
+			var errOut bytes.Buffer
+			imported, err := importProducts(ctx, &app.Container{Product: mockProductApp}, strings.NewReader(cs.InputCSV), &errOut)
+
+			assert.Equal(t, cs.ExpectedImported, imported)
+			assert.Equal(t, cs.ExpectedErr, err != nil)
+			assert.Assert(t, strings.Contains(errOut.String(), cs.ExpectedErrOut))
+		})
+	}
+}
+
+func TestExportProducts(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		Format         string
+		PrepareMockApp func(mockProductApp *mockAppProduct.MockApp)
+		ExpectedOutput string
+		ExpectedErr    bool
+	}{
+		"should export jsonl page by page": {
+			Format: "jsonl",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(0), int64(exportBatch), "").Return([]*productModel.ProductDB{
+					{ID: 1, Name: "Product 1", Quantity: 1, CreatedAt: createdAt},
+				}, nil)
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(1), int64(exportBatch), "").Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedOutput: `{"id":1,"name":"Product 1","quantity":1,"created_at":"2024-05-01T10:00:00Z"}` + "\n",
+		},
+		"should export csv": {
+			Format: "csv",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(0), int64(exportBatch), "").Return([]*productModel.ProductDB{
+					{ID: 1, Name: "Product, 1", Quantity: 1, CreatedAt: createdAt},
+				}, nil)
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), int64(1), int64(exportBatch), "").Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedOutput: "id,name,quantity,created_at\n1,\"Product, 1\",1,2024-05-01T10:00:00Z\n",
+		},
+		"should throw error": {
+			Format: "jsonl",
+			PrepareMockApp: func(mockProductApp *mockAppProduct.MockApp) {
+				mockProductApp.EXPECT().GetAllProducts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("error"))
+			},
+			ExpectedErr: true,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			mockProductApp := mockAppProduct.NewMockApp(ctrl)
+			cs.PrepareMockApp(mockProductApp)
+
+			var out bytes.Buffer
+			err := exportProducts(ctx, &app.Container{Product: mockProductApp}, &out, cs.Format, "")
+
+			assert.Equal(t, cs.ExpectedErr, err != nil)
+			assert.Equal(t, cs.ExpectedOutput, out.String())
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/danilotadeu/products/app"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/spf13/cobra"
+)
+
+// exportBatch is the page size used to walk the catalogue
+const exportBatch = 100
+
+var exportFlags struct {
+	format string
+	output string
+	name   string
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write every product as jsonl or csv",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportFlags.format != "jsonl" && exportFlags.format != "csv" {
+			return fmt.Errorf("unknown format %q, use jsonl or csv", exportFlags.format)
+		}
+
+		out := cmd.OutOrStdout()
+		if exportFlags.output != "" {
+			file, err := os.Create(exportFlags.output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		apps, closeApps, err := openApps(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeApps()
+
+		return exportProducts(cmd.Context(), apps, out, exportFlags.format, exportFlags.name)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFlags.format, "format", "jsonl", "output format: jsonl or csv")
+	exportCmd.Flags().StringVarP(&exportFlags.output, "output", "o", "", "output file (default stdout)")
+	exportCmd.Flags().StringVar(&exportFlags.name, "name", "", "export only the products whose name contains this value")
+}
+
+func exportProducts(ctx context.Context, apps *app.Container, w io.Writer, format, name string) error {
+	write := jsonlWriter(w)
+	flush := func() error { return nil }
+	if format == "csv" {
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write([]string{"id", "name", "quantity", "created_at"}); err != nil {
+			return err
+		}
+		write = func(product *productModel.ProductDB) error {
+			return csvWriter.Write([]string{
+				strconv.FormatInt(product.ID, 10),
+				product.Name,
+				strconv.FormatInt(product.Quantity, 10),
+				product.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	}
+
+	var offset int64
+	for {
+		products, err := apps.Product.GetAllProducts(ctx, offset, exportBatch, name)
+		if errors.Is(err, productModel.ErrorProductNotFound) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			if err := write(product); err != nil {
+				return err
+			}
+		}
+		offset += int64(len(products))
+	}
+
+	return flush()
+}
+
+func jsonlWriter(w io.Writer) func(product *productModel.ProductDB) error {
+	encoder := json.NewEncoder(w)
+	return func(product *productModel.ProductDB) error {
+		return encoder.Encode(product)
+	}
+}
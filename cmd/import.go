@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/danilotadeu/products/app"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import FILE.csv",
+	Short: "Create the products of a csv file with the name and quantity columns",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		apps, closeApps, err := openApps(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeApps()
+
+		imported, err := importProducts(cmd.Context(), apps, file, cmd.ErrOrStderr())
+		fmt.Fprintf(cmd.OutOrStdout(), "imported %d products\n", imported)
+		return err
+	},
+}
+
+// importProducts saves every valid line, the failures are reported to errOut and do not stop the import
+func importProducts(ctx context.Context, apps *app.Container, r io.Reader, errOut io.Writer) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("reading the header: %w", err)
+	}
+	columns := map[string]int{}
+	for idx, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = idx
+	}
+	nameIdx, hasName := columns["name"]
+	quantityIdx, hasQuantity := columns["quantity"]
+	if !hasName || !hasQuantity {
+		return 0, errors.New("the header must have the name and quantity columns")
+	}
+
+	validate := validation.New()
+	imported, failed := 0, 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintln(errOut, err)
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		quantity, err := strconv.ParseInt(strings.TrimSpace(record[quantityIdx]), 10, 64)
+		if err != nil {
+			failed++
+			fmt.Fprintf(errOut, "line %d: invalid quantity %q\n", line, record[quantityIdx])
+			continue
+		}
+
+		product := productModel.ProductDB{
+			Name:     strings.TrimSpace(record[nameIdx]),
+			Quantity: quantity,
+		}
+		if err := validate.Struct(product); err != nil {
+			failed++
+			fmt.Fprintf(errOut, "line %d: %s\n", line, validation.Message(ctx, err))
+			continue
+		}
+
+		if _, err := apps.Product.SaveProduct(ctx, product); err != nil {
+			failed++
+			fmt.Fprintf(errOut, "line %d: %s\n", line, err)
+			continue
+		}
+		imported++
+	}
+
+	if failed > 0 {
+		return imported, fmt.Errorf("%d lines failed", failed)
+	}
+	return imported, nil
+}
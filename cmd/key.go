@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var keyOwner string
+
+// keyCmd manages the api keys the clients send in the X-API-Key header, validated by the
+// auth middleware. The owner is a user created by userCmd, or any integration
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the api keys",
+}
+
+var keyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an api key, the key is shown only once",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if keyOwner == "" {
+			return fmt.Errorf("--owner is required")
+		}
+
+		apps, closeApps, err := openApps(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeApps()
+
+		created, err := apps.APIKey.CreateAPIKey(cmd.Context(), keyOwner)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "id: %d\nowner: %s\nkey: %s\n", created.ID, created.Owner, created.Key)
+		return nil
+	},
+}
+
+func init() {
+	keyCreateCmd.Flags().StringVar(&keyOwner, "owner", "", "user or integration that owns the key")
+	keyCmd.AddCommand(keyCreateCmd)
+}
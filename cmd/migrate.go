@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	serverInit "github.com/danilotadeu/products/server"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:       "migrate up|down|status|goto N",
+	Short:     "Apply, revert or inspect the embedded migrations",
+	Args:      cobra.RangeArgs(1, 2),
+	ValidArgs: []string{"up", "down", "status", "goto"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return serverInit.New(cfg).Migrate(args)
+	},
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/config"
+	"github.com/danilotadeu/products/i18n"
+	serverInit "github.com/danilotadeu/products/server"
+	"github.com/danilotadeu/products/store"
+	"github.com/danilotadeu/products/store/blob"
+	"github.com/danilotadeu/products/store/timeout"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configFile  string
+	printConfig bool
+	cfg         *config.Config
+)
+
+// rootCmd serves the api when no command is given
+var rootCmd = &cobra.Command{
+	Use:          "products",
+	Short:        "Products catalogue api and admin tools",
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		cfg, err = config.Load(configFile)
+		if err != nil {
+			return err
+		}
+		if err := i18n.SetDefault(cfg.Server.Locale); err != nil {
+			return err
+		}
+
+		if printConfig {
+			if err := cfg.Print(os.Stdout); err != nil {
+				return err
+			}
+			os.Exit(0)
+		}
+		return nil
+	},
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "yaml or toml configuration file (default $CONFIG_FILE)")
+	rootCmd.PersistentFlags().BoolVar(&printConfig, "print-config", false, "print the effective configuration with the secrets redacted and exit")
+
+	rootCmd.AddCommand(serveCmd, migrateCmd, importCmd, exportCmd, seedCmd, keyCmd, userCmd)
+}
+
+// Execute runs the command line, the error maps to the exit status with server.ExitCode
+func Execute() error {
+	return rootCmd.Execute()
+}
+
+// openApps wires the same store and app containers used by serve, without the http server.
+// As serve, it refuses a schema migrated by a newer binary before writing to it
+func openApps(ctx context.Context) (*app.Container, func(), error) {
+	// the data of the memory store would be lost when the command exits
+	if cfg.Store == "memory" {
+		return nil, nil, errors.New("the admin commands need a database, set STORE=sql")
+	}
+
+	server := serverInit.New(cfg)
+	db, err := server.ConnectDatabase()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := server.CheckSchema(ctx, db); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	storage, err := blob.Open(cfg.Media.Storage, cfg.Media.Path)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	apps := app.Register(store.Register(db, cfg.Database.Driver).WithTimeouts(timeout.Timeouts{
+		Read:  cfg.Database.ReadTimeout,
+		Write: cfg.Database.WriteTimeout,
+	}).WithBlob(storage), cfg.Media)
+	return apps, func() { db.Close() }, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/spf13/cobra"
+)
+
+// seedMaxQuantity bounds the random quantity of the seeded products
+const seedMaxQuantity = 100
+
+var seedCount int
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Create random products for development",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if seedCount < 1 {
+			return fmt.Errorf("--count must be positive")
+		}
+
+		apps, closeApps, err := openApps(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeApps()
+
+		// names are unique, every run gets its own prefix
+		run := make([]byte, 3)
+		if _, err := rand.Read(run); err != nil {
+			return err
+		}
+
+		for i := 1; i <= seedCount; i++ {
+			quantity, err := rand.Int(rand.Reader, big.NewInt(seedMaxQuantity))
+			if err != nil {
+				return err
+			}
+
+			_, err = apps.Product.SaveProduct(cmd.Context(), productModel.ProductDB{
+				Name:     fmt.Sprintf("Produto %s-%d", hex.EncodeToString(run), i),
+				Quantity: quantity.Int64() + 1,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "seeded %d products\n", seedCount)
+		return nil
+	},
+}
+
+func init() {
+	seedCmd.Flags().IntVar(&seedCount, "count", 10, "number of products")
+}
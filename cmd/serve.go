@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	serverInit "github.com/danilotadeu/products/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start the http api",
+	Args:  cobra.NoArgs,
+	RunE:  runServe,
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	return serverInit.New(cfg).Start()
+}
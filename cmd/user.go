@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var userName string
+
+// userCmd manages the users, the owners of the api keys
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage the users",
+}
+
+var userCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a user with its first api key, the key is shown only once",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if userName == "" {
+			return fmt.Errorf("--name is required")
+		}
+
+		apps, closeApps, err := openApps(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeApps()
+
+		created, err := apps.User.CreateUser(cmd.Context(), userName)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "id: %d\nname: %s\nkey: %s\n", created.ID, created.Name, created.APIKey.Key)
+		return nil
+	},
+}
+
+func init() {
+	userCreateCmd.Flags().StringVar(&userName, "name", "", "unique name of the user")
+	userCmd.AddCommand(userCreateCmd)
+}
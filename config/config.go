@@ -0,0 +1,275 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the effective configuration of the service, fields are loaded from
+// the defaults, an optional yaml/toml file, the .env file and the environment, in that precedence
+type Config struct {
+	Store     string    `yaml:"store" toml:"store" env:"STORE" validate:"oneof=sql memory"`
+	Server    Server    `yaml:"server" toml:"server"`
+	Database  Database  `yaml:"database" toml:"database"`
+	Log       Log       `yaml:"log" toml:"log"`
+	Tracing   Tracing   `yaml:"tracing" toml:"tracing"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	Catalog   Catalog   `yaml:"catalog" toml:"catalog"`
+	Cache     Cache     `yaml:"cache" toml:"cache"`
+	Media     Media     `yaml:"media" toml:"media"`
+}
+
+type Server struct {
+	Port               int           `yaml:"port" toml:"port" env:"PORT" validate:"min=1,max=65535"`
+	GRPCPort           int           `yaml:"grpc_port" toml:"grpc_port" env:"GRPC_PORT" validate:"min=1,max=65535,nefield=Port"`
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout" toml:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT" validate:"gt=0"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" validate:"gt=0"`
+	ShutdownDelay      time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY" validate:"gte=0"`
+	// Locale answers the clients whose Accept-Language matches none of the catalogues
+	Locale string `yaml:"locale" toml:"locale" env:"DEFAULT_LOCALE" validate:"oneof=pt-BR en es"`
+	// V1Deprecation and V1Sunset (YYYY-MM-DD) are announced in the headers of the v1 api
+	V1Deprecation string `yaml:"v1_deprecation" toml:"v1_deprecation" env:"API_V1_DEPRECATION" validate:"datetime=2006-01-02"`
+	V1Sunset      string `yaml:"v1_sunset" toml:"v1_sunset" env:"API_V1_SUNSET" validate:"datetime=2006-01-02"`
+}
+
+// Database selects the backend by Driver, Path is only used by sqlite, SSLMode only
+// by postgres and the connection settings by mysql and postgres. The timeouts bound each
+// statement by operation type, zero disables them
+type Database struct {
+	Driver       string        `yaml:"driver" toml:"driver" env:"DB_DRIVER" validate:"oneof=mysql sqlite postgres"`
+	Path         string        `yaml:"path" toml:"path" env:"DB_PATH" validate:"required_if=Driver sqlite"`
+	Host         string        `yaml:"host" toml:"host" env:"DB_HOST" validate:"required_unless=Driver sqlite"`
+	Port         int           `yaml:"port" toml:"port" env:"DB_PORT" validate:"min=1,max=65535"`
+	User         string        `yaml:"user" toml:"user" env:"DB_USER" validate:"required_unless=Driver sqlite"`
+	Password     string        `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name         string        `yaml:"name" toml:"name" env:"DB_DATABASE" validate:"required_unless=Driver sqlite"`
+	SSLMode      string        `yaml:"ssl_mode" toml:"ssl_mode" env:"DB_SSL_MODE" validate:"oneof=disable require verify-ca verify-full"`
+	AutoMigrate  bool          `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"DB_READ_TIMEOUT" validate:"gte=0"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"DB_WRITE_TIMEOUT" validate:"gte=0"`
+}
+
+type Log struct {
+	Level     string `yaml:"level" toml:"level" env:"LOG_LEVEL" validate:"oneof=trace debug info warn warning error fatal panic"`
+	Path      string `yaml:"path" toml:"path" env:"LOG_PATH" validate:"required"`
+	MaxSizeMB int    `yaml:"max_size_mb" toml:"max_size_mb" env:"LOG_MAX_SIZE_MB" validate:"min=1"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"OTEL_TRACES_EXPORTER" validate:"oneof=otlp stdout none"`
+}
+
+// Auth decides if the api key of the X-API-Key header is required, a key sent is always validated.
+// The keys not found are remembered by each instance, up to InvalidCacheSize for InvalidCacheTTL
+type Auth struct {
+	Required         bool          `yaml:"required" toml:"required" env:"AUTH_REQUIRED"`
+	InvalidCacheSize int           `yaml:"invalid_cache_size" toml:"invalid_cache_size" env:"AUTH_INVALID_CACHE_SIZE" validate:"min=1"`
+	InvalidCacheTTL  time.Duration `yaml:"invalid_cache_ttl" toml:"invalid_cache_ttl" env:"AUTH_INVALID_CACHE_TTL" validate:"gt=0"`
+}
+
+type RateLimit struct {
+	Enabled    bool    `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	KeyBy      string  `yaml:"key_by" toml:"key_by" env:"RATE_LIMIT_KEY_BY" validate:"oneof=ip apikey tenant"`
+	ReadRate   float64 `yaml:"read_rate" toml:"read_rate" env:"RATE_LIMIT_READ_RATE" validate:"gt=0"`
+	ReadBurst  int     `yaml:"read_burst" toml:"read_burst" env:"RATE_LIMIT_READ_BURST" validate:"min=1"`
+	WriteRate  float64 `yaml:"write_rate" toml:"write_rate" env:"RATE_LIMIT_WRITE_RATE" validate:"gt=0"`
+	WriteBurst int     `yaml:"write_burst" toml:"write_burst" env:"RATE_LIMIT_WRITE_BURST" validate:"min=1"`
+	// the budget per ip of the requests sending an api key, before it is looked up
+	AuthRate  float64 `yaml:"auth_rate" toml:"auth_rate" env:"RATE_LIMIT_AUTH_RATE" validate:"gt=0"`
+	AuthBurst int     `yaml:"auth_burst" toml:"auth_burst" env:"RATE_LIMIT_AUTH_BURST" validate:"min=1"`
+}
+
+type Catalog struct {
+	ReorderPoint int64 `yaml:"reorder_point" toml:"reorder_point" env:"REORDER_POINT" validate:"min=0"`
+}
+
+// Cache is the in-process cache of the product reads, each instance keeps its
+// own entries so a write on another instance is seen only after the TTL
+type Cache struct {
+	Enabled bool          `yaml:"enabled" toml:"enabled" env:"CACHE_ENABLED"`
+	Size    int           `yaml:"size" toml:"size" env:"CACHE_SIZE" validate:"min=1"`
+	TTL     time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL" validate:"gt=0"`
+}
+
+// Media is where the files attached to the products are kept, Path is the directory
+// of the filesystem storage. The memory store keeps them in memory. Renditions are the
+// sizes, in pixels of the longest side, the images are resized to. A webp rendition is
+// lossless, usually larger than the jpeg one for a photo
+type Media struct {
+	Storage         string `yaml:"storage" toml:"storage" env:"MEDIA_STORAGE" validate:"oneof=filesystem"`
+	Path            string `yaml:"path" toml:"path" env:"MEDIA_PATH" validate:"required"`
+	MaxSizeMB       int    `yaml:"max_size_mb" toml:"max_size_mb" env:"MEDIA_MAX_SIZE_MB" validate:"min=1"`
+	Renditions      []int  `yaml:"renditions" toml:"renditions" env:"MEDIA_RENDITIONS" validate:"dive,min=16,max=4096"`
+	RenditionFormat string `yaml:"rendition_format" toml:"rendition_format" env:"MEDIA_RENDITION_FORMAT" validate:"oneof=webp jpeg png"`
+}
+
+// MaxSize is MaxSizeMB in bytes
+func (m Media) MaxSize() int64 {
+	return int64(m.MaxSizeMB) << 20
+}
+
+// Error reports every invalid setting at once
+type Error struct {
+	Errors []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Errors, "\n  ")
+}
+
+// Default returns the configuration used when nothing is set
+func Default() Config {
+	return Config{
+		Store: "sql",
+		Server: Server{
+			Port:               3000,
+			GRPCPort:           9090,
+			HealthCheckTimeout: 2 * time.Second,
+			ShutdownTimeout:    15 * time.Second,
+			Locale:             "pt-BR",
+			V1Deprecation:      "2026-10-19",
+			V1Sunset:           "2027-04-30",
+		},
+		Database: Database{
+			Driver:       "mysql",
+			Path:         "products.db",
+			Port:         3306,
+			SSLMode:      "disable",
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		Log: Log{
+			Level:     "info",
+			Path:      "log/logrus.log",
+			MaxSizeMB: 50,
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		Auth: Auth{
+			InvalidCacheSize: 10000,
+			InvalidCacheTTL:  time.Minute,
+		},
+		RateLimit: RateLimit{
+			Enabled:    true,
+			KeyBy:      "ip",
+			ReadRate:   10,
+			ReadBurst:  100,
+			WriteRate:  1,
+			WriteBurst: 20,
+			AuthRate:   20,
+			AuthBurst:  200,
+		},
+		Catalog: Catalog{
+			ReorderPoint: 10,
+		},
+		Cache: Cache{
+			Size: 1000,
+			TTL:  30 * time.Second,
+		},
+		Media: Media{
+			Storage:         "filesystem",
+			Path:            "media",
+			MaxSizeMB:       10,
+			Renditions:      []int{128, 512, 1024},
+			RenditionFormat: "jpeg",
+		},
+	}
+}
+
+// Load builds the configuration, path is the optional yaml/toml file (CONFIG_FILE when empty)
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// the .env is optional, in containers the variables come from the environment
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	errs := applyEnv(&cfg)
+	errs = append(errs, validate(&cfg)...)
+	if len(errs) > 0 {
+		return nil, &Error{Errors: errs}
+	}
+
+	return &cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("config: unsupported file %q, use yaml or toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func validate(cfg *Config) []string {
+	var err error
+	if cfg.Store == "memory" {
+		// the memory store runs without a database
+		err = validator.New().StructExcept(cfg, "Database")
+	} else {
+		err = validator.New().Struct(cfg)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []string{err.Error()}
+	}
+
+	names := fieldNames()
+	errs := make([]string, len(validationErrors))
+	for idx, fieldErr := range validationErrors {
+		// the items of a list are reported by the setting of the list
+		namespace := fieldErr.StructNamespace()
+		if i := strings.Index(namespace, "["); i >= 0 {
+			namespace = namespace[:i]
+		}
+		if strings.HasPrefix(fieldErr.Tag(), "required") {
+			errs[idx] = fmt.Sprintf("%s: is required", names[namespace])
+			continue
+		}
+		rule := fieldErr.Tag()
+		if fieldErr.Param() != "" {
+			rule += "=" + fieldErr.Param()
+		}
+		errs[idx] = fmt.Sprintf("%s: value %v does not satisfy %s", names[namespace], fieldErr.Value(), rule)
+	}
+
+	return errs
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLoad(t *testing.T) {
+	cases := map[string]struct {
+		File           string
+		FileName       string
+		Env            map[string]string
+		ExpectedErrs   []string
+		ExpectedConfig func(cfg *Config)
+	}{
+		"should load the defaults and the environment": {
+			Env: map[string]string{"DB_HOST": "db", "DB_USER": "luke", "DB_DATABASE": "products"},
+			ExpectedConfig: func(cfg *Config) {
+				cfg.Database.Host = "db"
+				cfg.Database.User = "luke"
+				cfg.Database.Name = "products"
+			},
+		},
+		"should prefer the environment over the yaml file": {
+			FileName: "config.yaml",
+			File:     "server:\n  port: 8080\n  health_check_timeout: 5s\ndatabase:\n  host: file\n  user: luke\n  name: products\n",
+			Env:      map[string]string{"DB_HOST": "env"},
+			ExpectedConfig: func(cfg *Config) {
+				cfg.Server.Port = 8080
+				cfg.Server.HealthCheckTimeout = 5 * time.Second
+				cfg.Database.Host = "env"
+				cfg.Database.User = "luke"
+				cfg.Database.Name = "products"
+			},
+		},
+		"should load a toml file": {
+			FileName: "config.toml",
+			File:     "[database]\nhost = \"file\"\nuser = \"luke\"\nname = \"products\"\n[rate_limit]\nkey_by = \"tenant\"\n",
+			ExpectedConfig: func(cfg *Config) {
+				cfg.Database.Host = "file"
+				cfg.Database.User = "luke"
+				cfg.Database.Name = "products"
+				cfg.RateLimit.KeyBy = "tenant"
+			},
+		},
+		"should not require the mysql settings with sqlite": {
+			Env: map[string]string{"DB_DRIVER": "sqlite", "DB_PATH": "/tmp/products.db"},
+			ExpectedConfig: func(cfg *Config) {
+				cfg.Database.Driver = "sqlite"
+				cfg.Database.Path = "/tmp/products.db"
+			},
+		},
+		"should not require a database with the memory store": {
+			Env: map[string]string{"STORE": "memory", "DB_DRIVER": ""},
+			ExpectedConfig: func(cfg *Config) {
+				cfg.Store = "memory"
+				cfg.Database.Driver = ""
+			},
+		},
+		"should load a list from the environment": {
+			Env: map[string]string{"MEDIA_RENDITIONS": "256, 800", "DB_HOST": "db", "DB_USER": "luke", "DB_DATABASE": "products"},
+			ExpectedConfig: func(cfg *Config) {
+				cfg.Media.Renditions = []int{256, 800}
+				cfg.Database.Host = "db"
+				cfg.Database.User = "luke"
+				cfg.Database.Name = "products"
+			},
+		},
+		"should require the connection settings with postgres": {
+			Env: map[string]string{"DB_DRIVER": "postgres", "DB_PORT": "5432", "DB_USER": "luke", "DB_DATABASE": "products"},
+			ExpectedErrs: []string{
+				"DB_HOST (database.host): is required",
+			},
+		},
+		"should report every invalid setting": {
+			Env: map[string]string{"PORT": "xpto", "DEFAULT_LOCALE": "fr", "RATE_LIMIT_KEY_BY": "user", "MEDIA_STORAGE": "s3", "MEDIA_RENDITIONS": "128,8", "DB_USER": "luke", "DB_DATABASE": "products"},
+			ExpectedErrs: []string{
+				"PORT (server.port): invalid integer \"xpto\"",
+				"DEFAULT_LOCALE (server.locale): value fr does not satisfy oneof=pt-BR en es",
+				"DB_HOST (database.host): is required",
+				"RATE_LIMIT_KEY_BY (rate_limit.key_by): value user does not satisfy oneof=ip apikey tenant",
+				"MEDIA_STORAGE (media.storage): value s3 does not satisfy oneof=filesystem",
+				"MEDIA_RENDITIONS (media.renditions): value 8 does not satisfy min=16",
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			for _, f := range fields(&Config{}) {
+				t.Setenv(f.env, "")
+				os.Unsetenv(f.env)
+			}
+			for key, value := range cs.Env {
+				t.Setenv(key, value)
+			}
+
+			path := ""
+			if cs.FileName != "" {
+				path = filepath.Join(t.TempDir(), cs.FileName)
+				assert.NilError(t, os.WriteFile(path, []byte(cs.File), 0o600))
+			}
+
+			cfg, err := Load(path)
+			if len(cs.ExpectedErrs) > 0 {
+				configErr, ok := err.(*Error)
+				assert.Assert(t, ok)
+				assert.DeepEqual(t, cs.ExpectedErrs, configErr.Errors)
+				return
+			}
+
+			assert.NilError(t, err)
+			expected := Default()
+			cs.ExpectedConfig(&expected)
+			assert.DeepEqual(t, expected, *cfg)
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "xQlpKD95kp20Wa1JAX6O"
+
+	var out bytes.Buffer
+	assert.NilError(t, cfg.Print(&out))
+
+	assert.Assert(t, !strings.Contains(out.String(), cfg.Database.Password))
+	assert.Assert(t, strings.Contains(out.String(), redacted))
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "server.health_check_timeout") {
+			assert.DeepEqual(t, []string{"server.health_check_timeout", "HEALTH_CHECK_TIMEOUT", "2s"}, strings.Fields(line))
+		}
+	}
+}
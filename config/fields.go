@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const redacted = "******"
+
+// field is a leaf setting of the Config struct
+type field struct {
+	key       string
+	env       string
+	namespace string
+	secret    bool
+	value     reflect.Value
+}
+
+// fields lists the settings of cfg in declaration order
+func fields(cfg *Config) []field {
+	var result []field
+	var walk func(v reflect.Value, key, namespace string)
+	walk = func(v reflect.Value, key, namespace string) {
+		t := v.Type()
+		for idx := 0; idx < t.NumField(); idx++ {
+			structField := t.Field(idx)
+			fieldKey := structField.Tag.Get("yaml")
+			if key != "" {
+				fieldKey = key + "." + fieldKey
+			}
+			fieldNamespace := namespace + "." + structField.Name
+
+			if structField.Type.Kind() == reflect.Struct && structField.Type != reflect.TypeOf(time.Duration(0)) {
+				walk(v.Field(idx), fieldKey, fieldNamespace)
+				continue
+			}
+
+			result = append(result, field{
+				key:       fieldKey,
+				env:       structField.Tag.Get("env"),
+				namespace: fieldNamespace,
+				secret:    structField.Tag.Get("secret") == "true",
+				value:     v.Field(idx),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "", "Config")
+
+	return result
+}
+
+// fieldNames maps the validator namespace of each setting to a readable name
+func fieldNames() map[string]string {
+	cfg := Default()
+	names := map[string]string{}
+	for _, f := range fields(&cfg) {
+		names[f.namespace] = fmt.Sprintf("%s (%s)", f.env, f.key)
+	}
+	return names
+}
+
+// applyEnv overrides the settings with the environment, returning every unparsable value
+func applyEnv(cfg *Config) []string {
+	var errs []string
+	for _, f := range fields(cfg) {
+		raw, ok := os.LookupEnv(f.env)
+		if !ok || f.env == "" {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			errs = append(errs, fmt.Sprintf("%s (%s): %s", f.env, f.key, err.Error()))
+		}
+	}
+	return errs
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		// a comma separated list, empty for no items
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(value, item); err != nil {
+				return err
+			}
+			items = reflect.Append(items, value)
+		}
+		v.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Print writes the effective settings with the secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tENV\tVALUE")
+	for _, f := range fields(c) {
+		value := fmt.Sprint(f.value.Interface())
+		if f.secret && value != "" {
+			value = redacted
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.key, f.env, value)
+	}
+	return tw.Flush()
+}
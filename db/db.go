@@ -0,0 +1,52 @@
+package db
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Dialects with their own migration set in migrations/<dialect>
+const (
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
+// Dialects lists every supported dialect
+var Dialects = []string{MySQL, SQLite, Postgres}
+
+// migrations are embedded, so the binary does not need the migrate cli
+//
+//go:embed migrations/*/*.sql
+var migrations embed.FS
+
+// SchemaVersion is the last embedded migration, the version this binary expects
+var SchemaVersion = latestVersion(MySQL)
+
+// migrationsDir is the embedded directory of dialect
+func migrationsDir(dialect string) string {
+	return path.Join("migrations", dialect)
+}
+
+func latestVersion(dialect string) int64 {
+	entries, err := fs.ReadDir(migrations, migrationsDir(dialect))
+	if err != nil {
+		panic(err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		migration, err := source.DefaultParse(entry.Name())
+		if err != nil {
+			continue
+		}
+		if migration.Version > latest {
+			latest = migration.Version
+		}
+	}
+
+	return int64(latest)
+}
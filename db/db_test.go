@@ -0,0 +1,22 @@
+package db
+
+import (
+	"io/fs"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range Dialects {
+		t.Run(dialect, func(t *testing.T) {
+			entries, err := fs.ReadDir(migrations, migrationsDir(dialect))
+			assert.NilError(t, err)
+
+			// every version needs both directions and every dialect the same versions
+			assert.Equal(t, 0, len(entries)%2)
+			assert.Equal(t, int64(len(entries)/2), SchemaVersion)
+			assert.Equal(t, SchemaVersion, latestVersion(dialect))
+		})
+	}
+}
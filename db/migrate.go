@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary understands")
+
+// Status is the state of the schema compared to the embedded migrations
+type Status struct {
+	Version    int64
+	Dirty      bool
+	Latest     int64
+	Migrations []MigrationStatus
+}
+
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// Migrator runs the embedded migrations of a dialect, on mysql and postgres golang-migrate
+// holds an advisory lock during each command so concurrent runners wait for each other
+type Migrator struct {
+	migrate *migrate.Migrate
+	source  source.Driver
+	dialect string
+}
+
+// NewMigrator init a migrator of dialect, on mysql and postgres it uses a dedicated connection of db
+func NewMigrator(ctx context.Context, db *sql.DB, dialect string) (*Migrator, error) {
+	src, err := iofs.New(migrations, migrationsDir(dialect))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.iofs.New"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	var driver database.Driver
+	switch dialect {
+	case MySQL:
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.Conn"}).WithError(err).Error(err)
+			return nil, err
+		}
+
+		driver, err = mysql.WithConnection(ctx, conn, &mysql.Config{})
+		if err != nil {
+			conn.Close()
+			logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.mysql.WithConnection"}).WithError(err).Error(err)
+			return nil, err
+		}
+	case Postgres:
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.Conn"}).WithError(err).Error(err)
+			return nil, err
+		}
+
+		driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
+		if err != nil {
+			conn.Close()
+			logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.postgres.WithConnection"}).WithError(err).Error(err)
+			return nil, err
+		}
+	case SQLite:
+		driver, err = sqlite3.WithInstance(db, &sqlite3.Config{})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.sqlite3.WithInstance"}).WithError(err).Error(err)
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, dialect, driver)
+	if err != nil {
+		if dialect != SQLite {
+			driver.Close()
+		}
+		logrus.WithFields(logrus.Fields{"trace": "db.NewMigrator.NewWithInstance"}).WithError(err).Error(err)
+		return nil, err
+	}
+	m.Log = migrateLogger{}
+
+	return &Migrator{migrate: m, source: src, dialect: dialect}, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+// Down reverts the last applied migration
+func (m *Migrator) Down() error {
+	return ignoreNoChange(m.migrate.Steps(-1))
+}
+
+// Goto migrates up or down to version
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.migrate.Migrate(version))
+}
+
+// Version returns the applied version, 0 when the schema is empty
+func (m *Migrator) Version() (int64, bool, error) {
+	version, dirty, err := m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return int64(version), dirty, nil
+}
+
+// Status lists the embedded migrations and which of them were applied
+func (m *Migrator) Status() (*Status, error) {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Version: version,
+		Dirty:   dirty,
+		Latest:  SchemaVersion,
+	}
+
+	entries, err := fs.ReadDir(migrations, migrationsDir(m.dialect))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		migration, err := source.DefaultParse(entry.Name())
+		if err != nil || migration.Direction != source.Up {
+			continue
+		}
+		status.Migrations = append(status.Migrations, MigrationStatus{
+			Version: int64(migration.Version),
+			Name:    migration.Identifier,
+			Applied: int64(migration.Version) <= version,
+		})
+	}
+	sort.Slice(status.Migrations, func(i, j int) bool {
+		return status.Migrations[i].Version < status.Migrations[j].Version
+	})
+
+	return status, nil
+}
+
+// CheckSchema refuses a schema migrated by a newer binary
+func (m *Migrator) CheckSchema() error {
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: schema at version %d, binary expects %d", ErrSchemaTooNew, version, SchemaVersion)
+	}
+	return nil
+}
+
+// Close releases the connection, the *sql.DB stays open
+func (m *Migrator) Close() error {
+	// the sqlite driver closes the *sql.DB it was given
+	if m.dialect == SQLite {
+		return m.source.Close()
+	}
+
+	sourceErr, databaseErr := m.migrate.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return databaseErr
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	logrus.WithFields(logrus.Fields{"trace": "db.migrate"}).Infof(strings.TrimSuffix(format, "\n"), v...)
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
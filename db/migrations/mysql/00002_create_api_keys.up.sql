@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE api_keys (
+  id INT NOT NULL AUTO_INCREMENT,
+  owner VARCHAR(45) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT UC_API_KEY_HASH UNIQUE (key_hash));
+
+COMMIT;
\ No newline at end of file
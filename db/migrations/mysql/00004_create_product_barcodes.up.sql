@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE product_barcodes (
+  id INT NOT NULL AUTO_INCREMENT,
+  product_id INT NOT NULL,
+  code VARCHAR(14) NOT NULL,
+  gtin CHAR(14) NOT NULL,
+  PRIMARY KEY (id),
+  INDEX IDX_PRODUCT_BARCODES_PRODUCT (product_id),
+  CONSTRAINT UC_PRODUCT_BARCODES_GTIN UNIQUE (gtin));
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- the default collation of the name already ignores the case, the other dialects catch up to it
+DO 0;
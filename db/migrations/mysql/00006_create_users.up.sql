@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE users (
+  id INT NOT NULL AUTO_INCREMENT,
+  name VARCHAR(45) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (id),
+  CONSTRAINT UC_USER_NAME UNIQUE (name));
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,11 @@
+BEGIN;
+
+CREATE TABLE products (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(45) NOT NULL,
+  quantity VARCHAR(45) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  deleted_at TIMESTAMP NULL DEFAULT NULL,
+  CONSTRAINT UC_PRODUCT_NAME UNIQUE (name));
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+CREATE TABLE api_keys (
+  id SERIAL PRIMARY KEY,
+  owner VARCHAR(45) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMP NULL DEFAULT NULL,
+  CONSTRAINT UC_API_KEY_HASH UNIQUE (key_hash));
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE product_media;
+
+COMMIT;
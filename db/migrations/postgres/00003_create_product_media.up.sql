@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE product_media (
+  id SERIAL PRIMARY KEY,
+  product_id INT NOT NULL,
+  blob_key VARCHAR(255) NOT NULL,
+  filename VARCHAR(255) NOT NULL,
+  content_type VARCHAR(100) NOT NULL,
+  size BIGINT NOT NULL,
+  checksum CHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT UC_PRODUCT_MEDIA_BLOB_KEY UNIQUE (blob_key));
+
+CREATE INDEX IDX_PRODUCT_MEDIA_PRODUCT ON product_media (product_id);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE product_barcodes (
+  id SERIAL PRIMARY KEY,
+  product_id INT NOT NULL,
+  code VARCHAR(14) NOT NULL,
+  gtin CHAR(14) NOT NULL,
+  CONSTRAINT UC_PRODUCT_BARCODES_GTIN UNIQUE (gtin));
+
+CREATE INDEX IDX_PRODUCT_BARCODES_PRODUCT ON product_barcodes (product_id);
+
+COMMIT;
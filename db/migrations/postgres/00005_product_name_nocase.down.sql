@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX UC_PRODUCT_NAME_LOWER;
+
+COMMIT;
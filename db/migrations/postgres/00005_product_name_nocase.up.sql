@@ -0,0 +1,5 @@
+BEGIN;
+
+CREATE UNIQUE INDEX UC_PRODUCT_NAME_LOWER ON products (LOWER(name));
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE users;
+
+COMMIT;
\ No newline at end of file
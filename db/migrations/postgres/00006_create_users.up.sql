@@ -0,0 +1,9 @@
+BEGIN;
+
+CREATE TABLE users (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(45) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT UC_USER_NAME UNIQUE (name));
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE products (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(45) NOT NULL,
+  quantity VARCHAR(45) NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME NULL DEFAULT NULL,
+  CONSTRAINT UC_PRODUCT_NAME UNIQUE (name));
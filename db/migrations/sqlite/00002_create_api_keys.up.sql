@@ -0,0 +1,7 @@
+CREATE TABLE api_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  owner VARCHAR(45) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  revoked_at DATETIME NULL DEFAULT NULL,
+  CONSTRAINT UC_API_KEY_HASH UNIQUE (key_hash));
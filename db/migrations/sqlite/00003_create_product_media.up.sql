@@ -0,0 +1,12 @@
+CREATE TABLE product_media (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_id INTEGER NOT NULL,
+  blob_key VARCHAR(255) NOT NULL,
+  filename VARCHAR(255) NOT NULL,
+  content_type VARCHAR(100) NOT NULL,
+  size BIGINT NOT NULL,
+  checksum CHAR(64) NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT UC_PRODUCT_MEDIA_BLOB_KEY UNIQUE (blob_key));
+
+CREATE INDEX IDX_PRODUCT_MEDIA_PRODUCT ON product_media (product_id);
@@ -0,0 +1 @@
+DROP INDEX UC_PRODUCT_NAME_NOCASE;
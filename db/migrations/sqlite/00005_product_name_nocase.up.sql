@@ -0,0 +1 @@
+CREATE UNIQUE INDEX UC_PRODUCT_NAME_NOCASE ON products (name COLLATE NOCASE);
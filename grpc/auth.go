@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/danilotadeu/products/app/apikey"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataAPIKey is the metadata the clients send their api key in, as the X-API-Key of http
+const metadataAPIKey = "x-api-key"
+
+// apiKeyContext holds the validated key in the context of the call
+type apiKeyContext struct{}
+
+// authenticator validates the api keys of the calls as the auth middleware of the http api
+type authenticator struct {
+	apps     apikey.App
+	required bool
+}
+
+func (a authenticator) unary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a authenticator) stream(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, serverStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate answers Unauthenticated for a key that does not exist or was revoked, and for
+// the calls without a key when required. The validated key is read back with apiKeyFrom
+func (a authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	key := apiKeyOf(ctx)
+	if key == "" {
+		if a.required {
+			return ctx, statusError(ctx, errorsP.ErrUnauthorized.WithDetail("detail.missing_api_key_metadata", metadataAPIKey))
+		}
+		return ctx, nil
+	}
+
+	apiKey, err := a.apps.Authenticate(ctx, key)
+	if err != nil {
+		return ctx, statusError(ctx, err)
+	}
+	return context.WithValue(ctx, apiKeyContext{}, apiKey), nil
+}
+
+// apiKeyOf is the key sent in the metadata of the call, empty when there is none
+func apiKeyOf(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(metadataAPIKey); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// apiKeyFrom is the key validated by the authenticator, nil for an anonymous call
+func apiKeyFrom(ctx context.Context) *apiKeyModel.APIKeyDB {
+	apiKey, _ := ctx.Value(apiKeyContext{}).(*apiKeyModel.APIKeyDB)
+	return apiKey
+}
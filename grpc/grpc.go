@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/danilotadeu/products/api/middleware/ratelimit"
+	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/grpc/pb"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/danilotadeu/products/validation"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves the ProductService next to the http api, with reflection for grpcurl and the like
+type Server struct {
+	server   *grpc.Server
+	done     chan struct{}
+	shutdown sync.Once
+}
+
+// New registers the services over the same app container of the http api, authRequired
+// refuses the calls without an api key and rateLimit holds the budgets shared with the http api
+func New(apps *app.Container, authRequired bool, rateLimit ratelimit.Config) *Server {
+	auth := authenticator{apps: apps.APIKey, required: authRequired}
+	limiter := newRateLimiter(rateLimit)
+	s := &Server{
+		server: grpc.NewServer(
+			// the recovery runs inside the tracing, so the span of a panic ends as an Internal, and
+			// the keys sent are limited by ip before they are looked up, as in the http api
+			grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), localeUnaryInterceptor, recoveryUnaryInterceptor,
+				limiter.unaryAuthentications, auth.unary, limiter.unary),
+			grpc.ChainStreamInterceptor(tracing.StreamServerInterceptor(), localeStreamInterceptor, recoveryStreamInterceptor,
+				limiter.streamAuthentications, auth.stream, limiter.stream),
+		),
+		done: make(chan struct{}),
+	}
+
+	pb.RegisterProductServiceServer(s.server, &productService{
+		apps:      apps,
+		validator: validation.New(),
+		done:      s.done,
+	})
+	reflection.Register(s.server)
+
+	logrus.WithFields(logrus.Fields{"trace": "grpc"}).Infof("Registered - gRPC")
+	return s
+}
+
+// Serve accepts the connections of lis until Shutdown
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown ends the Watch streams and waits the in-flight calls, the ones still
+// running when ctx is done are cancelled
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdown.Do(func() { close(s.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// serverStream replaces the context of a stream, as the interceptors do for the unary calls
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/danilotadeu/products/i18n"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataAcceptLanguage is the metadata the clients ask for a locale with, as in http
+const metadataAcceptLanguage = "accept-language"
+
+// localeUnaryInterceptor negotiates the locale of the call from its accept-language metadata
+func localeUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withLocale(ctx), req)
+}
+
+// localeStreamInterceptor is localeUnaryInterceptor for the streams
+func localeStreamInterceptor(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, serverStream{ServerStream: stream, ctx: withLocale(stream.Context())})
+}
+
+func withLocale(ctx context.Context) context.Context {
+	acceptLanguage := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(metadataAcceptLanguage); len(values) > 0 {
+			acceptLanguage = values[0]
+		}
+	}
+	return i18n.WithLocale(ctx, i18n.Negotiate(acceptLanguage))
+}
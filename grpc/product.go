@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/grpc/pb"
+	"github.com/danilotadeu/products/i18n"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	genericModel "github.com/danilotadeu/products/model/generic"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultLimit is the page size of List when the request has none, as in the http api
+	defaultLimit = 10
+	// maxLimit bounds the page size, as in the v2 of the http api
+	maxLimit = 100
+)
+
+type productService struct {
+	pb.UnimplementedProductServiceServer
+	apps      *app.Container
+	validator *validator.Validate
+	// done is closed on shutdown to end the Watch streams
+	done <-chan struct{}
+}
+
+func (s *productService) Create(ctx context.Context, req *pb.CreateRequest) (*pb.Product, error) {
+	product := productModel.ProductDB{Name: req.GetName(), Quantity: req.GetQuantity()}
+	if err := s.validator.Struct(product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.Create.validator.Struct"}).WithError(err).Error(err)
+		return nil, invalidArgument(ctx, err)
+	}
+
+	id, err := s.apps.Product.SaveProduct(ctx, product)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.Create.SaveProduct"}).WithError(err).Error(err)
+		return nil, statusError(ctx, err)
+	}
+
+	return s.get(ctx, *id)
+}
+
+func (s *productService) Get(ctx context.Context, req *pb.GetRequest) (*pb.Product, error) {
+	return s.get(ctx, req.GetId())
+}
+
+func (s *productService) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	switch {
+	case req.GetPage() < 0:
+		return nil, statusError(ctx, errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_min", "page", 0))
+	case req.GetLimit() < 0 || req.GetLimit() > maxLimit:
+		return nil, statusError(ctx, errorsP.ErrInvalidQuery.WithDetail("detail.invalid_query_range", "limit", 0, maxLimit))
+	}
+
+	limit := req.GetLimit()
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	products, err := s.apps.Product.GetAllProducts(ctx, req.GetPage(), limit, req.GetName())
+	if err != nil && !errors.Is(err, productModel.ErrorProductNotFound) {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.List.GetAllProducts"}).WithError(err).Error(err)
+		return nil, statusError(ctx, err)
+	}
+
+	nextPage, previousPage := genericModel.MakePagination(req.GetPage())
+	_, err = s.apps.Product.GetAllProducts(ctx, *nextPage, limit, req.GetName())
+	if err != nil {
+		if !errors.Is(err, productModel.ErrorProductNotFound) {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.List.GetAllProducts_1"}).WithError(err).Error(err)
+			return nil, statusError(ctx, err)
+		}
+		nextPage = nil
+	}
+
+	total, err := s.apps.Product.GetTotalProducts(ctx)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.List.GetTotalProducts"}).WithError(err).Error(err)
+		return nil, statusError(ctx, err)
+	}
+
+	resp := &pb.ListResponse{
+		Products:     make([]*pb.Product, len(products)),
+		Count:        *total,
+		NextPage:     nextPage,
+		PreviousPage: previousPage,
+	}
+	for idx, product := range products {
+		resp.Products[idx] = toProto(product)
+	}
+	return resp, nil
+}
+
+func (s *productService) Update(ctx context.Context, req *pb.UpdateRequest) (*pb.Product, error) {
+	product := productModel.ProductDB{ID: req.GetId(), Name: req.GetName(), Quantity: req.GetQuantity()}
+	if err := s.validator.Struct(product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.Update.validator.Struct"}).WithError(err).Error(err)
+		return nil, invalidArgument(ctx, err)
+	}
+
+	if err := s.apps.Product.UpdateProduct(ctx, product); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.Update.UpdateProduct"}).WithError(err).Error(err)
+		return nil, statusError(ctx, err)
+	}
+
+	return s.get(ctx, product.ID)
+}
+
+func (s *productService) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := s.apps.Product.Delete(ctx, req.GetId()); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.Delete.Delete"}).WithError(err).Error(err)
+		return nil, statusError(ctx, err)
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *productService) Watch(req *pb.WatchRequest, stream pb.ProductService_WatchServer) error {
+	ctx := stream.Context()
+	events := s.apps.Product.Watch(ctx)
+	// the header tells the client every change from now on is streamed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return statusError(ctx, ctx.Err())
+				}
+				return status.Error(codes.ResourceExhausted, i18n.T(ctx, "grpc.watch_behind"))
+			}
+			if err := stream.Send(&pb.ProductEvent{Type: eventType(event.Type), Product: toProto(&event.Product)}); err != nil {
+				logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.Watch.Send"}).WithError(err).Error(err)
+				return err
+			}
+		case <-s.done:
+			return status.Error(codes.Unavailable, i18n.T(ctx, "grpc.shutting_down"))
+		}
+	}
+}
+
+func (s *productService) get(ctx context.Context, id int64) (*pb.Product, error) {
+	product, err := s.apps.Product.GetOneByID(ctx, id)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.product.get.GetOneByID"}).WithError(err).Error(err)
+		return nil, statusError(ctx, err)
+	}
+
+	return toProto(product), nil
+}
+
+// invalidArgument lists the invalid fields of a failed validation, in the locale of ctx,
+// as a BadRequest detail
+func invalidArgument(ctx context.Context, err error) error {
+	st := status.New(codes.InvalidArgument, validation.Message(ctx, err))
+
+	fields := validation.Fields(ctx, err)
+	if fields == nil {
+		return st.Err()
+	}
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+	for idx, field := range fields {
+		violations[idx] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message}
+	}
+	if detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// statusError maps the errors by their status in the catalogue, with its code as the reason of
+// an ErrorInfo and the message in the locale of ctx. The internal ones are not exposed
+func statusError(ctx context.Context, err error) error {
+	e := errorsP.From(err)
+	code := codeOf(e.Status)
+	if code == codes.Internal {
+		return status.Error(code, i18n.T(ctx, "grpc.INTERNAL"))
+	}
+
+	problem := e.Problem(i18n.Locale(ctx), "")
+	message := problem.Title
+	if problem.Detail != "" {
+		message += ": " + problem.Detail
+	}
+	st := status.New(code, message)
+	if detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Code}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// codeOf is the grpc code of the errors answered with status by the http api
+func codeOf(status int) codes.Code {
+	switch status {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case errorsP.StatusClientClosedRequest:
+		return codes.Canceled
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+
+func toProto(product *productModel.ProductDB) *pb.Product {
+	result := &pb.Product{
+		Id:       product.ID,
+		Name:     product.Name,
+		Quantity: product.Quantity,
+	}
+	if !product.CreatedAt.IsZero() {
+		result.CreatedAt = timestamppb.New(product.CreatedAt)
+	}
+	return result
+}
+
+func eventType(eventType productModel.EventType) pb.ProductEvent_Type {
+	switch eventType {
+	case productModel.EventCreated:
+		return pb.ProductEvent_TYPE_CREATED
+	case productModel.EventUpdated:
+		return pb.ProductEvent_TYPE_UPDATED
+	case productModel.EventDeleted:
+		return pb.ProductEvent_TYPE_DELETED
+	}
+	return pb.ProductEvent_TYPE_UNSPECIFIED
+}
@@ -0,0 +1,425 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/danilotadeu/products/api/middleware/ratelimit"
+	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/config"
+	"github.com/danilotadeu/products/grpc/pb"
+	mockAppProduct "github.com/danilotadeu/products/mock/app/product"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store"
+	"github.com/golang/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/v3/assert"
+)
+
+// dial serves apps over an in-memory listener and returns a client of it
+func dial(t *testing.T, apps *app.Container, authRequired bool, rateLimit ratelimit.Config) (pb.ProductServiceClient, *Server) {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := New(apps, authRequired, rateLimit)
+	go server.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+
+	return pb.NewProductServiceClient(conn), server
+}
+
+func TestProductService(t *testing.T) {
+	createdAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := map[string]struct {
+		Call           func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error)
+		PrepareMockApp func(mockApp *mockAppProduct.MockApp)
+		ExpectedCode   codes.Code
+		Expected       func(t *testing.T, resp interface{})
+	}{
+		"should create a product": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Create(ctx, &pb.CreateRequest{Name: "Product 1", Quantity: 10})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				var id int64 = 1
+				mockApp.EXPECT().SaveProduct(gomock.Any(), productModel.ProductDB{Name: "Product 1", Quantity: 10}).Return(&id, nil)
+				mockApp.EXPECT().GetOneByID(gomock.Any(), id).Return(&productModel.ProductDB{ID: 1, Name: "Product 1", Quantity: 10, CreatedAt: createdAt}, nil)
+			},
+			ExpectedCode: codes.OK,
+			Expected: func(t *testing.T, resp interface{}) {
+				product := resp.(*pb.Product)
+				assert.Equal(t, int64(1), product.Id)
+				assert.Equal(t, createdAt, product.CreatedAt.AsTime())
+			},
+		},
+		"should reject an invalid product": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Create(ctx, &pb.CreateRequest{Quantity: 10})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {},
+			ExpectedCode:   codes.InvalidArgument,
+		},
+		"should return already exists for a taken name": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Create(ctx, &pb.CreateRequest{Name: "Product 1", Quantity: 10})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, productModel.ErrorProductAlreadyExists)
+			},
+			ExpectedCode: codes.AlreadyExists,
+		},
+		"should return not found for a missing product": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Get(ctx, &pb.GetRequest{Id: 1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(nil, productModel.ErrorProductNotFound)
+			},
+			ExpectedCode: codes.NotFound,
+		},
+		"should return deadline exceeded when the statement expires": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Get(ctx, &pb.GetRequest{Id: 1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(nil, fmt.Errorf("%w: interrupted", context.DeadlineExceeded))
+			},
+			ExpectedCode: codes.DeadlineExceeded,
+		},
+		"should hide the internal errors": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Delete(ctx, &pb.DeleteRequest{Id: 1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().Delete(gomock.Any(), int64(1)).Return(fmt.Errorf("connection refused"))
+			},
+			ExpectedCode: codes.Internal,
+		},
+		"should list a page with the next one": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.List(ctx, &pb.ListRequest{Page: 0, Name: "Product"})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().GetAllProducts(gomock.Any(), int64(0), int64(defaultLimit), "Product").Return([]*productModel.ProductDB{{ID: 1, Name: "Product 1"}}, nil)
+				mockApp.EXPECT().GetAllProducts(gomock.Any(), int64(1), int64(defaultLimit), "Product").Return([]*productModel.ProductDB{{ID: 2, Name: "Product 2"}}, nil)
+				var total int64 = 2
+				mockApp.EXPECT().GetTotalProducts(gomock.Any()).Return(&total, nil)
+			},
+			ExpectedCode: codes.OK,
+			Expected: func(t *testing.T, resp interface{}) {
+				list := resp.(*pb.ListResponse)
+				assert.Equal(t, 1, len(list.Products))
+				assert.Equal(t, int64(2), list.Count)
+				assert.Equal(t, int64(1), list.GetNextPage())
+				assert.Assert(t, list.PreviousPage == nil)
+			},
+		},
+		"should list an empty page": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.List(ctx, &pb.ListRequest{Page: 5, Limit: 2})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().GetAllProducts(gomock.Any(), gomock.Any(), int64(2), "").Return(nil, productModel.ErrorProductNotFound).Times(2)
+				var total int64 = 2
+				mockApp.EXPECT().GetTotalProducts(gomock.Any()).Return(&total, nil)
+			},
+			ExpectedCode: codes.OK,
+			Expected: func(t *testing.T, resp interface{}) {
+				list := resp.(*pb.ListResponse)
+				assert.Equal(t, 0, len(list.Products))
+				assert.Assert(t, list.NextPage == nil)
+				assert.Equal(t, int64(4), list.GetPreviousPage())
+			},
+		},
+		"should refuse a negative page": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.List(ctx, &pb.ListRequest{Page: -1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {},
+			ExpectedCode:   codes.InvalidArgument,
+		},
+		"should refuse a negative limit": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.List(ctx, &pb.ListRequest{Limit: -1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {},
+			ExpectedCode:   codes.InvalidArgument,
+		},
+		"should refuse a limit over the maximum": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.List(ctx, &pb.ListRequest{Limit: maxLimit + 1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {},
+			ExpectedCode:   codes.InvalidArgument,
+		},
+		"should return invalid argument for the invalid requests of the catalogue": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Update(ctx, &pb.UpdateRequest{Id: 1, Name: "Product 2", Quantity: 3})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(errorsP.ErrInvalidBarcode.WithDetail("detail.invalid_barcode", "123"))
+			},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		"should return cancelled when the client goes away": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Get(ctx, &pb.GetRequest{Id: 1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(nil, errorsP.ErrRequestCancelled)
+			},
+			ExpectedCode: codes.Canceled,
+		},
+		"should update a product": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Update(ctx, &pb.UpdateRequest{Id: 1, Name: "Product 2", Quantity: 3})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().UpdateProduct(gomock.Any(), productModel.ProductDB{ID: 1, Name: "Product 2", Quantity: 3}).Return(nil)
+				mockApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(&productModel.ProductDB{ID: 1, Name: "Product 2", Quantity: 3}, nil)
+			},
+			ExpectedCode: codes.OK,
+			Expected: func(t *testing.T, resp interface{}) {
+				assert.Equal(t, "Product 2", resp.(*pb.Product).Name)
+			},
+		},
+		"should answer a panic with an internal error": {
+			Call: func(ctx context.Context, client pb.ProductServiceClient) (interface{}, error) {
+				return client.Get(ctx, &pb.GetRequest{Id: 1})
+			},
+			PrepareMockApp: func(mockApp *mockAppProduct.MockApp) {
+				mockApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).DoAndReturn(func(context.Context, int64) (*productModel.ProductDB, error) {
+					panic("nil map")
+				})
+			},
+			ExpectedCode: codes.Internal,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			mockApp := mockAppProduct.NewMockApp(ctrl)
+			cs.PrepareMockApp(mockApp)
+
+			client, _ := dial(t, &app.Container{Product: mockApp}, false, ratelimit.Config{})
+			resp, err := cs.Call(ctx, client)
+
+			assert.Equal(t, cs.ExpectedCode, status.Code(err), "%v", err)
+			if cs.Expected != nil {
+				cs.Expected(t, resp)
+			}
+		})
+	}
+}
+
+func TestProductServiceWatch(t *testing.T) {
+	apps := app.Register(store.NewMemory(), config.Default().Media)
+	client, server := dial(t, apps, false, ratelimit.Config{})
+
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), "accept-language", "pt-BR"))
+	defer cancel()
+	stream, err := client.Watch(ctx, &pb.WatchRequest{})
+	assert.NilError(t, err)
+	// the header is sent once the stream is subscribed
+	_, err = stream.Header()
+	assert.NilError(t, err)
+
+	created, err := client.Create(ctx, &pb.CreateRequest{Name: "Product 1", Quantity: 10})
+	assert.NilError(t, err)
+	_, err = client.Delete(ctx, &pb.DeleteRequest{Id: created.Id})
+	assert.NilError(t, err)
+
+	for _, expected := range []pb.ProductEvent_Type{pb.ProductEvent_TYPE_CREATED, pb.ProductEvent_TYPE_DELETED} {
+		event, err := stream.Recv()
+		assert.NilError(t, err)
+		assert.Equal(t, expected, event.Type)
+		assert.Equal(t, created.Id, event.Product.Id)
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+	defer shutdownCancel()
+	assert.NilError(t, server.Shutdown(shutdownCtx))
+
+	_, err = stream.Recv()
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "o servidor está sendo desligado", st.Message())
+}
+
+func TestProductServiceFieldViolations(t *testing.T) {
+	client, _ := dial(t, &app.Container{}, false, ratelimit.Config{})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "accept-language", "en-US,en;q=0.9")
+	_, err := client.Create(ctx, &pb.CreateRequest{Name: " Product 1", Quantity: -1})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, 1, len(st.Details()))
+
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	fields := []string{}
+	for _, violation := range badRequest.GetFieldViolations() {
+		fields = append(fields, violation.GetField()+": "+violation.GetDescription())
+	}
+	assert.DeepEqual(t, []string{
+		"name: name must not start or end with spaces",
+		"quantity: quantity must be greater than or equal to 0",
+	}, fields)
+}
+
+func TestProductServiceAuth(t *testing.T) {
+	apps := app.Register(store.NewMemory(), config.Default().Media)
+	created, err := apps.APIKey.CreateAPIKey(context.Background(), "ci")
+	assert.NilError(t, err)
+	id, err := apps.Product.SaveProduct(context.Background(), productModel.ProductDB{Name: "Product 1", Quantity: 10})
+	assert.NilError(t, err)
+
+	cases := map[string]struct {
+		Required     bool
+		Key          string
+		ExpectedCode codes.Code
+	}{
+		"should answer the anonymous calls when the key is optional": {
+			ExpectedCode: codes.OK,
+		},
+		"should refuse the calls without a key when it is required": {
+			Required:     true,
+			ExpectedCode: codes.Unauthenticated,
+		},
+		"should answer the calls with a valid key": {
+			Required:     true,
+			Key:          created.Key,
+			ExpectedCode: codes.OK,
+		},
+		"should refuse an invalid key even when it is optional": {
+			Key:          "pk_unknown",
+			ExpectedCode: codes.Unauthenticated,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			client, _ := dial(t, apps, cs.Required, ratelimit.Config{})
+
+			ctx := context.Background()
+			if cs.Key != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, metadataAPIKey, cs.Key)
+			}
+			_, err := client.Get(ctx, &pb.GetRequest{Id: *id})
+			assert.Equal(t, cs.ExpectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestProductServiceLocalizedErrors(t *testing.T) {
+	cases := map[string]struct {
+		Err             error
+		ExpectedCode    codes.Code
+		ExpectedMessage string
+		ExpectedReason  string
+	}{
+		"should translate the error of the catalogue": {
+			Err:             productModel.ErrorProductNotFound.WithDetail("detail.product_not_found", 1),
+			ExpectedCode:    codes.NotFound,
+			ExpectedMessage: "Produto não encontrado: o produto 1 não existe",
+			ExpectedReason:  "PRODUCT_NOT_FOUND",
+		},
+		"should translate the internal errors without exposing them": {
+			Err:             fmt.Errorf("connection refused"),
+			ExpectedCode:    codes.Internal,
+			ExpectedMessage: "erro interno",
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			mockApp := mockAppProduct.NewMockApp(ctrl)
+			mockApp.EXPECT().GetOneByID(gomock.Any(), int64(1)).Return(nil, cs.Err)
+
+			client, _ := dial(t, &app.Container{Product: mockApp}, false, ratelimit.Config{})
+			ctx = metadata.AppendToOutgoingContext(ctx, "accept-language", "pt-BR")
+			_, err := client.Get(ctx, &pb.GetRequest{Id: 1})
+
+			st := status.Convert(err)
+			assert.Equal(t, cs.ExpectedCode, st.Code())
+			assert.Equal(t, cs.ExpectedMessage, st.Message())
+
+			reason := ""
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					reason = info.GetReason()
+				}
+			}
+			assert.Equal(t, cs.ExpectedReason, reason)
+		})
+	}
+}
+
+func TestProductServiceRateLimit(t *testing.T) {
+	apps := app.Register(store.NewMemory(), config.Default().Media)
+	id, err := apps.Product.SaveProduct(context.Background(), productModel.ProductDB{Name: "Product 1", Quantity: 10})
+	assert.NilError(t, err)
+
+	cases := map[string]struct {
+		Key           func(idx int) string
+		ExpectedCodes []codes.Code
+	}{
+		"should limit the reads of a client": {
+			Key:           func(idx int) string { return "" },
+			ExpectedCodes: []codes.Code{codes.OK, codes.OK, codes.ResourceExhausted},
+		},
+		"should limit the made-up keys by ip before they are looked up": {
+			Key:           func(idx int) string { return fmt.Sprintf("pk_unknown_%d", idx) },
+			ExpectedCodes: []codes.Code{codes.Unauthenticated, codes.Unauthenticated, codes.ResourceExhausted},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			client, _ := dial(t, apps, false, ratelimit.Config{
+				Enabled: true,
+				KeyBy:   ratelimit.KeyByIP,
+				Read:    ratelimit.Limit{Rate: 0.01, Burst: 2},
+				Write:   ratelimit.Limit{Rate: 0.01, Burst: 2},
+				Auth:    ratelimit.Limit{Rate: 0.01, Burst: 2},
+			})
+
+			for idx, expected := range cs.ExpectedCodes {
+				ctx := context.Background()
+				if key := cs.Key(idx); key != "" {
+					ctx = metadata.AppendToOutgoingContext(ctx, metadataAPIKey, key)
+				}
+				_, err := client.Get(ctx, &pb.GetRequest{Id: *id})
+
+				st := status.Convert(err)
+				assert.Equal(t, expected, st.Code(), "%v", err)
+				if expected == codes.ResourceExhausted {
+					retry := false
+					for _, detail := range st.Details() {
+						_, ok := detail.(*errdetails.RetryInfo)
+						retry = retry || ok
+					}
+					assert.Assert(t, retry)
+				}
+			}
+		})
+	}
+}
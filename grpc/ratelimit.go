@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"time"
+
+	"github.com/danilotadeu/products/api/middleware/ratelimit"
+	"github.com/danilotadeu/products/grpc/pb"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// readMethods spend the read budget, the other methods the write one
+var readMethods = map[string]bool{
+	pb.ProductService_Get_FullMethodName:   true,
+	pb.ProductService_List_FullMethodName:  true,
+	pb.ProductService_Watch_FullMethodName: true,
+}
+
+// rateLimiter takes the budgets of the http api with the same client keys, so a client
+// shares them between both
+type rateLimiter struct {
+	cfg ratelimit.Config
+}
+
+func newRateLimiter(cfg ratelimit.Config) rateLimiter {
+	if cfg.Store == nil {
+		cfg.Store = ratelimit.NewMemoryStore()
+	}
+	return rateLimiter{cfg: cfg}
+}
+
+// unaryAuthentications limits per ip the calls sending an api key, it runs before the
+// authenticator so the made-up keys are throttled without reaching the database
+func (l rateLimiter) unaryAuthentications(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.authentications(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l rateLimiter) streamAuthentications(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := l.authentications(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// unary limits the calls by the client key of the validated api key, it runs after the authenticator
+func (l rateLimiter) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.take(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l rateLimiter) stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := l.take(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+func (l rateLimiter) authentications(ctx context.Context) error {
+	if !l.cfg.Enabled || apiKeyOf(ctx) == "" {
+		return nil
+	}
+	return l.takeKey(ctx, ratelimit.AuthenticationKey(peerIP(ctx)), l.cfg.Auth)
+}
+
+func (l rateLimiter) take(ctx context.Context, method string) error {
+	if !l.cfg.Enabled {
+		return nil
+	}
+
+	class, limit := "write", l.cfg.Write
+	if readMethods[method] {
+		class, limit = "read", l.cfg.Read
+	}
+	return l.takeKey(ctx, class+":"+ratelimit.ClientKey(l.cfg.KeyBy, apiKeyFrom(ctx), peerIP(ctx)), limit)
+}
+
+// takeKey answers ResourceExhausted, with a RetryInfo, when the bucket of key is empty, a
+// failing store lets the call through
+func (l rateLimiter) takeKey(ctx context.Context, key string, limit ratelimit.Limit) error {
+	result, err := l.cfg.Store.Take(ctx, key, limit)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.ratelimit.Store.Take"}).WithError(err).Error(err)
+		return nil
+	}
+	if result.Allowed {
+		return nil
+	}
+
+	retryAfter := int64(math.Ceil(result.RetryAfter.Seconds()))
+	st := status.Convert(statusError(ctx, errorsP.ErrRateLimited.WithDetail("detail.rate_limited", retryAfter)))
+	if detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(retryAfter) * time.Second)}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// peerIP is the remote address of the call without its port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/danilotadeu/products/i18n"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryUnaryInterceptor answers a panic of the handler with an Internal instead of
+// taking down the process with every call in flight
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recovered(ctx, info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor is recoveryUnaryInterceptor for the streams
+func recoveryStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recovered(stream.Context(), info.FullMethod, p)
+		}
+	}()
+	return handler(srv, stream)
+}
+
+// recovered logs the panic with its stack, which is not exposed to the client
+func recovered(ctx context.Context, method string, p interface{}) error {
+	err := fmt.Errorf("panic: %v", p)
+	logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "grpc.recovered", "method": method, "stack": string(debug.Stack())}).WithError(err).Error(err)
+	return status.Error(codes.Internal, i18n.T(ctx, "grpc.INTERNAL"))
+}
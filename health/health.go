@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	healthModel "github.com/danilotadeu/products/model/health"
+	"github.com/sirupsen/logrus"
+)
+
+// DEFAULT_TIMEOUT limits each dependency check
+const DEFAULT_TIMEOUT = 2 * time.Second
+
+var (
+	ErrShuttingDown  = errors.New("shutting down")
+	ErrWorkerStopped = errors.New("worker stopped")
+)
+
+// Check verifies a single dependency
+type Check func(ctx context.Context) error
+
+// Checker keeps the dependencies that must be healthy for the service to be ready
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	names        []string
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+// New init a checker without dependencies
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+
+	return &Checker{
+		timeout: timeout,
+		checks:  map[string]Check{},
+	}
+}
+
+// Register adds a dependency to the readiness, background workers register themselves here too
+func (h *Checker) Register(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+	}
+	h.checks[name] = check
+}
+
+// Shutdown makes the service not ready, so the orchestrator stops sending traffic
+func (h *Checker) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Report runs every check concurrently and aggregates the results
+func (h *Checker) Report(ctx context.Context) healthModel.Report {
+	h.mu.RLock()
+	names := append([]string{}, h.names...)
+	checks := make([]Check, len(names))
+	for idx, name := range names {
+		checks[idx] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	results := make([]healthModel.Check, len(names))
+	var wg sync.WaitGroup
+	for idx := range checks {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			results[idx] = h.run(ctx, names[idx], checks[idx])
+		}(idx)
+	}
+	wg.Wait()
+
+	report := healthModel.Report{
+		Status: healthModel.StatusOK,
+		Checks: make(map[string]healthModel.Check, len(names)),
+	}
+	for idx, name := range names {
+		report.Checks[name] = results[idx]
+		if results[idx].Status != healthModel.StatusOK {
+			report.Status = healthModel.StatusUnavailable
+		}
+	}
+
+	if h.shuttingDown.Load() {
+		report.Status = healthModel.StatusUnavailable
+		report.Checks["lifecycle"] = healthModel.Check{
+			Status: healthModel.StatusUnavailable,
+			Error:  ErrShuttingDown.Error(),
+		}
+	}
+
+	return report
+}
+
+func (h *Checker) run(ctx context.Context, name string, check Check) healthModel.Check {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := healthModel.Check{
+		Status:    healthModel.StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "health.Checker.run", "check": name}).WithError(err).Error(err)
+		result.Status = healthModel.StatusUnavailable
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Worker fails once the background worker that closes done has stopped, unless it
+// was stopped by the shutdown
+func (h *Checker) Worker(done <-chan struct{}) Check {
+	return func(ctx context.Context) error {
+		select {
+		case <-done:
+			if h.shuttingDown.Load() {
+				return nil
+			}
+			return ErrWorkerStopped
+		default:
+			return nil
+		}
+	}
+}
+
+// Database pings the database
+func Database(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Migrations checks the golang-migrate version table against the version this binary expects
+func Migrations(db *sql.DB, expected int64) Check {
+	return func(ctx context.Context) error {
+		var version int64
+		var dirty bool
+		err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version != expected {
+			return fmt.Errorf("schema at version %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	healthModel "github.com/danilotadeu/products/model/health"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckerReport(t *testing.T) {
+	cases := map[string]struct {
+		Checks         map[string]Check
+		ShuttingDown   bool
+		ExpectedStatus string
+		ExpectedChecks map[string]string
+	}{
+		"should be ok when every check passes": {
+			Checks: map[string]Check{
+				"database": func(ctx context.Context) error { return nil },
+			},
+			ExpectedStatus: healthModel.StatusOK,
+			ExpectedChecks: map[string]string{"database": healthModel.StatusOK},
+		},
+		"should be unavailable when a check fails": {
+			Checks: map[string]Check{
+				"database":   func(ctx context.Context) error { return nil },
+				"migrations": func(ctx context.Context) error { return fmt.Errorf("error") },
+			},
+			ExpectedStatus: healthModel.StatusUnavailable,
+			ExpectedChecks: map[string]string{"database": healthModel.StatusOK, "migrations": healthModel.StatusUnavailable},
+		},
+		"should be unavailable when a check times out": {
+			Checks: map[string]Check{
+				"database": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			ExpectedStatus: healthModel.StatusUnavailable,
+			ExpectedChecks: map[string]string{"database": healthModel.StatusUnavailable},
+		},
+		"should be unavailable while shutting down": {
+			Checks: map[string]Check{
+				"database": func(ctx context.Context) error { return nil },
+			},
+			ShuttingDown:   true,
+			ExpectedStatus: healthModel.StatusUnavailable,
+			ExpectedChecks: map[string]string{"database": healthModel.StatusOK, "lifecycle": healthModel.StatusUnavailable},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			checker := New(10 * time.Millisecond)
+			for checkName, check := range cs.Checks {
+				checker.Register(checkName, check)
+			}
+			if cs.ShuttingDown {
+				checker.Shutdown()
+			}
+
+			report := checker.Report(context.Background())
+
+			assert.Equal(t, cs.ExpectedStatus, report.Status)
+			assert.Equal(t, len(cs.ExpectedChecks), len(report.Checks))
+			for checkName, status := range cs.ExpectedChecks {
+				assert.Equal(t, status, report.Checks[checkName].Status)
+			}
+		})
+	}
+}
+
+func TestCheckerWorker(t *testing.T) {
+	checker := New(10 * time.Millisecond)
+	done := make(chan struct{})
+	check := checker.Worker(done)
+
+	assert.NilError(t, check(context.Background()))
+	close(done)
+	assert.ErrorIs(t, check(context.Background()), ErrWorkerStopped)
+	checker.Shutdown()
+	assert.NilError(t, check(context.Background()), "should not fail for the stop of the shutdown")
+}
@@ -0,0 +1,144 @@
+// Package i18n holds the message catalogues of the api and picks the locale of each
+// request, falling back to the default locale configured at startup
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	PortugueseBR = "pt-BR"
+	English      = "en"
+	Spanish      = "es"
+)
+
+// Locales lists the locales with a catalogue in locales/, PortugueseBR is the default
+// until SetDefault is called
+var Locales = []string{PortugueseBR, English, Spanish}
+
+//go:embed locales/*.json
+var files embed.FS
+
+var (
+	catalogues = load()
+	matcher    = language.NewMatcher(tags(Locales))
+
+	mu            sync.RWMutex
+	defaultLocale = PortugueseBR
+)
+
+type localeKey struct{}
+
+// SetDefault changes the locale answered when the client asks for none we have
+func SetDefault(locale string) error {
+	if _, ok := catalogues[locale]; !ok {
+		return fmt.Errorf("i18n: unsupported locale %q, use one of %s", locale, strings.Join(Locales, ", "))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultLocale = locale
+	return nil
+}
+
+// Default is the locale answered when the client asks for none we have
+func Default() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// Negotiate picks the locale best matching an Accept-Language header, or Default
+func Negotiate(acceptLanguage string) string {
+	requested, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(requested) == 0 {
+		return Default()
+	}
+	_, idx, confidence := matcher.Match(requested...)
+	if confidence == language.No {
+		return Default()
+	}
+	return Locales[idx]
+}
+
+// WithLocale returns a copy of ctx answering in locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale is the locale of ctx, or Default when none was negotiated
+func Locale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return Default()
+}
+
+// T translates key in the locale of ctx
+func T(ctx context.Context, key string, args ...interface{}) string {
+	return Translate(Locale(ctx), key, args...)
+}
+
+// Translate formats the message of key in locale, looking it up in the Default catalogue
+// when locale lacks it. An unknown key is used as the format itself, so a literal message
+// passes through untranslated
+func Translate(locale, key string, args ...interface{}) string {
+	format, ok := Lookup(locale, key)
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Lookup is the unformatted message of key in locale, or in the Default catalogue
+func Lookup(locale, key string) (string, bool) {
+	if format, ok := catalogues[locale][key]; ok {
+		return format, true
+	}
+	format, ok := catalogues[Default()][key]
+	return format, ok
+}
+
+// Keys lists the keys of the catalogue of locale
+func Keys(locale string) []string {
+	keys := make([]string, 0, len(catalogues[locale]))
+	for key := range catalogues[locale] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// load reads the embedded catalogues, a broken file is a bug caught by the tests
+func load() map[string]map[string]string {
+	loaded := make(map[string]map[string]string, len(Locales))
+	for _, locale := range Locales {
+		content, err := files.ReadFile(path.Join("locales", locale+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: read the catalogue of %s: %v", locale, err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(content, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parse the catalogue of %s: %v", locale, err))
+		}
+		loaded[locale] = messages
+	}
+	return loaded
+}
+
+func tags(locales []string) []language.Tag {
+	parsed := make([]language.Tag, len(locales))
+	for idx, locale := range locales {
+		parsed[idx] = language.MustParse(locale)
+	}
+	return parsed
+}
@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCataloguesHaveTheSameKeys(t *testing.T) {
+	expected := Keys(PortugueseBR)
+	sort.Strings(expected)
+	for _, locale := range Locales {
+		keys := Keys(locale)
+		sort.Strings(keys)
+		assert.DeepEqual(t, expected, keys)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]struct {
+		Default        string
+		AcceptLanguage string
+		ExpectedLocale string
+	}{
+		"should answer the default without the header": {
+			ExpectedLocale: PortugueseBR,
+		},
+		"should match the language of the region": {
+			AcceptLanguage: "pt-PT",
+			ExpectedLocale: PortugueseBR,
+		},
+		"should pick the heaviest supported language": {
+			AcceptLanguage: "de;q=0.9, en-GB;q=0.5, es;q=0.7",
+			ExpectedLocale: Spanish,
+		},
+		"should answer the configured default for the unsupported languages": {
+			Default:        English,
+			AcceptLanguage: "ja",
+			ExpectedLocale: English,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			if cs.Default != "" {
+				assert.NilError(t, SetDefault(cs.Default))
+				t.Cleanup(func() { SetDefault(PortugueseBR) })
+			}
+			assert.Equal(t, cs.ExpectedLocale, Negotiate(cs.AcceptLanguage))
+		})
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	assert.ErrorContains(t, SetDefault("fr"), `unsupported locale "fr"`)
+	assert.Equal(t, PortugueseBR, Default())
+}
+
+func TestTranslate(t *testing.T) {
+	cases := map[string]struct {
+		Locale   string
+		Key      string
+		Args     []interface{}
+		Expected string
+	}{
+		"should format the message of the locale": {
+			Locale:   Spanish,
+			Key:      "detail.product_not_found",
+			Args:     []interface{}{1},
+			Expected: "el producto 1 no existe",
+		},
+		"should use the default catalogue for an unknown locale": {
+			Locale:   "fr",
+			Key:      "detail.product_not_found",
+			Args:     []interface{}{1},
+			Expected: "o produto 1 não existe",
+		},
+		"should use an unknown key as the format": {
+			Locale:   English,
+			Key:      "%s",
+			Args:     []interface{}{"unexpected EOF"},
+			Expected: "unexpected EOF",
+		},
+		"should not format a literal message without arguments": {
+			Locale:   English,
+			Key:      "100% literal",
+			Expected: "100% literal",
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, cs.Expected, Translate(cs.Locale, cs.Key, cs.Args...))
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "nenhum produto nesta página", T(ctx, "detail.empty_page"))
+	assert.Equal(t, "no products in this page", T(WithLocale(ctx, English), "detail.empty_page"))
+}
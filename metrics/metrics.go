@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+const (
+	namespace = "products"
+
+	// unmatchedRoute labels requests that did not match any route, to keep the cardinality bounded
+	unmatchedRoute = "unmatched"
+
+	// stockTimeout limits the query made on every scrape to read the stock gauges
+	stockTimeout = 2 * time.Second
+)
+
+// StockFunc reads the business gauges from the catalogue
+type StockFunc func(ctx context.Context) (*productModel.StockStats, error)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of store queries by store and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"store", "operation"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "cache_requests_total",
+		Help:      "Total of store cache lookups by store, operation and result (hit or miss).",
+	}, []string{"store", "operation", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		queryDuration,
+		cacheRequests,
+	)
+}
+
+// Register adds the collectors that depend on the database and the catalogue
+func Register(db *sql.DB, stock StockFunc) {
+	// the memory store has no connection pool
+	if db != nil {
+		registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+	}
+	registry.MustRegister(newStockCollector(stock))
+
+	logrus.WithFields(logrus.Fields{"trace": "metrics"}).Infof("Registered - Metrics")
+}
+
+// Handler exposes the registry in the Prometheus text format
+func Handler() fiber.Handler {
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	}
+}
+
+// Middleware counts and times every request by its route pattern
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if err != nil {
+			// the response is written by the error handler after the middlewares, so the
+			// status is the one of the catalogue, or of the router for its own errors
+			status = errorsP.From(err).Status
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+				// the router reports a missing route with a fiber.ErrNotFound
+				if fiberErr.Code == fiber.StatusNotFound {
+					route = unmatchedRoute
+				}
+			}
+		}
+
+		labels := prometheus.Labels{
+			"method": c.Method(),
+			"route":  route,
+			"status": strconv.Itoa(status),
+		}
+		httpRequests.With(labels).Inc()
+		httpDuration.With(labels).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// ObserveQuery records the latency of a store operation started at start, use it with defer
+func ObserveQuery(store, operation string, start time.Time) {
+	queryDuration.WithLabelValues(store, operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCache counts a cache lookup of a store operation
+func ObserveCache(store, operation string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(store, operation, result).Inc()
+}
+
+type stockCollector struct {
+	stock             StockFunc
+	total             *prometheus.Desc
+	units             *prometheus.Desc
+	belowReorderPoint *prometheus.Desc
+}
+
+func newStockCollector(stock StockFunc) prometheus.Collector {
+	return &stockCollector{
+		stock:             stock,
+		total:             prometheus.NewDesc(namespace+"_catalog_products", "Total of products in the catalogue.", nil, nil),
+		units:             prometheus.NewDesc(namespace+"_catalog_stock_units", "Total of units in stock.", nil, nil),
+		belowReorderPoint: prometheus.NewDesc(namespace+"_catalog_below_reorder_point", "Total of products with stock below the reorder point.", nil, nil),
+	}
+}
+
+func (s *stockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.total
+	ch <- s.units
+	ch <- s.belowReorderPoint
+}
+
+func (s *stockCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), stockTimeout)
+	defer cancel()
+
+	stats, err := s.stock(ctx)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"trace": "metrics.stockCollector.Collect"}).WithError(err).Error(err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(s.total, prometheus.GaugeValue, float64(stats.Total))
+	ch <- prometheus.MustNewConstMetric(s.units, prometheus.GaugeValue, float64(stats.Units))
+	ch <- prometheus.MustNewConstMetric(s.belowReorderPoint, prometheus.GaugeValue, float64(stats.BelowReorderPoint))
+}
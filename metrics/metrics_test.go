@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	cases := map[string]struct {
+		Target        string
+		ExpectedRoute string
+		ExpectedCode  string
+	}{
+		"should label requests with the route pattern": {
+			Target:        "/products/1",
+			ExpectedRoute: "/products/:id",
+			ExpectedCode:  "200",
+		},
+		"should label unknown routes as unmatched": {
+			Target:        "/xpto",
+			ExpectedRoute: unmatchedRoute,
+			ExpectedCode:  "404",
+		},
+		"should label handler errors with their status": {
+			Target:        "/products/0",
+			ExpectedRoute: "/products/:id",
+			ExpectedCode:  "400",
+		},
+		"should label the errors of the catalogue with their status": {
+			Target:        "/products/404",
+			ExpectedRoute: "/products/:id",
+			ExpectedCode:  "404",
+		},
+		"should label the rate limited requests": {
+			Target:        "/products/429",
+			ExpectedRoute: "/products/:id",
+			ExpectedCode:  "429",
+		},
+		"should label the unknown errors as internal": {
+			Target:        "/products/500",
+			ExpectedRoute: "/products/:id",
+			ExpectedCode:  "500",
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			httpRequests.Reset()
+
+			app := fiber.New()
+			app.Use(Middleware())
+			app.Get("/products/:id", func(c *fiber.Ctx) error {
+				switch c.Params("id") {
+				case "0":
+					return fiber.ErrBadRequest
+				case "404":
+					return errorsP.ErrProductNotFound
+				case "429":
+					return errorsP.ErrRateLimited
+				case "500":
+					return errors.New("connection refused")
+				}
+				return c.SendStatus(http.StatusOK)
+			})
+
+			_, err := app.Test(httptest.NewRequest(http.MethodGet, cs.Target, nil), -1)
+			assert.NilError(t, err)
+
+			counter := httpRequests.WithLabelValues(http.MethodGet, cs.ExpectedRoute, cs.ExpectedCode)
+			assert.Equal(t, float64(1), testutil.ToFloat64(counter))
+		})
+	}
+}
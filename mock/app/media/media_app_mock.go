@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/danilotadeu/products/app/media (interfaces: App)
+
+// Package mockAppMedia is a generated GoMock package.
+package mockAppMedia
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	media "github.com/danilotadeu/products/model/media"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockApp is a mock of App interface.
+type MockApp struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppMockRecorder
+}
+
+// MockAppMockRecorder is the mock recorder for MockApp.
+type MockAppMockRecorder struct {
+	mock *MockApp
+}
+
+// NewMockApp creates a new mock instance.
+func NewMockApp(ctrl *gomock.Controller) *MockApp {
+	mock := &MockApp{ctrl: ctrl}
+	mock.recorder = &MockAppMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApp) EXPECT() *MockAppMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockApp) Delete(arg0 context.Context, arg1, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAppMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockApp)(nil).Delete), arg0, arg1, arg2)
+}
+
+// Get mocks base method.
+func (m *MockApp) Get(arg0 context.Context, arg1, arg2 int64) (*media.MediaDB, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*media.MediaDB)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAppMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockApp)(nil).Get), arg0, arg1, arg2)
+}
+
+// GetRendition mocks base method.
+func (m *MockApp) GetRendition(arg0 context.Context, arg1, arg2 int64, arg3 int) (*media.MediaDB, *media.Rendition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRendition", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*media.MediaDB)
+	ret1, _ := ret[1].(*media.Rendition)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRendition indicates an expected call of GetRendition.
+func (mr *MockAppMockRecorder) GetRendition(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRendition", reflect.TypeOf((*MockApp)(nil).GetRendition), arg0, arg1, arg2, arg3)
+}
+
+// List mocks base method.
+func (m *MockApp) List(arg0 context.Context, arg1 int64) ([]*media.MediaDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].([]*media.MediaDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAppMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockApp)(nil).List), arg0, arg1)
+}
+
+// Upload mocks base method.
+func (m *MockApp) Upload(arg0 context.Context, arg1 int64, arg2 string, arg3 io.Reader) (*media.MediaDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*media.MediaDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockAppMockRecorder) Upload(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockApp)(nil).Upload), arg0, arg1, arg2, arg3)
+}
+
+// Work mocks base method.
+func (m *MockApp) Work(arg0 context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Work", arg0)
+}
+
+// Work indicates an expected call of Work.
+func (mr *MockAppMockRecorder) Work(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Work", reflect.TypeOf((*MockApp)(nil).Work), arg0)
+}
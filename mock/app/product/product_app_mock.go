@@ -64,6 +64,36 @@ func (mr *MockAppMockRecorder) GetAllProducts(arg0, arg1, arg2, arg3 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockApp)(nil).GetAllProducts), arg0, arg1, arg2, arg3)
 }
 
+// GetByBarcode mocks base method.
+func (m *MockApp) GetByBarcode(arg0 context.Context, arg1 string) (*product.ProductDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByBarcode", arg0, arg1)
+	ret0, _ := ret[0].(*product.ProductDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByBarcode indicates an expected call of GetByBarcode.
+func (mr *MockAppMockRecorder) GetByBarcode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByBarcode", reflect.TypeOf((*MockApp)(nil).GetByBarcode), arg0, arg1)
+}
+
+// GetByIDs mocks base method.
+func (m *MockApp) GetByIDs(arg0 context.Context, arg1 []int64) ([]*product.ProductDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]*product.ProductDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockAppMockRecorder) GetByIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockApp)(nil).GetByIDs), arg0, arg1)
+}
+
 // GetOneByID mocks base method.
 func (m *MockApp) GetOneByID(arg0 context.Context, arg1 int64) (*product.ProductDB, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +109,21 @@ func (mr *MockAppMockRecorder) GetOneByID(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByID", reflect.TypeOf((*MockApp)(nil).GetOneByID), arg0, arg1)
 }
 
+// GetStockStats mocks base method.
+func (m *MockApp) GetStockStats(arg0 context.Context, arg1 int64) (*product.StockStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockStats", arg0, arg1)
+	ret0, _ := ret[0].(*product.StockStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockStats indicates an expected call of GetStockStats.
+func (mr *MockAppMockRecorder) GetStockStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockStats", reflect.TypeOf((*MockApp)(nil).GetStockStats), arg0, arg1)
+}
+
 // GetTotalProducts mocks base method.
 func (m *MockApp) GetTotalProducts(arg0 context.Context) (*int64, error) {
 	m.ctrl.T.Helper()
@@ -122,3 +167,17 @@ func (mr *MockAppMockRecorder) UpdateProduct(arg0, arg1 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockApp)(nil).UpdateProduct), arg0, arg1)
 }
+
+// Watch mocks base method.
+func (m *MockApp) Watch(arg0 context.Context) <-chan product.Event {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", arg0)
+	ret0, _ := ret[0].(<-chan product.Event)
+	return ret0
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockAppMockRecorder) Watch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockApp)(nil).Watch), arg0)
+}
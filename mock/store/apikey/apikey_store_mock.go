@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/danilotadeu/products/store/apikey (interfaces: Store)
+
+// Package mockStoreAPIKey is a generated GoMock package.
+package mockStoreAPIKey
+
+import (
+	context "context"
+	reflect "reflect"
+
+	apikey "github.com/danilotadeu/products/model/apikey"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// GetByHash mocks base method.
+func (m *MockStore) GetByHash(arg0 context.Context, arg1 string) (*apikey.APIKeyDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", arg0, arg1)
+	ret0, _ := ret[0].(*apikey.APIKeyDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockStoreMockRecorder) GetByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockStore)(nil).GetByHash), arg0, arg1)
+}
+
+// SaveAPIKey mocks base method.
+func (m *MockStore) SaveAPIKey(arg0 context.Context, arg1 apikey.APIKeyDB) (*int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(*int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveAPIKey indicates an expected call of SaveAPIKey.
+func (mr *MockStoreMockRecorder) SaveAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAPIKey", reflect.TypeOf((*MockStore)(nil).SaveAPIKey), arg0, arg1)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/danilotadeu/products/store/barcode (interfaces: Store)
+
+// Package mockStoreBarcode is a generated GoMock package.
+package mockStoreBarcode
+
+import (
+	context "context"
+	reflect "reflect"
+
+	product "github.com/danilotadeu/products/model/product"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteByProduct mocks base method.
+func (m *MockStore) DeleteByProduct(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByProduct", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByProduct indicates an expected call of DeleteByProduct.
+func (mr *MockStoreMockRecorder) DeleteByProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByProduct", reflect.TypeOf((*MockStore)(nil).DeleteByProduct), arg0, arg1)
+}
+
+// GetProductID mocks base method.
+func (m *MockStore) GetProductID(arg0 context.Context, arg1 string) (*int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductID", arg0, arg1)
+	ret0, _ := ret[0].(*int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductID indicates an expected call of GetProductID.
+func (mr *MockStoreMockRecorder) GetProductID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductID", reflect.TypeOf((*MockStore)(nil).GetProductID), arg0, arg1)
+}
+
+// ListByProducts mocks base method.
+func (m *MockStore) ListByProducts(arg0 context.Context, arg1 []int64) ([]*product.BarcodeDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByProducts", arg0, arg1)
+	ret0, _ := ret[0].([]*product.BarcodeDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByProducts indicates an expected call of ListByProducts.
+func (mr *MockStoreMockRecorder) ListByProducts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByProducts", reflect.TypeOf((*MockStore)(nil).ListByProducts), arg0, arg1)
+}
+
+// Replace mocks base method.
+func (m *MockStore) Replace(arg0 context.Context, arg1 int64, arg2 []product.BarcodeDB) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replace", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace.
+func (mr *MockStoreMockRecorder) Replace(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockStore)(nil).Replace), arg0, arg1, arg2)
+}
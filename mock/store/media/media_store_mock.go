@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/danilotadeu/products/store/media (interfaces: Store)
+
+// Package mockStoreMedia is a generated GoMock package.
+package mockStoreMedia
+
+import (
+	context "context"
+	reflect "reflect"
+
+	media "github.com/danilotadeu/products/model/media"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockStore) Delete(arg0 context.Context, arg1, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStoreMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStore)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteByProduct mocks base method.
+func (m *MockStore) DeleteByProduct(arg0 context.Context, arg1 int64) ([]*media.MediaDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByProduct", arg0, arg1)
+	ret0, _ := ret[0].([]*media.MediaDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByProduct indicates an expected call of DeleteByProduct.
+func (mr *MockStoreMockRecorder) DeleteByProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByProduct", reflect.TypeOf((*MockStore)(nil).DeleteByProduct), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockStore) GetByID(arg0 context.Context, arg1, arg2 int64) (*media.MediaDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*media.MediaDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockStoreMockRecorder) GetByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockStore)(nil).GetByID), arg0, arg1, arg2)
+}
+
+// ListByProduct mocks base method.
+func (m *MockStore) ListByProduct(arg0 context.Context, arg1 int64) ([]*media.MediaDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByProduct", arg0, arg1)
+	ret0, _ := ret[0].([]*media.MediaDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByProduct indicates an expected call of ListByProduct.
+func (mr *MockStoreMockRecorder) ListByProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByProduct", reflect.TypeOf((*MockStore)(nil).ListByProduct), arg0, arg1)
+}
+
+// SaveMedia mocks base method.
+func (m *MockStore) SaveMedia(arg0 context.Context, arg1 media.MediaDB) (*int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMedia", arg0, arg1)
+	ret0, _ := ret[0].(*int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveMedia indicates an expected call of SaveMedia.
+func (mr *MockStoreMockRecorder) SaveMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMedia", reflect.TypeOf((*MockStore)(nil).SaveMedia), arg0, arg1)
+}
@@ -64,6 +64,21 @@ func (mr *MockStoreMockRecorder) GetAll(arg0, arg1, arg2, arg3 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockStore)(nil).GetAll), arg0, arg1, arg2, arg3)
 }
 
+// GetByIDs mocks base method.
+func (m *MockStore) GetByIDs(arg0 context.Context, arg1 []int64) ([]*product.ProductDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]*product.ProductDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockStoreMockRecorder) GetByIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockStore)(nil).GetByIDs), arg0, arg1)
+}
+
 // GetOne mocks base method.
 func (m *MockStore) GetOne(arg0 context.Context, arg1 string) (*product.ProductDB, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +109,21 @@ func (mr *MockStoreMockRecorder) GetOneByID(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneByID", reflect.TypeOf((*MockStore)(nil).GetOneByID), arg0, arg1)
 }
 
+// GetStockStats mocks base method.
+func (m *MockStore) GetStockStats(arg0 context.Context, arg1 int64) (*product.StockStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockStats", arg0, arg1)
+	ret0, _ := ret[0].(*product.StockStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockStats indicates an expected call of GetStockStats.
+func (mr *MockStoreMockRecorder) GetStockStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockStats", reflect.TypeOf((*MockStore)(nil).GetStockStats), arg0, arg1)
+}
+
 // GetTotalProducts mocks base method.
 func (m *MockStore) GetTotalProducts(arg0 context.Context) (*int64, error) {
 	m.ctrl.T.Helper()
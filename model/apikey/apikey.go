@@ -0,0 +1,27 @@
+package apikey
+
+import (
+	"time"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+)
+
+var ErrorAPIKeyAlreadyExists = errorsP.ErrAPIKeyAlreadyExists
+
+// ErrorAPIKeyInvalid is a key that does not exist or was revoked
+var ErrorAPIKeyInvalid = errorsP.ErrUnauthorized.WithDetail("detail.invalid_api_key")
+
+type APIKeyDB struct {
+	ID        int64      `json:"id"`
+	Owner     string     `json:"owner" validate:"required,max=45"`
+	KeyHash   string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKey carries the plain key, it is shown only once
+type CreatedAPIKey struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	Key   string `json:"key"`
+}
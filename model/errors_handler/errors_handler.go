@@ -1,5 +1,191 @@
+// Package errors_handler is the catalogue of the errors answered by the api, each one
+// with a stable code the clients can branch on
 package errors_handler
 
-type ErrorsResponse struct {
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/danilotadeu/products/i18n"
+)
+
+const (
+	// StatusClientClosedRequest is the status of a request cancelled before the response
+	StatusClientClosedRequest = 499
+
+	// MIMEProblemJSON is the content type of the Problem bodies
+	MIMEProblemJSON = "application/problem+json"
+
+	// TypeBase prefixes the type of the problems, GET TypeBase + type describes it
+	TypeBase = "/problems/"
+)
+
+// Error is an entry of the catalogue. The errors are compared by Code, so a copy made
+// by WithDetail or Wrap still matches its entry with errors.Is. Title and Detail are
+// translated by Problem, Title is the message of the catalogues lacking "error.<Code>"
+type Error struct {
+	Code   string
+	Status int
+	Title  string
+	// Detail is an i18n key, or a literal format, formatted with Args
+	Detail string
+	Args   []interface{}
+	Fields []FieldError
+	Err    error
+}
+
+// FieldError is a field of the request that broke a validation rule
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
+
+var (
+	ErrBadRequest           = &Error{Code: "BAD_REQUEST", Status: http.StatusBadRequest, Title: "Requisição inválida"}
+	ErrInvalidID            = &Error{Code: "INVALID_ID", Status: http.StatusBadRequest, Title: "Id inválido"}
+	ErrInvalidQuery         = &Error{Code: "INVALID_QUERY_PARAMETER", Status: http.StatusBadRequest, Title: "Parâmetro de consulta inválido"}
+	ErrValidation           = &Error{Code: "VALIDATION_FAILED", Status: http.StatusBadRequest, Title: "Dados inválidos"}
+	ErrInvalidBarcode       = &Error{Code: "INVALID_BARCODE", Status: http.StatusBadRequest, Title: "Código de barras inválido"}
+	ErrUnauthorized         = &Error{Code: "UNAUTHORIZED", Status: http.StatusUnauthorized, Title: "Api key inválida ou ausente"}
+	ErrRouteNotFound        = &Error{Code: "ROUTE_NOT_FOUND", Status: http.StatusNotFound, Title: "Rota não encontrada"}
+	ErrProblemNotFound      = &Error{Code: "PROBLEM_NOT_FOUND", Status: http.StatusNotFound, Title: "Tipo de erro não encontrado"}
+	ErrProductNotFound      = &Error{Code: "PRODUCT_NOT_FOUND", Status: http.StatusNotFound, Title: "Produto não encontrado"}
+	ErrMediaNotFound        = &Error{Code: "MEDIA_NOT_FOUND", Status: http.StatusNotFound, Title: "Arquivo não encontrado"}
+	ErrMethodNotAllowed     = &Error{Code: "METHOD_NOT_ALLOWED", Status: http.StatusMethodNotAllowed, Title: "Método não permitido"}
+	ErrProductAlreadyExists = &Error{Code: "PRODUCT_ALREADY_EXISTS", Status: http.StatusConflict, Title: "Produto já cadastrado"}
+	ErrAPIKeyAlreadyExists  = &Error{Code: "API_KEY_ALREADY_EXISTS", Status: http.StatusConflict, Title: "Api key já cadastrada"}
+	ErrUserAlreadyExists    = &Error{Code: "USER_ALREADY_EXISTS", Status: http.StatusConflict, Title: "Usuário já cadastrado"}
+	ErrBarcodeAlreadyExists = &Error{Code: "BARCODE_ALREADY_EXISTS", Status: http.StatusConflict, Title: "Código de barras já cadastrado"}
+	ErrMediaTooLarge        = &Error{Code: "MEDIA_TOO_LARGE", Status: http.StatusRequestEntityTooLarge, Title: "Arquivo muito grande"}
+	ErrUnsupportedMediaType = &Error{Code: "UNSUPPORTED_MEDIA_TYPE", Status: http.StatusUnsupportedMediaType, Title: "Tipo de arquivo não suportado"}
+	ErrRateLimited          = &Error{Code: "RATE_LIMITED", Status: http.StatusTooManyRequests, Title: "Limite de requisições excedido"}
+	ErrRequestCancelled     = &Error{Code: "REQUEST_CANCELLED", Status: StatusClientClosedRequest, Title: "A requisição foi cancelada"}
+	ErrInternal             = &Error{Code: "INTERNAL", Status: http.StatusInternalServerError, Title: "Aconteceu um erro interno"}
+	ErrQueryTimeout         = &Error{Code: "QUERY_TIMEOUT", Status: http.StatusGatewayTimeout, Title: "O tempo limite da consulta foi excedido"}
+)
+
+// Catalogue lists every entry, a code is never reused for another error
+var Catalogue = []*Error{
+	ErrBadRequest,
+	ErrInvalidID,
+	ErrInvalidQuery,
+	ErrValidation,
+	ErrInvalidBarcode,
+	ErrUnauthorized,
+	ErrRouteNotFound,
+	ErrProblemNotFound,
+	ErrProductNotFound,
+	ErrMediaNotFound,
+	ErrMethodNotAllowed,
+	ErrProductAlreadyExists,
+	ErrAPIKeyAlreadyExists,
+	ErrUserAlreadyExists,
+	ErrBarcodeAlreadyExists,
+	ErrMediaTooLarge,
+	ErrUnsupportedMediaType,
+	ErrRateLimited,
+	ErrRequestCancelled,
+	ErrInternal,
+	ErrQueryTimeout,
+}
+
+// From finds the entry of the catalogue of err, a query cancelled or past its timeout
+// included, the errors out of it are internal
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.Canceled):
+		return ErrRequestCancelled.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrQueryTimeout.Wrap(err)
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// Problem is the application/problem+json body (RFC 7807) of an Error
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Code     string `json:"code"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors lists the invalid fields of a VALIDATION_FAILED
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+func (e *Error) Error() string {
+	message := e.Title
+	if e.Detail != "" {
+		message += ": " + i18n.Translate(i18n.Default(), e.Detail, e.Args...)
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any Error with the same code
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+// WithDetail returns a copy of e explaining this occurrence to the client, key is looked
+// up in the i18n catalogues and used as the format when missing
+func (e *Error) WithDetail(key string, args ...interface{}) *Error {
+	copied := *e
+	copied.Detail = key
+	copied.Args = args
+	return &copied
+}
+
+// WithFields returns a copy of e listing the invalid fields
+func (e *Error) WithFields(fields []FieldError) *Error {
+	copied := *e
+	copied.Fields = fields
+	return &copied
+}
+
+// Wrap returns a copy of e caused by err, err is logged but never answered
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// Type is the code in kebab case, the type of the problem is TypeBase + Type
+func (e *Error) Type() string {
+	return strings.ReplaceAll(strings.ToLower(e.Code), "_", "-")
+}
+
+// Problem is the body answering e in locale to the request of instance
+func (e *Error) Problem(locale, instance string) Problem {
+	title, ok := i18n.Lookup(locale, "error."+e.Code)
+	if !ok {
+		title = e.Title
+	}
+	detail := ""
+	if e.Detail != "" {
+		detail = i18n.Translate(locale, e.Detail, e.Args...)
+	}
+	return Problem{
+		Type:     TypeBase + e.Type(),
+		Title:    title,
+		Status:   e.Status,
+		Code:     e.Code,
+		Detail:   detail,
+		Instance: instance,
+		Errors:   e.Fields,
+	}
+}
@@ -0,0 +1,49 @@
+package media
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+)
+
+var ErrorMediaNotFound = errorsP.ErrMediaNotFound
+
+var ErrorMediaTooLarge = errorsP.ErrMediaTooLarge
+
+var ErrorUnsupportedMediaType = errorsP.ErrUnsupportedMediaType
+
+// MediaDB is a file attached to a product, its content is the blob of BlobKey
+type MediaDB struct {
+	ID          int64     `json:"id"`
+	ProductID   int64     `json:"product_id"`
+	BlobKey     string    `json:"-"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ResponseMedia lists the media of a product
+type ResponseMedia struct {
+	Data []*MediaDB `json:"data"`
+}
+
+// Rendition is a copy of an image media resized to fit Size, the caller closes Content
+type Rendition struct {
+	Size        int
+	ContentType string
+	Content     io.ReadCloser
+}
+
+// RenditionPrefix is the prefix of the blobs of the renditions of the media kept in blobKey
+func RenditionPrefix(blobKey string) string {
+	return "renditions/" + blobKey
+}
+
+// RenditionKey is the blob of the rendition of size in format, webp, jpeg or png
+func RenditionKey(blobKey string, size int, format string) string {
+	return fmt.Sprintf("%s/%d.%s", RenditionPrefix(blobKey), size, format)
+}
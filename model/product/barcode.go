@@ -0,0 +1,9 @@
+package product
+
+// BarcodeDB is a barcode of a product, Code as sent by the client and GTIN the GTIN-14
+// it is unique and looked up by
+type BarcodeDB struct {
+	ProductID int64
+	Code      string
+	GTIN      string
+}
@@ -1,18 +1,25 @@
 package product
 
 import (
-	"errors"
 	"time"
 
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
 	genericModel "github.com/danilotadeu/products/model/generic"
 )
 
-var ErrorProductNotFound = errors.New("product not found")
+var ErrorProductNotFound = errorsP.ErrProductNotFound
 
+var ErrorProductAlreadyExists = errorsP.ErrProductAlreadyExists
+
+var ErrorBarcodeAlreadyExists = errorsP.ErrBarcodeAlreadyExists
+
+// ProductDB is a product. Its Barcodes are UPC-A, EAN-13 or GTIN-14, an update without
+// them keeps the current ones and an empty list removes them
 type ProductDB struct {
 	ID        int64      `json:"id"`
-	Name      string     `json:"name" validate:"required"`
-	Quantity  int64      `json:"quantity" validate:"required"`
+	Name      string     `json:"name" validate:"required,trimmed,productname"`
+	Quantity  int64      `json:"quantity" validate:"gte=0"`
+	Barcodes  []string   `json:"barcodes,omitempty" validate:"omitempty,max=10,dive,barcode"`
 	CreatedAt time.Time  `json:"created_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
@@ -21,7 +28,28 @@ type ProductsTotal struct {
 	Total int64 `json:"total"`
 }
 
+type StockStats struct {
+	Total             int64 `json:"total"`
+	Units             int64 `json:"units"`
+	BelowReorderPoint int64 `json:"below_reorder_point"`
+}
+
 type ResponseProducts struct {
 	Data               []*ProductDB            `json:"data"`
 	ResponsePagination genericModel.Pagination `json:"pagination"`
 }
+
+// EventType is the kind of change of a product
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a change of a product made by this instance
+type Event struct {
+	Type    EventType
+	Product ProductDB
+}
@@ -0,0 +1,68 @@
+package product
+
+import "time"
+
+// ProductRequest is the body creating or updating a product in v2, the id and the
+// timestamps are never taken from the client. An update without barcodes keeps them
+type ProductRequest struct {
+	Name     string   `json:"name" validate:"required,trimmed,productname"`
+	Quantity int64    `json:"quantity" validate:"gte=0"`
+	Barcodes []string `json:"barcodes" validate:"omitempty,max=10,dive,barcode"`
+}
+
+// ProductResponse is a product as answered by v2, the timestamps are ISO 8601 in UTC
+type ProductResponse struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Quantity  int64    `json:"quantity"`
+	Barcodes  []string `json:"barcodes"`
+	CreatedAt string   `json:"created_at"`
+	DeletedAt *string  `json:"deleted_at,omitempty"`
+}
+
+// ProductEnvelope wraps a product answered by v2
+type ProductEnvelope struct {
+	Data ProductResponse `json:"data"`
+}
+
+// ProductsEnvelope wraps a page of products answered by v2
+type ProductsEnvelope struct {
+	Data []ProductResponse `json:"data"`
+	Meta PageMeta          `json:"meta"`
+}
+
+// PageMeta locates a page of v2, the pages start at 1
+type PageMeta struct {
+	Page         int64  `json:"page"`
+	Limit        int64  `json:"limit"`
+	NextPage     *int64 `json:"next_page"`
+	PreviousPage *int64 `json:"previous_page"`
+}
+
+// ProductDB is the product of the request, identified by id
+func (r ProductRequest) ProductDB(id int64) ProductDB {
+	return ProductDB{ID: id, Name: r.Name, Quantity: r.Quantity, Barcodes: r.Barcodes}
+}
+
+// NewProductResponse is the v2 representation of p
+func NewProductResponse(p *ProductDB) ProductResponse {
+	response := ProductResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Quantity:  p.Quantity,
+		Barcodes:  p.Barcodes,
+		CreatedAt: isoTime(p.CreatedAt),
+	}
+	if response.Barcodes == nil {
+		response.Barcodes = []string{}
+	}
+	if p.DeletedAt != nil {
+		deletedAt := isoTime(*p.DeletedAt)
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
+
+func isoTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
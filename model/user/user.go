@@ -0,0 +1,23 @@
+package user
+
+import (
+	"time"
+
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+)
+
+var ErrorUserAlreadyExists = errorsP.ErrUserAlreadyExists
+
+type UserDB struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name" validate:"required,max=45"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreatedUser carries the first api key of the user, shown only once
+type CreatedUser struct {
+	ID     int64                     `json:"id"`
+	Name   string                    `json:"name"`
+	APIKey apiKeyModel.CreatedAPIKey `json:"api_key"`
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EXIT_FAILURE is returned when a component fails to start or stops running by itself
+	EXIT_FAILURE = 1
+	// EXIT_SHUTDOWN_TIMEOUT is returned when the components did not stop inside the drain deadline
+	EXIT_SHUTDOWN_TIMEOUT = 2
+)
+
+var ErrShutdownTimeout = errors.New("shutdown deadline exceeded")
+
+// Hook is a component of the process, OnStart must not block: long running
+// work goes to a goroutine that reports its failure with Lifecycle.Fail
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts the hooks in order and stops them in reverse order
+type Lifecycle struct {
+	hooks        []Hook
+	drainTimeout time.Duration
+	failures     chan error
+	signals      []os.Signal
+}
+
+// NewLifecycle init a lifecycle that gives drainTimeout to the hooks to stop
+func NewLifecycle(drainTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		drainTimeout: drainTimeout,
+		failures:     make(chan error, 1),
+		signals:      []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+// Append registers a component, it is stopped before the ones appended earlier
+func (l *Lifecycle) Append(hook Hook) {
+	l.hooks = append(l.hooks, hook)
+}
+
+// Fail asks the lifecycle to shut down because a running component failed
+func (l *Lifecycle) Fail(err error) {
+	select {
+	case l.failures <- err:
+	default:
+	}
+}
+
+// Run starts every hook, waits for a signal or a failure and then shuts down
+func (l *Lifecycle) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, l.signals...)
+	defer stop()
+
+	for idx, hook := range l.hooks {
+		if hook.OnStart == nil {
+			continue
+		}
+		if err := hook.OnStart(ctx); err != nil {
+			logrus.WithFields(logrus.Fields{"trace": "server.Lifecycle.Run.OnStart", "hook": hook.Name}).WithError(err).Error(err)
+			return joinErrors(fmt.Errorf("%s: %w", hook.Name, err), l.stop(l.hooks[:idx]))
+		}
+		logrus.WithFields(logrus.Fields{"trace": "server.lifecycle"}).Infof("Started - %s", hook.Name)
+	}
+
+	var failure error
+	select {
+	case <-ctx.Done():
+		logrus.WithFields(logrus.Fields{"trace": "server.lifecycle"}).Infof("Gracefully shutting down...")
+	case failure = <-l.failures:
+		logrus.WithFields(logrus.Fields{"trace": "server.Lifecycle.Run.Fail"}).WithError(failure).Error(failure)
+	}
+
+	return joinErrors(failure, l.stop(l.hooks))
+}
+
+// stop runs the OnStop of hooks in reverse order sharing one drain deadline
+func (l *Lifecycle) stop(hooks []Hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.drainTimeout)
+	defer cancel()
+
+	var errs []string
+	for idx := len(hooks) - 1; idx >= 0; idx-- {
+		hook := hooks[idx]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := hook.OnStop(ctx); err != nil {
+			logrus.WithFields(logrus.Fields{"trace": "server.Lifecycle.stop.OnStop", "hook": hook.Name}).WithError(err).Error(err)
+			errs = append(errs, hook.Name+": "+err.Error())
+			continue
+		}
+		logrus.WithFields(logrus.Fields{"trace": "server.lifecycle"}).Infof("Stopped - %s", hook.Name)
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = errors.New(strings.Join(errs, "; "))
+	}
+	if ctx.Err() != nil {
+		return joinErrors(ErrShutdownTimeout, err)
+	}
+	return err
+}
+
+// multiError keeps errors.Is working for every error it holds
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, len(m))
+	for idx, err := range m {
+		msgs[idx] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m multiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinErrors(errs ...error) error {
+	var result multiError
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	switch len(result) {
+	case 0:
+		return nil
+	case 1:
+		return result[0]
+	default:
+		return result
+	}
+}
+
+// ExitCode maps the error returned by Start to the process status
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrShutdownTimeout):
+		return EXIT_SHUTDOWN_TIMEOUT
+	default:
+		return EXIT_FAILURE
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLifecycleRun(t *testing.T) {
+	cases := map[string]struct {
+		FailStart        string
+		BlockStop        string
+		Trigger          func(l *Lifecycle)
+		ExpectedCalls    []string
+		ExpectedExitCode int
+	}{
+		"should stop in reverse order on SIGTERM": {
+			Trigger: func(l *Lifecycle) {
+				_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+			},
+			ExpectedCalls:    []string{"start database", "start http", "stop http", "stop database"},
+			ExpectedExitCode: 0,
+		},
+		"should stop when a component fails": {
+			Trigger: func(l *Lifecycle) {
+				l.Fail(fmt.Errorf("listen: address already in use"))
+			},
+			ExpectedCalls:    []string{"start database", "start http", "stop http", "stop database"},
+			ExpectedExitCode: EXIT_FAILURE,
+		},
+		"should stop only the started components when a start fails": {
+			FailStart:        "http",
+			ExpectedCalls:    []string{"start database", "start http", "stop database"},
+			ExpectedExitCode: EXIT_FAILURE,
+		},
+		"should give up after the drain deadline": {
+			BlockStop: "http",
+			Trigger: func(l *Lifecycle) {
+				_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+			},
+			ExpectedCalls:    []string{"start database", "start http", "stop http", "stop database"},
+			ExpectedExitCode: EXIT_SHUTDOWN_TIMEOUT,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			lifecycle := NewLifecycle(50 * time.Millisecond)
+
+			calls := []string{}
+			for _, component := range []string{"database", "http"} {
+				component := component
+				lifecycle.Append(Hook{
+					Name: component,
+					OnStart: func(ctx context.Context) error {
+						calls = append(calls, "start "+component)
+						if cs.FailStart == component {
+							return fmt.Errorf("error")
+						}
+						return nil
+					},
+					OnStop: func(ctx context.Context) error {
+						calls = append(calls, "stop "+component)
+						if cs.BlockStop == component {
+							<-ctx.Done()
+							return ctx.Err()
+						}
+						return nil
+					},
+				})
+			}
+
+			if cs.Trigger != nil {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					cs.Trigger(lifecycle)
+				}()
+			}
+
+			err := lifecycle.Run(context.Background())
+
+			assert.DeepEqual(t, cs.ExpectedCalls, calls)
+			assert.Equal(t, cs.ExpectedExitCode, ExitCode(err))
+		})
+	}
+}
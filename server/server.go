@@ -1,71 +1,350 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"net"
+	"net/url"
 	"os"
-	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/danilotadeu/products/api"
+	"github.com/danilotadeu/products/api/middleware/ratelimit"
 	"github.com/danilotadeu/products/app"
+	"github.com/danilotadeu/products/config"
+	schema "github.com/danilotadeu/products/db"
+	grpcServer "github.com/danilotadeu/products/grpc"
+	"github.com/danilotadeu/products/health"
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
 	"github.com/danilotadeu/products/store"
+	apiKeyStore "github.com/danilotadeu/products/store/apikey"
+	"github.com/danilotadeu/products/store/blob"
+	productStore "github.com/danilotadeu/products/store/product"
+	"github.com/danilotadeu/products/store/timeout"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-const LOGS_PATH = "log/logrus.log"
-
 // Server is a interface to define contract to server up
 type Server interface {
-	Start()
-	ConnectDatabase() *sql.DB
+	Start() error
+	Migrate(args []string) error
+	ConnectDatabase() (*sql.DB, error)
+	CheckSchema(ctx context.Context, db *sql.DB) error
 }
 
 type server struct {
-	App   *app.Container
-	Store *store.Container
-	Db    *sql.DB
+	Config *config.Config
+	App    *app.Container
+	Store  *store.Container
+	Db     *sql.DB
+	Health *health.Checker
+	Http   *fiber.App
+	Grpc   *grpcServer.Server
 }
 
 // New is instance the server
-func New() Server {
-	return &server{}
+func New(cfg *config.Config) Server {
+	return &server{
+		Config: cfg,
+	}
 }
 
-func (e *server) Start() {
+func (e *server) Start() error {
 	logrus.SetFormatter(&logrus.JSONFormatter{})
 	logrus.SetOutput(io.MultiWriter(os.Stdout, &lumberjack.Logger{
-		Filename: LOGS_PATH,
-		MaxSize:  50, // megabytes
+		Filename: e.Config.Log.Path,
+		MaxSize:  e.Config.Log.MaxSizeMB, // megabytes
 	}))
+	level, _ := logrus.ParseLevel(e.Config.Log.Level)
+	logrus.SetLevel(level)
+	logrus.AddHook(tracing.NewLogrusHook())
+
+	lifecycle := NewLifecycle(e.Config.Server.ShutdownTimeout)
+
+	var shutdownTracing func(context.Context) error
+	lifecycle.Append(Hook{
+		Name: "tracing",
+		OnStart: func(ctx context.Context) (err error) {
+			shutdownTracing, err = tracing.Init(e.Config.Tracing.Exporter)
+			return err
+		},
+		OnStop: func(ctx context.Context) error {
+			return shutdownTracing(ctx)
+		},
+	})
+
+	// the memory store runs without a database
+	if e.Config.Store != "memory" {
+		lifecycle.Append(Hook{
+			Name: "database",
+			OnStart: func(ctx context.Context) (err error) {
+				e.Db, err = e.ConnectDatabase()
+				if err != nil {
+					return err
+				}
+				if err := e.prepareSchema(ctx); err != nil {
+					e.Db.Close()
+					return err
+				}
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return e.Db.Close()
+			},
+		})
+	}
+
+	lifecycle.Append(Hook{
+		Name: "app",
+		OnStart: func(ctx context.Context) error {
+			e.Health = health.New(e.Config.Server.HealthCheckTimeout)
+			if e.Db == nil {
+				e.Store = store.NewMemory()
+			} else {
+				e.Store = store.Register(e.Db, e.Config.Database.Driver).WithTimeouts(timeout.Timeouts{
+					Read:  e.Config.Database.ReadTimeout,
+					Write: e.Config.Database.WriteTimeout,
+				})
+				e.Health.Register("database", health.Database(e.Db))
+				e.Health.Register("migrations", health.Migrations(e.Db, schema.SchemaVersion))
+
+				storage, err := blob.Open(e.Config.Media.Storage, e.Config.Media.Path)
+				if err != nil {
+					return err
+				}
+				e.Store.WithBlob(storage)
+			}
+			e.Store.APIKey = apiKeyStore.NewInvalidCacheStore(e.Store.APIKey, e.Config.Auth.InvalidCacheSize, e.Config.Auth.InvalidCacheTTL)
+			if cache := e.Config.Cache; cache.Enabled {
+				e.Store.Product = productStore.NewCachedStore(e.Store.Product, cache.Size, cache.TTL, e.Config.Database.ReadTimeout)
+			}
+			e.App = app.Register(e.Store, e.Config.Media)
+
+			metrics.Register(e.Db, func(ctx context.Context) (*productModel.StockStats, error) {
+				return e.App.Product.GetStockStats(ctx, e.Config.Catalog.ReorderPoint)
+			})
+			return nil
+		},
+	})
+
+	// the uploads still waiting when it stops are rendered on demand
+	var stopRenditions context.CancelFunc
+	renditionsDone := make(chan struct{})
+	lifecycle.Append(Hook{
+		Name: "renditions",
+		OnStart: func(ctx context.Context) error {
+			var workCtx context.Context
+			workCtx, stopRenditions = context.WithCancel(context.Background())
+			e.Health.Register("renditions", e.Health.Worker(renditionsDone))
+			go func() {
+				defer close(renditionsDone)
+				e.App.Media.Work(workCtx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopRenditions()
+			select {
+			case <-renditionsDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	// the http and grpc clients share the same budgets
+	rateLimit := ratelimit.NewConfig(e.Config.RateLimit)
 
-	e.Db = e.ConnectDatabase()
-	e.Store = store.Register(e.Db)
-	e.App = app.Register(e.Store)
-	api.Register(e.App, os.Getenv("PORT"))
-
-	gracefulShutdown := make(chan os.Signal, 1)
-	signal.Notify(gracefulShutdown, os.Interrupt)
-	go func() {
-		<-gracefulShutdown
-		_ = e.Db.Close()
-	}()
+	// the requests still running past the drain deadline are cancelled
+	var cancelRequests context.CancelFunc
+	lifecycle.Append(Hook{
+		Name: "http",
+		OnStart: func(ctx context.Context) error {
+			var requestsCtx context.Context
+			requestsCtx, cancelRequests = context.WithCancel(context.Background())
+			e.Http = api.Register(requestsCtx, e.App, e.Health, e.Config, rateLimit)
+			go func() {
+				if err := e.Http.Listen(fmt.Sprintf(":%d", e.Config.Server.Port)); err != nil {
+					lifecycle.Fail(err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			// keeps serving while the orchestrator notices the readiness going down
+			e.Health.Shutdown()
+			select {
+			case <-time.After(e.Config.Server.ShutdownDelay):
+			case <-ctx.Done():
+			}
+
+			// waits the in-flight requests until the drain deadline
+			defer cancelRequests()
+			deadline, _ := ctx.Deadline()
+			return e.Http.ShutdownWithTimeout(time.Until(deadline))
+		},
+	})
+
+	lifecycle.Append(Hook{
+		Name: "grpc",
+		OnStart: func(ctx context.Context) error {
+			lis, err := net.Listen("tcp", fmt.Sprintf(":%d", e.Config.Server.GRPCPort))
+			if err != nil {
+				return err
+			}
+
+			e.Grpc = grpcServer.New(e.App, e.Config.Auth.Required, rateLimit)
+			go func() {
+				if err := e.Grpc.Serve(lis); err != nil {
+					lifecycle.Fail(err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return e.Grpc.Shutdown(ctx)
+		},
+	})
+
+	return lifecycle.Run(context.Background())
 }
 
-func (e *server) ConnectDatabase() *sql.DB {
-	connectionMysql := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?multiStatements=true&parseTime=true", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_DATABASE"))
-	db, err := sql.Open("mysql", connectionMysql)
+func (e *server) ConnectDatabase() (*sql.DB, error) {
+	dbConfig := e.Config.Database
+
+	var db *sql.DB
+	var err error
+	switch dbConfig.Driver {
+	case schema.SQLite:
+		// a single connection serializes the writers, the busy timeout covers other processes on the file
+		connectionSQLite := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", dbConfig.Path)
+		db, err = sql.Open("sqlite3", connectionSQLite)
+		if err == nil {
+			db.SetMaxOpenConns(1)
+		}
+	case schema.Postgres:
+		connectionPostgres := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(dbConfig.User, dbConfig.Password),
+			Host:     net.JoinHostPort(dbConfig.Host, strconv.Itoa(dbConfig.Port)),
+			Path:     dbConfig.Name,
+			RawQuery: url.Values{"sslmode": {dbConfig.SSLMode}}.Encode(),
+		}
+		db, err = sql.Open("postgres", connectionPostgres.String())
+	default:
+		connectionMysql := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?multiStatements=true&parseTime=true", dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Name)
+		db, err = sql.Open("mysql", connectionMysql)
+	}
 	if err != nil {
-		panic(err)
+		logrus.WithFields(logrus.Fields{"trace": "server.ConnectDatabase.Open"}).WithError(err).Error(err)
+		return nil, err
 	}
 
 	if err = db.Ping(); err != nil {
 		db.Close()
-		log.Println("error db.Ping(): ", err.Error())
-		panic(err)
+		logrus.WithFields(logrus.Fields{"trace": "server.ConnectDatabase.Ping"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// prepareSchema applies the migrations when configured and refuses a schema newer than the binary
+func (e *server) prepareSchema(ctx context.Context) error {
+	migrator, err := schema.NewMigrator(ctx, e.Db, e.Config.Database.Driver)
+	if err != nil {
+		return err
 	}
+	defer migrator.Close()
 
-	return db
+	if e.Config.Database.AutoMigrate {
+		if err := migrator.Up(); err != nil {
+			logrus.WithFields(logrus.Fields{"trace": "server.prepareSchema.Up"}).WithError(err).Error(err)
+			return err
+		}
+	}
+
+	return migrator.CheckSchema()
+}
+
+// CheckSchema refuses a schema of db newer than the binary, without migrating it
+func (e *server) CheckSchema(ctx context.Context, db *sql.DB) error {
+	migrator, err := schema.NewMigrator(ctx, db, e.Config.Database.Driver)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.CheckSchema()
+}
+
+// Migrate runs the migrate subcommand: up, down, status or goto N
+func (e *server) Migrate(args []string) error {
+	usage := errors.New("usage: migrate up|down|status|goto N")
+	if len(args) == 0 {
+		return usage
+	}
+	if e.Config.Store == "memory" {
+		return errors.New("the memory store has no migrations, set STORE=sql")
+	}
+
+	db, err := e.ConnectDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := schema.NewMigrator(context.Background(), db, e.Config.Database.Driver)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "goto":
+		if len(args) != 2 {
+			return usage
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return usage
+		}
+		return migrator.Goto(uint(version))
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		return printStatus(os.Stdout, status)
+	default:
+		return usage
+	}
+}
+
+func printStatus(w io.Writer, status *schema.Status) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "version: %d (latest %d, dirty %t)\n\n", status.Version, status.Latest, status.Dirty)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, migration := range status.Migrations {
+		state := "pending"
+		if migration.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", migration.Version, migration.Name, state)
+	}
+	return tw.Flush()
 }
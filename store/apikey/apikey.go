@@ -0,0 +1,81 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// Store is a contract to APIKey..
+//
+//go:generate mockgen -destination ../../mock/store/apikey/apikey_store_mock.go -package mockStoreAPIKey . Store
+type Store interface {
+	SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error)
+	// GetByHash finds the key not revoked with the sha256 keyHash, ErrorAPIKeyInvalid when
+	// there is none
+	GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error)
+}
+
+type storeImpl struct {
+	db *sql.DB
+}
+
+// NewStore init an APIKey
+func NewStore(db *sql.DB) Store {
+	return &storeImpl{
+		db: db,
+	}
+}
+
+func (a *storeImpl) SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error) {
+	defer metrics.ObserveQuery("apikey", "SaveAPIKey", time.Now())
+
+	query := "INSERT INTO api_keys(owner, key_hash) VALUES (?, ?)"
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.apikey.SaveAPIKey", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, apiKey.Owner, apiKey.KeyHash)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.apikey.SaveAPIKey.Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.apikey.SaveAPIKey.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *storeImpl) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	defer metrics.ObserveQuery("apikey", "GetByHash", time.Now())
+
+	query := "SELECT id, owner, key_hash, created_at FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL"
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.apikey.GetByHash", query)
+	defer span.End()
+
+	return getByHash(ctx, tx.From(ctx, a.db).QueryRowContext(ctx, query, keyHash), "store.apikey.GetByHash.Scan")
+}
+
+// getByHash scans the key of row, shared by the drivers
+func getByHash(ctx context.Context, row *sql.Row, trace string) (*apiKeyModel.APIKeyDB, error) {
+	var apiKey apiKeyModel.APIKeyDB
+	if err := row.Scan(&apiKey.ID, &apiKey.Owner, &apiKey.KeyHash, &apiKey.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apiKeyModel.ErrorAPIKeyInvalid
+		}
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
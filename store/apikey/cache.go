@@ -0,0 +1,132 @@
+package apikey
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store/tx"
+)
+
+// invalidCacheStore remembers the hashes GetByHash did not find in front of another Store,
+// so a client sending made-up keys does not cost a query each. The keys found are never
+// cached, a revoked key is refused on the next request
+type invalidCacheStore struct {
+	Store
+	invalid *expiringSet
+}
+
+// NewInvalidCacheStore wraps store with the last size hashes answered with ErrorAPIKeyInvalid,
+// each one kept for ttl. The keys are random, so a hash refused is not expected to be saved
+// later, SaveAPIKey still drops it from this instance
+func NewInvalidCacheStore(store Store, size int, ttl time.Duration) Store {
+	return &invalidCacheStore{
+		Store:   store,
+		invalid: newExpiringSet(size, ttl),
+	}
+}
+
+func (a *invalidCacheStore) SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error) {
+	defer tx.AfterCommit(ctx, func() { a.invalid.Remove(apiKey.KeyHash) })
+	defer a.invalid.Remove(apiKey.KeyHash)
+	return a.Store.SaveAPIKey(ctx, apiKey)
+}
+
+func (a *invalidCacheStore) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	// the transaction may see keys not committed yet
+	if tx.InTransaction(ctx) {
+		return a.Store.GetByHash(ctx, keyHash)
+	}
+
+	if a.invalid.Contains(keyHash) {
+		metrics.ObserveCache("apikey", "GetByHash", true)
+		return nil, apiKeyModel.ErrorAPIKeyInvalid
+	}
+	metrics.ObserveCache("apikey", "GetByHash", false)
+
+	apiKey, err := a.Store.GetByHash(ctx, keyHash)
+	if errors.Is(err, apiKeyModel.ErrorAPIKeyInvalid) {
+		a.invalid.Add(keyHash)
+	}
+	return apiKey, err
+}
+
+// expiringSet keeps up to size keys, each one for ttl. Every key lives the same ttl, so the
+// oldest added is the first to expire and the one dropped when it is full
+type expiringSet struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+type expiringEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+func newExpiringSet(size int, ttl time.Duration) *expiringSet {
+	return &expiringSet{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Contains reports if key was added and did not expire
+func (s *expiringSet) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+	_, ok := s.entries[key]
+	return ok
+}
+
+// Add keeps key for ttl from now
+func (s *expiringSet) Add(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.order.Remove(element)
+	}
+	s.entries[key] = s.order.PushBack(&expiringEntry{key: key, expiresAt: s.now().Add(s.ttl)})
+	for s.order.Len() > s.size {
+		s.remove(s.order.Front())
+	}
+}
+
+// Remove drops key
+func (s *expiringSet) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+}
+
+// expire drops the keys whose ttl is over, from the oldest
+func (s *expiringSet) expire() {
+	now := s.now()
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		if now.Before(element.Value.(*expiringEntry).expiresAt) {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+func (s *expiringSet) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*expiringEntry).key)
+}
@@ -0,0 +1,114 @@
+package apikey
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store/tx"
+	"gotest.tools/v3/assert"
+)
+
+// countingStore counts the reads that reach the wrapped store
+type countingStore struct {
+	Store
+	reads atomic.Int64
+}
+
+func (s *countingStore) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	s.reads.Add(1)
+	return s.Store.GetByHash(ctx, keyHash)
+}
+
+func TestInvalidCacheStore(t *testing.T) {
+	cases := map[string]struct {
+		Run           func(t *testing.T, ctx context.Context, store Store, now *time.Time)
+		ExpectedReads int64
+	}{
+		"should read an invalid key once": {
+			Run: func(t *testing.T, ctx context.Context, store Store, now *time.Time) {
+				for idx := 0; idx < 3; idx++ {
+					_, err := store.GetByHash(ctx, "made-up")
+					assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+				}
+			},
+			ExpectedReads: 1,
+		},
+		"should not cache the valid keys": {
+			Run: func(t *testing.T, ctx context.Context, store Store, now *time.Time) {
+				for idx := 0; idx < 2; idx++ {
+					apiKey, err := store.GetByHash(ctx, "valid")
+					assert.NilError(t, err)
+					assert.Equal(t, "ci", apiKey.Owner)
+				}
+			},
+			ExpectedReads: 2,
+		},
+		"should read the key again after the ttl": {
+			Run: func(t *testing.T, ctx context.Context, store Store, now *time.Time) {
+				_, err := store.GetByHash(ctx, "made-up")
+				assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+
+				*now = now.Add(time.Minute)
+
+				_, err = store.GetByHash(ctx, "made-up")
+				assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+			},
+			ExpectedReads: 2,
+		},
+		"should drop the oldest key when it is full": {
+			Run: func(t *testing.T, ctx context.Context, store Store, now *time.Time) {
+				for _, keyHash := range []string{"first", "second", "third", "first"} {
+					_, err := store.GetByHash(ctx, keyHash)
+					assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+				}
+			},
+			ExpectedReads: 4,
+		},
+		"should drop the key saved": {
+			Run: func(t *testing.T, ctx context.Context, store Store, now *time.Time) {
+				_, err := store.GetByHash(ctx, "new")
+				assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+
+				_, err = store.SaveAPIKey(ctx, apiKeyModel.APIKeyDB{Owner: "ci", KeyHash: "new"})
+				assert.NilError(t, err)
+
+				apiKey, err := store.GetByHash(ctx, "new")
+				assert.NilError(t, err)
+				assert.Equal(t, "ci", apiKey.Owner)
+			},
+			ExpectedReads: 2,
+		},
+		"should bypass the cache inside a transaction": {
+			Run: func(t *testing.T, ctx context.Context, store Store, now *time.Time) {
+				err := tx.NewNopManager().WithinTransaction(ctx, func(ctx context.Context) error {
+					for idx := 0; idx < 2; idx++ {
+						_, err := store.GetByHash(ctx, "made-up")
+						assert.ErrorIs(t, err, apiKeyModel.ErrorAPIKeyInvalid)
+					}
+					return nil
+				})
+				assert.NilError(t, err)
+			},
+			ExpectedReads: 2,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			counting := &countingStore{Store: NewMemoryStore()}
+			_, err := counting.SaveAPIKey(ctx, apiKeyModel.APIKeyDB{Owner: "ci", KeyHash: "valid"})
+			assert.NilError(t, err)
+
+			now := time.Now()
+			store := NewInvalidCacheStore(counting, 2, time.Minute).(*invalidCacheStore)
+			store.invalid.now = func() time.Time { return now }
+
+			cs.Run(t, ctx, store, &now)
+
+			assert.Equal(t, cs.ExpectedReads, counting.reads.Load())
+		})
+	}
+}
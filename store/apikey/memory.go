@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	lastID  int64
+	apiKeys map[string]apiKeyModel.APIKeyDB
+}
+
+// NewMemoryStore init an APIKey kept in memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		apiKeys: map[string]apiKeyModel.APIKeyDB{},
+	}
+}
+
+func (a *memoryStore) SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.apiKeys[apiKey.KeyHash]; ok {
+		return nil, apiKeyModel.ErrorAPIKeyAlreadyExists
+	}
+
+	a.lastID++
+	lastId := a.lastID
+	apiKey.ID = lastId
+	apiKey.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	a.apiKeys[apiKey.KeyHash] = apiKey
+
+	return &lastId, nil
+}
+
+func (a *memoryStore) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	apiKey, ok := a.apiKeys[keyHash]
+	if !ok || apiKey.RevokedAt != nil {
+		return nil, apiKeyModel.ErrorAPIKeyInvalid
+	}
+
+	return &apiKey, nil
+}
@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore init an APIKey backed by postgres
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{
+		db: db,
+	}
+}
+
+func (a *postgresStore) SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error) {
+	defer metrics.ObserveQuery("apikey", "SaveAPIKey", time.Now())
+
+	query := "INSERT INTO api_keys(owner, key_hash) VALUES ($1, $2) RETURNING id"
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.apikey.SaveAPIKey", query)
+	defer span.End()
+
+	var lastId int64
+	if err := tx.From(ctx, a.db).QueryRowContext(ctx, query, apiKey.Owner, apiKey.KeyHash).Scan(&lastId); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.apikey.postgres.SaveAPIKey.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *postgresStore) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	defer metrics.ObserveQuery("apikey", "GetByHash", time.Now())
+
+	query := "SELECT id, owner, key_hash, created_at FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL"
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.apikey.GetByHash", query)
+	defer span.End()
+
+	return getByHash(ctx, tx.From(ctx, a.db).QueryRowContext(ctx, query, keyHash), "store.apikey.postgres.GetByHash.Scan")
+}
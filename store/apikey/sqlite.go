@@ -0,0 +1,56 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore init an APIKey backed by a sqlite file
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqliteStore{
+		db: db,
+	}
+}
+
+func (a *sqliteStore) SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error) {
+	defer metrics.ObserveQuery("apikey", "SaveAPIKey", time.Now())
+
+	query := "INSERT INTO api_keys(owner, key_hash) VALUES (?, ?)"
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.apikey.SaveAPIKey", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, apiKey.Owner, apiKey.KeyHash)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.apikey.sqlite.SaveAPIKey.Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.apikey.sqlite.SaveAPIKey.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *sqliteStore) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	defer metrics.ObserveQuery("apikey", "GetByHash", time.Now())
+
+	query := "SELECT id, owner, key_hash, created_at FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL"
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.apikey.GetByHash", query)
+	defer span.End()
+
+	return getByHash(ctx, tx.From(ctx, a.db).QueryRowContext(ctx, query, keyHash), "store.apikey.sqlite.GetByHash.Scan")
+}
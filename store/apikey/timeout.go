@@ -0,0 +1,38 @@
+package apikey
+
+import (
+	"context"
+
+	apiKeyModel "github.com/danilotadeu/products/model/apikey"
+	"github.com/danilotadeu/products/store/timeout"
+)
+
+// timeoutStore bounds every statement of another Store by the read or write timeout
+type timeoutStore struct {
+	store    Store
+	timeouts timeout.Timeouts
+}
+
+// NewTimeoutStore wraps store so the statements are cancelled after the timeouts
+func NewTimeoutStore(store Store, timeouts timeout.Timeouts) Store {
+	return &timeoutStore{
+		store:    store,
+		timeouts: timeouts,
+	}
+}
+
+func (a *timeoutStore) SaveAPIKey(ctx context.Context, apiKey apiKeyModel.APIKeyDB) (*int64, error) {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	id, err := a.store.SaveAPIKey(ctx, apiKey)
+	return id, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetByHash(ctx context.Context, keyHash string) (*apiKeyModel.APIKeyDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	apiKey, err := a.store.GetByHash(ctx, keyHash)
+	return apiKey, timeout.Err(ctx, err)
+}
@@ -0,0 +1,183 @@
+package barcode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+const mysqlDuplicateEntry = 1062
+
+// Store is a contract to the barcodes of the products, a GTIN belongs to one product at most
+//
+//go:generate mockgen -destination ../../mock/store/barcode/barcode_store_mock.go -package mockStoreBarcode . Store
+type Store interface {
+	// Replace swaps the barcodes of the product for barcodes, ErrorBarcodeAlreadyExists when
+	// a GTIN belongs to another product. Callers run it in a transaction
+	Replace(ctx context.Context, productID int64, barcodes []productModel.BarcodeDB) error
+	// ListByProducts loads the barcodes of productIDs in one query, ordered by product and
+	// in the order they were saved
+	ListByProducts(ctx context.Context, productIDs []int64) ([]*productModel.BarcodeDB, error)
+	// GetProductID is the product of the GTIN-14 gtin, ErrorProductNotFound when none
+	GetProductID(ctx context.Context, gtin string) (*int64, error)
+	DeleteByProduct(ctx context.Context, productID int64) error
+}
+
+type storeImpl struct {
+	db *sql.DB
+}
+
+// NewStore init a Barcode
+func NewStore(db *sql.DB) Store {
+	return &storeImpl{
+		db: db,
+	}
+}
+
+func (a *storeImpl) Replace(ctx context.Context, productID int64, barcodes []productModel.BarcodeDB) error {
+	defer metrics.ObserveQuery("barcode", "Replace", time.Now())
+
+	// the span shows the insert run for each barcode
+	statement, _ := insertStatement(productID, productModel.BarcodeDB{}).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.barcode.Replace", statement)
+	defer span.End()
+
+	return mysqlDuplicate(replace(ctx, tx.From(ctx, a.db), "store.barcode.Replace", builder.Question, productID, barcodes))
+}
+
+func (a *storeImpl) ListByProducts(ctx context.Context, productIDs []int64) ([]*productModel.BarcodeDB, error) {
+	defer metrics.ObserveQuery("barcode", "ListByProducts", time.Now())
+
+	statement, args := listStatement(productIDs).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.barcode.ListByProducts", statement)
+	defer span.End()
+
+	return list(ctx, tx.From(ctx, a.db), "store.barcode.ListByProducts", statement, args)
+}
+
+func (a *storeImpl) GetProductID(ctx context.Context, gtin string) (*int64, error) {
+	defer metrics.ObserveQuery("barcode", "GetProductID", time.Now())
+
+	statement, args := selectStatement(gtin).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.barcode.GetProductID", statement)
+	defer span.End()
+
+	return productID(ctx, tx.From(ctx, a.db), "store.barcode.GetProductID", statement, args)
+}
+
+func (a *storeImpl) DeleteByProduct(ctx context.Context, productID int64) error {
+	defer metrics.ObserveQuery("barcode", "DeleteByProduct", time.Now())
+
+	statement, args := deleteByProductStatement(productID).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.barcode.DeleteByProduct", statement)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, statement, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.barcode.DeleteByProduct.Exec"}).WithError(err).Error(err)
+		return err
+	}
+	return nil
+}
+
+// the statements are the same in every dialect but for their placeholders
+
+func insertStatement(productID int64, barcode productModel.BarcodeDB) *builder.InsertQuery {
+	return builder.Insert("product_barcodes").
+		Value("product_id", productID).
+		Value("code", barcode.Code).
+		Value("gtin", barcode.GTIN)
+}
+
+func listStatement(productIDs []int64) *builder.SelectQuery {
+	ids := make([]interface{}, len(productIDs))
+	for idx, id := range productIDs {
+		ids[idx] = id
+	}
+	return builder.Select("product_barcodes", "product_id", "code", "gtin").
+		WhereIn("product_id", ids...).
+		OrderBy("product_id, id")
+}
+
+func selectStatement(gtin string) *builder.SelectQuery {
+	return builder.Select("product_barcodes", "product_id").Where("gtin = ?", gtin)
+}
+
+func deleteByProductStatement(productID int64) *builder.DeleteQuery {
+	return builder.Delete("product_barcodes").Where("product_id = ?", productID)
+}
+
+// replace deletes the barcodes of the product before inserting barcodes, trace names the
+// caller in the logs
+func replace(ctx context.Context, db tx.DBTX, trace string, placeholder builder.Placeholder, productID int64, barcodes []productModel.BarcodeDB) error {
+	statement, args := deleteByProductStatement(productID).Build(placeholder)
+	if _, err := db.ExecContext(ctx, statement, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Exec"}).WithError(err).Error(err)
+		return err
+	}
+
+	for _, barcode := range barcodes {
+		statement, args := insertStatement(productID, barcode).Build(placeholder)
+		if _, err := db.ExecContext(ctx, statement, args...); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Exec_1"}).WithError(err).Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// list scans the barcodes selected by statement
+func list(ctx context.Context, db tx.DBTX, trace, statement string, args []interface{}) ([]*productModel.BarcodeDB, error) {
+	results := []*productModel.BarcodeDB{}
+	if len(args) == 0 {
+		return results, nil
+	}
+
+	res, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Query"}).WithError(err).Error(err)
+		return nil, err
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var barcode productModel.BarcodeDB
+		if err := res.Scan(&barcode.ProductID, &barcode.Code, &barcode.GTIN); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Scan"}).WithError(err).Error(err)
+			return nil, err
+		}
+		results = append(results, &barcode)
+	}
+
+	return results, res.Err()
+}
+
+func productID(ctx context.Context, db tx.DBTX, trace, statement string, args []interface{}) (*int64, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, statement, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, productModel.ErrorProductNotFound
+	}
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+	return &id, nil
+}
+
+// mysqlDuplicate maps the violation of the unique gtin to ErrorBarcodeAlreadyExists
+func mysqlDuplicate(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return productModel.ErrorBarcodeAlreadyExists
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package barcode
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	schema "github.com/danilotadeu/products/db"
+	productModel "github.com/danilotadeu/products/model/product"
+	"gotest.tools/v3/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+		"sqlite": func(t *testing.T) Store {
+			db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "products.db")+"?_foreign_keys=on")
+			assert.NilError(t, err)
+			t.Cleanup(func() { db.Close() })
+			db.SetMaxOpenConns(1)
+
+			migrator, err := schema.NewMigrator(context.Background(), db, schema.SQLite)
+			assert.NilError(t, err)
+			assert.NilError(t, migrator.Up())
+			assert.NilError(t, migrator.Close())
+			return NewSQLiteStore(db)
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			assert.NilError(t, store.Replace(ctx, 2, barcodes("4006381333931", "036000291452")))
+			assert.NilError(t, store.Replace(ctx, 1, barcodes("012345678905")))
+			assert.NilError(t, store.Replace(ctx, 1, barcodes("10012345000017")))
+
+			listed, err := store.ListByProducts(ctx, []int64{2, 1, 3})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []string{"1:10012345000017", "2:4006381333931", "2:036000291452"}, codes(listed))
+
+			id, err := store.GetProductID(ctx, "00036000291452")
+			assert.NilError(t, err)
+			assert.Equal(t, int64(2), *id)
+
+			_, err = store.GetProductID(ctx, "00012345678905")
+			assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+
+			err = store.Replace(ctx, 1, barcodes("0036000291452"))
+			assert.ErrorIs(t, err, productModel.ErrorBarcodeAlreadyExists)
+
+			assert.NilError(t, store.DeleteByProduct(ctx, 2))
+			assert.NilError(t, store.Replace(ctx, 1, barcodes("0036000291452")))
+
+			listed, err = store.ListByProducts(ctx, []int64{1, 2})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []string{"1:0036000291452"}, codes(listed))
+
+			listed, err = store.ListByProducts(ctx, nil)
+			assert.NilError(t, err)
+			assert.Equal(t, 0, len(listed))
+		})
+	}
+}
+
+// barcodes are the valid codes with their GTIN-14
+func barcodes(codes ...string) []productModel.BarcodeDB {
+	result := make([]productModel.BarcodeDB, len(codes))
+	for idx, code := range codes {
+		result[idx] = productModel.BarcodeDB{Code: code, GTIN: "00000000000000"[len(code):] + code}
+	}
+	return result
+}
+
+func codes(barcodes []*productModel.BarcodeDB) []string {
+	result := []string{}
+	for _, barcode := range barcodes {
+		result = append(result, fmt.Sprintf("%d:%s", barcode.ProductID, barcode.Code))
+	}
+	return result
+}
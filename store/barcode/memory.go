@@ -0,0 +1,101 @@
+package barcode
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	productModel "github.com/danilotadeu/products/model/product"
+)
+
+type memoryStore struct {
+	mu sync.Mutex
+	// barcodes are in the order they were saved, as the ids of the tables
+	barcodes []productModel.BarcodeDB
+}
+
+// NewMemoryStore init a Barcode kept in memory
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+func (a *memoryStore) Replace(ctx context.Context, productID int64, barcodes []productModel.BarcodeDB) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, barcode := range barcodes {
+		for _, saved := range a.barcodes {
+			if saved.GTIN == barcode.GTIN && saved.ProductID != productID {
+				return productModel.ErrorBarcodeAlreadyExists
+			}
+		}
+	}
+
+	a.deleteByProduct(productID)
+	for _, barcode := range barcodes {
+		barcode.ProductID = productID
+		a.barcodes = append(a.barcodes, barcode)
+	}
+	return nil
+}
+
+func (a *memoryStore) ListByProducts(ctx context.Context, productIDs []int64) ([]*productModel.BarcodeDB, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	results := []*productModel.BarcodeDB{}
+	for _, id := range sortedIDs(productIDs) {
+		for _, barcode := range a.barcodes {
+			if barcode.ProductID == id {
+				barcode := barcode
+				results = append(results, &barcode)
+			}
+		}
+	}
+	return results, nil
+}
+
+func (a *memoryStore) GetProductID(ctx context.Context, gtin string) (*int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, barcode := range a.barcodes {
+		if barcode.GTIN == gtin {
+			id := barcode.ProductID
+			return &id, nil
+		}
+	}
+	return nil, productModel.ErrorProductNotFound
+}
+
+func (a *memoryStore) DeleteByProduct(ctx context.Context, productID int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.deleteByProduct(productID)
+	return nil
+}
+
+func (a *memoryStore) deleteByProduct(productID int64) {
+	kept := a.barcodes[:0]
+	for _, barcode := range a.barcodes {
+		if barcode.ProductID != productID {
+			kept = append(kept, barcode)
+		}
+	}
+	a.barcodes = kept
+}
+
+// sortedIDs is ids without repetitions in ascending order, as the ORDER BY of the tables
+func sortedIDs(ids []int64) []int64 {
+	seen := map[int64]bool{}
+	sorted := []int64{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			sorted = append(sorted, id)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
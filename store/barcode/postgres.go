@@ -0,0 +1,83 @@
+package barcode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const postgresUniqueViolation = "23505"
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore init a Barcode backed by postgres
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{
+		db: db,
+	}
+}
+
+func (a *postgresStore) Replace(ctx context.Context, productID int64, barcodes []productModel.BarcodeDB) error {
+	defer metrics.ObserveQuery("barcode", "Replace", time.Now())
+
+	// the span shows the insert run for each barcode
+	statement, _ := insertStatement(productID, productModel.BarcodeDB{}).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.barcode.Replace", statement)
+	defer span.End()
+
+	return postgresDuplicate(replace(ctx, tx.From(ctx, a.db), "store.barcode.postgres.Replace", builder.Dollar, productID, barcodes))
+}
+
+func (a *postgresStore) ListByProducts(ctx context.Context, productIDs []int64) ([]*productModel.BarcodeDB, error) {
+	defer metrics.ObserveQuery("barcode", "ListByProducts", time.Now())
+
+	statement, args := listStatement(productIDs).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.barcode.ListByProducts", statement)
+	defer span.End()
+
+	return list(ctx, tx.From(ctx, a.db), "store.barcode.postgres.ListByProducts", statement, args)
+}
+
+func (a *postgresStore) GetProductID(ctx context.Context, gtin string) (*int64, error) {
+	defer metrics.ObserveQuery("barcode", "GetProductID", time.Now())
+
+	statement, args := selectStatement(gtin).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.barcode.GetProductID", statement)
+	defer span.End()
+
+	return productID(ctx, tx.From(ctx, a.db), "store.barcode.postgres.GetProductID", statement, args)
+}
+
+func (a *postgresStore) DeleteByProduct(ctx context.Context, productID int64) error {
+	defer metrics.ObserveQuery("barcode", "DeleteByProduct", time.Now())
+
+	statement, args := deleteByProductStatement(productID).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.barcode.DeleteByProduct", statement)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, statement, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.barcode.postgres.DeleteByProduct.Exec"}).WithError(err).Error(err)
+		return err
+	}
+	return nil
+}
+
+// postgresDuplicate maps the violation of the unique gtin to ErrorBarcodeAlreadyExists
+func postgresDuplicate(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == postgresUniqueViolation {
+		return productModel.ErrorBarcodeAlreadyExists
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package barcode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore init a Barcode backed by a sqlite file
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqliteStore{
+		db: db,
+	}
+}
+
+func (a *sqliteStore) Replace(ctx context.Context, productID int64, barcodes []productModel.BarcodeDB) error {
+	defer metrics.ObserveQuery("barcode", "Replace", time.Now())
+
+	// the span shows the insert run for each barcode
+	statement, _ := insertStatement(productID, productModel.BarcodeDB{}).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.barcode.Replace", statement)
+	defer span.End()
+
+	return sqliteDuplicate(replace(ctx, tx.From(ctx, a.db), "store.barcode.sqlite.Replace", builder.Question, productID, barcodes))
+}
+
+func (a *sqliteStore) ListByProducts(ctx context.Context, productIDs []int64) ([]*productModel.BarcodeDB, error) {
+	defer metrics.ObserveQuery("barcode", "ListByProducts", time.Now())
+
+	statement, args := listStatement(productIDs).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.barcode.ListByProducts", statement)
+	defer span.End()
+
+	return list(ctx, tx.From(ctx, a.db), "store.barcode.sqlite.ListByProducts", statement, args)
+}
+
+func (a *sqliteStore) GetProductID(ctx context.Context, gtin string) (*int64, error) {
+	defer metrics.ObserveQuery("barcode", "GetProductID", time.Now())
+
+	statement, args := selectStatement(gtin).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.barcode.GetProductID", statement)
+	defer span.End()
+
+	return productID(ctx, tx.From(ctx, a.db), "store.barcode.sqlite.GetProductID", statement, args)
+}
+
+func (a *sqliteStore) DeleteByProduct(ctx context.Context, productID int64) error {
+	defer metrics.ObserveQuery("barcode", "DeleteByProduct", time.Now())
+
+	statement, args := deleteByProductStatement(productID).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.barcode.DeleteByProduct", statement)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, statement, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.barcode.sqlite.DeleteByProduct.Exec"}).WithError(err).Error(err)
+		return err
+	}
+	return nil
+}
+
+// sqliteDuplicate maps the violation of the unique gtin to ErrorBarcodeAlreadyExists
+func sqliteDuplicate(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return productModel.ErrorBarcodeAlreadyExists
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package barcode
+
+import (
+	"context"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/timeout"
+)
+
+// timeoutStore bounds every statement of another Store by the read or write timeout
+type timeoutStore struct {
+	store    Store
+	timeouts timeout.Timeouts
+}
+
+// NewTimeoutStore wraps store so the statements are cancelled after the timeouts
+func NewTimeoutStore(store Store, timeouts timeout.Timeouts) Store {
+	return &timeoutStore{
+		store:    store,
+		timeouts: timeouts,
+	}
+}
+
+func (a *timeoutStore) Replace(ctx context.Context, productID int64, barcodes []productModel.BarcodeDB) error {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return timeout.Err(ctx, a.store.Replace(ctx, productID, barcodes))
+}
+
+func (a *timeoutStore) ListByProducts(ctx context.Context, productIDs []int64) ([]*productModel.BarcodeDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	barcodes, err := a.store.ListByProducts(ctx, productIDs)
+	return barcodes, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetProductID(ctx context.Context, gtin string) (*int64, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	id, err := a.store.GetProductID(ctx, gtin)
+	return id, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) DeleteByProduct(ctx context.Context, productID int64) error {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return timeout.Err(ctx, a.store.DeleteByProduct(ctx, productID))
+}
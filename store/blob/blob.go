@@ -0,0 +1,40 @@
+// Package blob keeps the content of the files attached to the products. Storage is
+// implemented by the local filesystem, an S3 compatible bucket only needs another one
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// Filesystem keeps the blobs in a local directory
+	Filesystem = "filesystem"
+)
+
+// ErrNotFound is returned by Get for a key never put or already deleted
+var ErrNotFound = errors.New("blob: not found")
+
+// Storage is a contract to the blobs, the keys are slash separated paths
+type Storage interface {
+	// Put stores the content of r under key, a blob is never visible half written
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob of key, the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob of key, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every blob whose key is under prefix + "/"
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Open init the Storage of kind, path is the directory of the filesystem storage
+func Open(kind, path string) (Storage, error) {
+	switch kind {
+	case Filesystem:
+		return NewFilesystemStorage(path)
+	default:
+		return nil, fmt.Errorf("blob: unsupported storage %q", kind)
+	}
+}
@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestStorage(t *testing.T) {
+	storages := map[string]func(t *testing.T) Storage{
+		"filesystem": func(t *testing.T) Storage {
+			storage, err := NewFilesystemStorage(t.TempDir())
+			assert.NilError(t, err)
+			return storage
+		},
+		"memory": func(t *testing.T) Storage {
+			return NewMemoryStorage()
+		},
+	}
+	for name, newStorage := range storages {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := newStorage(t)
+
+			assert.NilError(t, storage.Put(ctx, "products/1/a", strings.NewReader("first")))
+			assert.NilError(t, storage.Put(ctx, "products/1/a", strings.NewReader("second")))
+			assert.Equal(t, "second", read(t, storage, "products/1/a"))
+
+			assert.NilError(t, storage.Delete(ctx, "products/1/a"))
+			_, err := storage.Get(ctx, "products/1/a")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.NilError(t, storage.Delete(ctx, "products/1/a"))
+
+			for _, key := range []string{"renditions/products/1/a/128.jpeg", "renditions/products/1/a/512.jpeg", "renditions/products/1/ab/128.jpeg"} {
+				assert.NilError(t, storage.Put(ctx, key, strings.NewReader(key)))
+			}
+			assert.NilError(t, storage.DeletePrefix(ctx, "renditions/products/1/a"))
+			_, err = storage.Get(ctx, "renditions/products/1/a/512.jpeg")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.Equal(t, "renditions/products/1/ab/128.jpeg", read(t, storage, "renditions/products/1/ab/128.jpeg"))
+			assert.NilError(t, storage.DeletePrefix(ctx, "renditions/products/1/a"))
+		})
+	}
+}
+
+func TestFilesystemStorageKeys(t *testing.T) {
+	storage, err := NewFilesystemStorage(t.TempDir())
+	assert.NilError(t, err)
+
+	for _, key := range []string{"", "../a", "products/../../a", "/a", "products//a", `products\a`} {
+		assert.ErrorContains(t, storage.Put(context.Background(), key, strings.NewReader("a")), "invalid key", key)
+	}
+}
+
+func read(t *testing.T, storage Storage, key string) string {
+	t.Helper()
+	r, err := storage.Get(context.Background(), key)
+	assert.NilError(t, err)
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	assert.NilError(t, err)
+	return string(content)
+}
@@ -0,0 +1,107 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type filesystemStorage struct {
+	root string
+}
+
+// NewFilesystemStorage init a Storage in the directory root, created when missing
+func NewFilesystemStorage(root string) (Storage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &filesystemStorage{
+		root: root,
+	}, nil
+}
+
+func (s *filesystemStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.blob.filesystem.Put.MkdirAll"}).WithError(err).Error(err)
+		return err
+	}
+
+	// written aside and renamed, so a reader never sees a partial blob
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.blob.filesystem.Put.CreateTemp"}).WithError(err).Error(err)
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.blob.filesystem.Put.Copy"}).WithError(err).Error(err)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.blob.filesystem.Put.Close"}).WithError(err).Error(err)
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+func (s *filesystemStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *filesystemStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.blob.filesystem.Delete.Remove"}).WithError(err).Error(err)
+		return err
+	}
+	return nil
+}
+
+func (s *filesystemStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	name, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(name); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.blob.filesystem.DeletePrefix.RemoveAll"}).WithError(err).Error(err)
+		return err
+	}
+	return nil
+}
+
+// path is the file of key, refusing the keys escaping the root
+func (s *filesystemStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || cleaned != "/"+key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("blob: invalid key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,62 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+type memoryStorage struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+// NewMemoryStorage init a Storage kept in memory, for the memory store and the tests
+func NewMemoryStorage() Storage {
+	return &memoryStorage{
+		blobs: map[string][]byte{},
+	}
+}
+
+func (s *memoryStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = content
+	return nil
+}
+
+func (s *memoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.blobs {
+		if strings.HasPrefix(key, prefix+"/") {
+			delete(s.blobs, key)
+		}
+	}
+	return nil
+}
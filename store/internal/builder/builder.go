@@ -0,0 +1,289 @@
+// Package builder composes the SQL statements of the stores, values are always
+// sent as placeholders and never written into the statement
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Placeholder is the parameter syntax of a driver
+type Placeholder int
+
+const (
+	// Question is the ? of mysql and sqlite
+	Question Placeholder = iota
+	// Dollar is the $1, $2... of postgres
+	Dollar
+)
+
+// clause is a piece of statement written with ? for each of its args
+type clause struct {
+	sql  string
+	args []interface{}
+}
+
+// SelectQuery builds a SELECT with conditional WHERE clauses
+type SelectQuery struct {
+	table   string
+	columns []clause
+	where   []clause
+	orderBy string
+	limit   *clause
+}
+
+// Select starts a SELECT of columns from table
+func Select(table string, columns ...string) *SelectQuery {
+	q := &SelectQuery{table: table}
+	for _, column := range columns {
+		q.columns = append(q.columns, clause{sql: column})
+	}
+	return q
+}
+
+// Column adds an expression to the selected columns, written with ? for each arg
+func (q *SelectQuery) Column(expr string, args ...interface{}) *SelectQuery {
+	q.columns = append(q.columns, clause{sql: expr, args: args})
+	return q
+}
+
+// Where adds a condition joined by AND, written with ? for each arg
+func (q *SelectQuery) Where(cond string, args ...interface{}) *SelectQuery {
+	return q.WhereIf(true, cond, args...)
+}
+
+// WhereIf adds the condition only when ok
+func (q *SelectQuery) WhereIf(ok bool, cond string, args ...interface{}) *SelectQuery {
+	if ok {
+		q.where = append(q.where, clause{sql: cond, args: args})
+	}
+	return q
+}
+
+// WhereIn adds a column IN (...) condition with one placeholder per value, with
+// no values nothing matches
+func (q *SelectQuery) WhereIn(column string, values ...interface{}) *SelectQuery {
+	if len(values) == 0 {
+		return q.Where("1 = 0")
+	}
+	return q.Where(column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")", values...)
+}
+
+// OrderBy sets the ORDER BY expression
+func (q *SelectQuery) OrderBy(expr string) *SelectQuery {
+	q.orderBy = expr
+	return q
+}
+
+// Limit sets the LIMIT and OFFSET, a negative one is 0 as the databases disagree on them
+func (q *SelectQuery) Limit(limit, offset int64) *SelectQuery {
+	if limit < 0 {
+		limit = 0
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	q.limit = &clause{sql: "LIMIT ? OFFSET ?", args: []interface{}{limit, offset}}
+	return q
+}
+
+// Build returns the statement written with placeholder and its args in order
+func (q *SelectQuery) Build(placeholder Placeholder) (string, []interface{}) {
+	s := newStatement(placeholder)
+	s.write("SELECT ")
+	for idx, column := range q.columns {
+		if idx > 0 {
+			s.write(", ")
+		}
+		s.add(column)
+	}
+	s.write(" FROM " + q.table)
+	s.where(q.where)
+	if q.orderBy != "" {
+		s.write(" ORDER BY " + q.orderBy)
+	}
+	if q.limit != nil {
+		s.write(" ")
+		s.add(*q.limit)
+	}
+	return s.build()
+}
+
+// InsertQuery builds an INSERT of a single row
+type InsertQuery struct {
+	table     string
+	columns   []string
+	values    []interface{}
+	returning string
+}
+
+// Insert starts an INSERT into table
+func Insert(table string) *InsertQuery {
+	return &InsertQuery{table: table}
+}
+
+// Value sets column to value
+func (q *InsertQuery) Value(column string, value interface{}) *InsertQuery {
+	q.columns = append(q.columns, column)
+	q.values = append(q.values, value)
+	return q
+}
+
+// Returning adds a RETURNING of column, only postgres supports it
+func (q *InsertQuery) Returning(column string) *InsertQuery {
+	q.returning = column
+	return q
+}
+
+// Build returns the statement written with placeholder and its args in order
+func (q *InsertQuery) Build(placeholder Placeholder) (string, []interface{}) {
+	s := newStatement(placeholder)
+	s.write("INSERT INTO " + q.table + "(" + strings.Join(q.columns, ", ") + ") VALUES (")
+	for idx, value := range q.values {
+		if idx > 0 {
+			s.write(", ")
+		}
+		s.add(clause{sql: "?", args: []interface{}{value}})
+	}
+	s.write(")")
+	if q.returning != "" {
+		s.write(" RETURNING " + q.returning)
+	}
+	return s.build()
+}
+
+// UpdateQuery builds an UPDATE with conditional WHERE clauses
+type UpdateQuery struct {
+	table string
+	set   []clause
+	where []clause
+}
+
+// Update starts an UPDATE of table
+func Update(table string) *UpdateQuery {
+	return &UpdateQuery{table: table}
+}
+
+// Set sets column to value
+func (q *UpdateQuery) Set(column string, value interface{}) *UpdateQuery {
+	q.set = append(q.set, clause{sql: column + " = ?", args: []interface{}{value}})
+	return q
+}
+
+// SetExpr sets column to a SQL expression without args, like NOW()
+func (q *UpdateQuery) SetExpr(column, expr string) *UpdateQuery {
+	q.set = append(q.set, clause{sql: column + " = " + expr})
+	return q
+}
+
+// Where adds a condition joined by AND, written with ? for each arg
+func (q *UpdateQuery) Where(cond string, args ...interface{}) *UpdateQuery {
+	return q.WhereIf(true, cond, args...)
+}
+
+// WhereIf adds the condition only when ok
+func (q *UpdateQuery) WhereIf(ok bool, cond string, args ...interface{}) *UpdateQuery {
+	if ok {
+		q.where = append(q.where, clause{sql: cond, args: args})
+	}
+	return q
+}
+
+// Build returns the statement written with placeholder and its args in order
+func (q *UpdateQuery) Build(placeholder Placeholder) (string, []interface{}) {
+	s := newStatement(placeholder)
+	s.write("UPDATE " + q.table + " SET ")
+	for idx, set := range q.set {
+		if idx > 0 {
+			s.write(", ")
+		}
+		s.add(set)
+	}
+	s.where(q.where)
+	return s.build()
+}
+
+// DeleteQuery builds a DELETE with conditional WHERE clauses
+type DeleteQuery struct {
+	table string
+	where []clause
+}
+
+// Delete starts a DELETE from table
+func Delete(table string) *DeleteQuery {
+	return &DeleteQuery{table: table}
+}
+
+// Where adds a condition joined by AND, written with ? for each arg
+func (q *DeleteQuery) Where(cond string, args ...interface{}) *DeleteQuery {
+	return q.WhereIf(true, cond, args...)
+}
+
+// WhereIf adds the condition only when ok
+func (q *DeleteQuery) WhereIf(ok bool, cond string, args ...interface{}) *DeleteQuery {
+	if ok {
+		q.where = append(q.where, clause{sql: cond, args: args})
+	}
+	return q
+}
+
+// Build returns the statement written with placeholder and its args in order
+func (q *DeleteQuery) Build(placeholder Placeholder) (string, []interface{}) {
+	s := newStatement(placeholder)
+	s.write("DELETE FROM " + q.table)
+	s.where(q.where)
+	return s.build()
+}
+
+// statement accumulates the sql and numbers the placeholders as the clauses are added
+type statement struct {
+	placeholder Placeholder
+	sql         strings.Builder
+	args        []interface{}
+}
+
+func newStatement(placeholder Placeholder) *statement {
+	return &statement{placeholder: placeholder}
+}
+
+func (s *statement) write(sql string) {
+	s.sql.WriteString(sql)
+}
+
+// add writes c replacing each ? by the placeholder of the next arg, a clause whose
+// ? do not match its args is a programming error
+func (s *statement) add(c clause) {
+	if count := strings.Count(c.sql, "?"); count != len(c.args) {
+		panic(fmt.Sprintf("builder: %q has %d placeholders for %d args", c.sql, count, len(c.args)))
+	}
+
+	for _, r := range c.sql {
+		if r != '?' {
+			s.sql.WriteRune(r)
+			continue
+		}
+		s.args = append(s.args, c.args[0])
+		c.args = c.args[1:]
+		if s.placeholder == Dollar {
+			s.sql.WriteString("$" + strconv.Itoa(len(s.args)))
+		} else {
+			s.sql.WriteByte('?')
+		}
+	}
+}
+
+func (s *statement) where(conds []clause) {
+	for idx, cond := range conds {
+		if idx == 0 {
+			s.write(" WHERE ")
+		} else {
+			s.write(" AND ")
+		}
+		s.add(cond)
+	}
+}
+
+func (s *statement) build() (string, []interface{}) {
+	return s.sql.String(), s.args
+}
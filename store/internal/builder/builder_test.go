@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestBuild(t *testing.T) {
+	cases := map[string]struct {
+		Build         func(placeholder Placeholder) (string, []interface{})
+		Placeholder   Placeholder
+		ExpectedQuery string
+		ExpectedArgs  []interface{}
+	}{
+		"should build a select with the conditional clauses": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Select("products", "id", "name").
+					Where("deleted_at IS NULL").
+					WhereIf(true, "name LIKE ?", "%O'Brien%").
+					WhereIf(false, "id = ?", 1).
+					OrderBy("id").
+					Limit(10, 20).
+					Build(placeholder)
+			},
+			Placeholder:   Question,
+			ExpectedQuery: "SELECT id, name FROM products WHERE deleted_at IS NULL AND name LIKE ? ORDER BY id LIMIT ? OFFSET ?",
+			ExpectedArgs:  []interface{}{"%O'Brien%", int64(10), int64(20)},
+		},
+		"should number the placeholders of postgres in order": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Select("products", "COUNT(*)").
+					Column("SUM(quantity < ?)", int64(5)).
+					Where("name = ?", "a").
+					Limit(10, 0).
+					Build(placeholder)
+			},
+			Placeholder:   Dollar,
+			ExpectedQuery: "SELECT COUNT(*), SUM(quantity < $1) FROM products WHERE name = $2 LIMIT $3 OFFSET $4",
+			ExpectedArgs:  []interface{}{int64(5), "a", int64(10), int64(0)},
+		},
+		"should build an in condition": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Select("products", "id").Where("deleted_at IS NULL").WhereIn("id", int64(1), int64(2), int64(3)).Build(placeholder)
+			},
+			Placeholder:   Dollar,
+			ExpectedQuery: "SELECT id FROM products WHERE deleted_at IS NULL AND id IN ($1, $2, $3)",
+			ExpectedArgs:  []interface{}{int64(1), int64(2), int64(3)},
+		},
+		"should match nothing with an empty in": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Select("products", "id").WhereIn("id").Build(placeholder)
+			},
+			Placeholder:   Question,
+			ExpectedQuery: "SELECT id FROM products WHERE 1 = 0",
+		},
+		"should build a select without where": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Select("products", "COUNT(*)").WhereIf(false, "id = ?", 1).Build(placeholder)
+			},
+			Placeholder:   Question,
+			ExpectedQuery: "SELECT COUNT(*) FROM products",
+		},
+		"should build an insert": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Insert("products").Value("name", "'); DROP TABLE products; --").Value("quantity", int64(1)).Build(placeholder)
+			},
+			Placeholder:   Question,
+			ExpectedQuery: "INSERT INTO products(name, quantity) VALUES (?, ?)",
+			ExpectedArgs:  []interface{}{"'); DROP TABLE products; --", int64(1)},
+		},
+		"should build an insert returning the id": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Insert("products").Value("name", "a").Value("quantity", int64(1)).Returning("id").Build(placeholder)
+			},
+			Placeholder:   Dollar,
+			ExpectedQuery: "INSERT INTO products(name, quantity) VALUES ($1, $2) RETURNING id",
+			ExpectedArgs:  []interface{}{"a", int64(1)},
+		},
+		"should build an update": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Update("products").Set("name", "a?").SetExpr("deleted_at", "NOW()").Where("id = ?", int64(7)).Build(placeholder)
+			},
+			Placeholder:   Dollar,
+			ExpectedQuery: "UPDATE products SET name = $1, deleted_at = NOW() WHERE id = $2",
+			ExpectedArgs:  []interface{}{"a?", int64(7)},
+		},
+		"should build a delete": {
+			Build: func(placeholder Placeholder) (string, []interface{}) {
+				return Delete("product_media").Where("product_id = ?", int64(1)).WhereIf(true, "id = ?", int64(2)).Build(placeholder)
+			},
+			Placeholder:   Dollar,
+			ExpectedQuery: "DELETE FROM product_media WHERE product_id = $1 AND id = $2",
+			ExpectedArgs:  []interface{}{int64(1), int64(2)},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			query, args := cs.Build(cs.Placeholder)
+
+			assert.Equal(t, cs.ExpectedQuery, query)
+			assert.DeepEqual(t, cs.ExpectedArgs, args)
+		})
+	}
+}
+
+func TestBuildPanicsOnMissingArgs(t *testing.T) {
+	defer func() {
+		assert.Equal(t, `builder: "id = ? OR id = ?" has 2 placeholders for 1 args`, recover())
+	}()
+
+	Select("products", "id").Where("id = ? OR id = ?", 1).Build(Question)
+	t.Fatal("expected a panic")
+}
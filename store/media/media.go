@@ -0,0 +1,220 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// columns are the media columns in the order query scans them
+var columns = []string{"id", "product_id", "blob_key", "filename", "content_type", "size", "checksum", "created_at"}
+
+// Store is a contract to the media of the products, a media is always looked up
+// through its product
+//
+//go:generate mockgen -destination ../../mock/store/media/media_store_mock.go -package mockStoreMedia . Store
+type Store interface {
+	SaveMedia(ctx context.Context, media mediaModel.MediaDB) (*int64, error)
+	GetByID(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, error)
+	// ListByProduct is ordered by id, a product without media has an empty list
+	ListByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error)
+	Delete(ctx context.Context, productID, id int64) error
+	// DeleteByProduct removes every media of the product, returning them so their blobs
+	// can be deleted as well
+	DeleteByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error)
+}
+
+type storeImpl struct {
+	db *sql.DB
+}
+
+// NewStore init a Media
+func NewStore(db *sql.DB) Store {
+	return &storeImpl{
+		db: db,
+	}
+}
+
+func (a *storeImpl) SaveMedia(ctx context.Context, media mediaModel.MediaDB) (*int64, error) {
+	defer metrics.ObserveQuery("media", "SaveMedia", time.Now())
+
+	statement, args := insertStatement(media).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.media.SaveMedia", statement)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, statement, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.media.SaveMedia.Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.media.SaveMedia.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *storeImpl) GetByID(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "GetByID", time.Now())
+
+	statement, args := selectStatement(productID, id).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.media.GetByID", statement)
+	defer span.End()
+
+	return first(query(ctx, tx.From(ctx, a.db), "store.media.GetByID", statement, args...))
+}
+
+func (a *storeImpl) ListByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "ListByProduct", time.Now())
+
+	statement, args := listStatement(productID).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.media.ListByProduct", statement)
+	defer span.End()
+
+	return query(ctx, tx.From(ctx, a.db), "store.media.ListByProduct", statement, args...)
+}
+
+func (a *storeImpl) Delete(ctx context.Context, productID, id int64) error {
+	defer metrics.ObserveQuery("media", "Delete", time.Now())
+
+	statement, args := deleteStatement(productID, id).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.media.Delete", statement)
+	defer span.End()
+
+	return deleteOne(ctx, tx.From(ctx, a.db), "store.media.Delete", statement, args...)
+}
+
+func (a *storeImpl) DeleteByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "DeleteByProduct", time.Now())
+
+	list, listArgs := listStatement(productID).Build(builder.Question)
+	statement, args := deleteByProductStatement(productID).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.media.DeleteByProduct", statement)
+	defer span.End()
+
+	return deleteAll(ctx, tx.From(ctx, a.db), "store.media.DeleteByProduct", list, listArgs, statement, args)
+}
+
+// the statements are the same in every dialect but for their placeholders
+
+func insertStatement(media mediaModel.MediaDB) *builder.InsertQuery {
+	return builder.Insert("product_media").
+		Value("product_id", media.ProductID).
+		Value("blob_key", media.BlobKey).
+		Value("filename", media.Filename).
+		Value("content_type", media.ContentType).
+		Value("size", media.Size).
+		Value("checksum", media.Checksum)
+}
+
+func selectStatement(productID, id int64) *builder.SelectQuery {
+	return builder.Select("product_media", columns...).
+		Where("product_id = ?", productID).
+		Where("id = ?", id)
+}
+
+func listStatement(productID int64) *builder.SelectQuery {
+	return builder.Select("product_media", columns...).
+		Where("product_id = ?", productID).
+		OrderBy("id")
+}
+
+func deleteStatement(productID, id int64) *builder.DeleteQuery {
+	return builder.Delete("product_media").
+		Where("product_id = ?", productID).
+		Where("id = ?", id)
+}
+
+func deleteByProductStatement(productID int64) *builder.DeleteQuery {
+	return builder.Delete("product_media").Where("product_id = ?", productID)
+}
+
+// query scans the media selected by statement, trace names the caller in the logs
+func query(ctx context.Context, db tx.DBTX, trace, statement string, args ...interface{}) ([]*mediaModel.MediaDB, error) {
+	res, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Query"}).WithError(err).Error(err)
+		return nil, err
+	}
+	defer res.Close()
+
+	results := []*mediaModel.MediaDB{}
+	for res.Next() {
+		var media mediaModel.MediaDB
+		err := res.Scan(
+			&media.ID,
+			&media.ProductID,
+			&media.BlobKey,
+			&media.Filename,
+			&media.ContentType,
+			&media.Size,
+			&media.Checksum,
+			&media.CreatedAt,
+		)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Scan"}).WithError(err).Error(err)
+			return nil, err
+		}
+		results = append(results, &media)
+	}
+
+	return results, res.Err()
+}
+
+// first is the only media of a lookup by id
+func first(medias []*mediaModel.MediaDB, err error) (*mediaModel.MediaDB, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(medias) == 0 {
+		return nil, mediaModel.ErrorMediaNotFound
+	}
+	return medias[0], nil
+}
+
+// deleteOne runs statement, ErrorMediaNotFound when it removes nothing
+func deleteOne(ctx context.Context, db tx.DBTX, trace, statement string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, statement, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Exec"}).WithError(err).Error(err)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".RowsAffected"}).WithError(err).Error(err)
+		return err
+	}
+	if affected == 0 {
+		return mediaModel.ErrorMediaNotFound
+	}
+	return nil
+}
+
+// deleteAll lists the media of the product before deleting them, callers run it in a
+// transaction to not miss a media saved in between
+func deleteAll(ctx context.Context, db tx.DBTX, trace, list string, listArgs []interface{}, statement string, args []interface{}) ([]*mediaModel.MediaDB, error) {
+	medias, err := query(ctx, db, trace, list, listArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(medias) == 0 {
+		return medias, nil
+	}
+
+	if _, err := db.ExecContext(ctx, statement, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": trace + ".Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+	return medias, nil
+}
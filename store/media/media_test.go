@@ -0,0 +1,94 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	schema "github.com/danilotadeu/products/db"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"gotest.tools/v3/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+		"sqlite": func(t *testing.T) Store {
+			db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "products.db")+"?_foreign_keys=on")
+			assert.NilError(t, err)
+			t.Cleanup(func() { db.Close() })
+			db.SetMaxOpenConns(1)
+
+			migrator, err := schema.NewMigrator(context.Background(), db, schema.SQLite)
+			assert.NilError(t, err)
+			assert.NilError(t, migrator.Up())
+			assert.NilError(t, migrator.Close())
+			return NewSQLiteStore(db)
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			first := save(t, ctx, store, 1, "products/1/a")
+			second := save(t, ctx, store, 1, "products/1/b")
+			other := save(t, ctx, store, 2, "products/2/a")
+
+			media, err := store.GetByID(ctx, 1, first)
+			assert.NilError(t, err)
+			assert.Equal(t, "products/1/a", media.BlobKey)
+			assert.Equal(t, "photo.png", media.Filename)
+			assert.Equal(t, int64(4), media.Size)
+			assert.Assert(t, !media.CreatedAt.IsZero())
+
+			_, err = store.GetByID(ctx, 2, first)
+			assert.ErrorIs(t, err, mediaModel.ErrorMediaNotFound)
+			assert.ErrorIs(t, store.Delete(ctx, 2, first), mediaModel.ErrorMediaNotFound)
+
+			medias, err := store.ListByProduct(ctx, 1)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []int64{first, second}, ids(medias))
+
+			assert.NilError(t, store.Delete(ctx, 1, first))
+			deleted, err := store.DeleteByProduct(ctx, 1)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []int64{second}, ids(deleted))
+
+			medias, err = store.ListByProduct(ctx, 1)
+			assert.NilError(t, err)
+			assert.Equal(t, 0, len(medias))
+
+			medias, err = store.ListByProduct(ctx, 2)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []int64{other}, ids(medias))
+		})
+	}
+}
+
+func save(t *testing.T, ctx context.Context, store Store, productID int64, key string) int64 {
+	t.Helper()
+	id, err := store.SaveMedia(ctx, mediaModel.MediaDB{
+		ProductID:   productID,
+		BlobKey:     key,
+		Filename:    "photo.png",
+		ContentType: "image/png",
+		Size:        4,
+		Checksum:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	})
+	assert.NilError(t, err)
+	return *id
+}
+
+func ids(medias []*mediaModel.MediaDB) []int64 {
+	result := []int64{}
+	for _, media := range medias {
+		result = append(result, media.ID)
+	}
+	return result
+}
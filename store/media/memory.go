@@ -0,0 +1,89 @@
+package media
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	mediaModel "github.com/danilotadeu/products/model/media"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	lastID int64
+	medias map[int64]mediaModel.MediaDB
+}
+
+// NewMemoryStore init a Media kept in memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		medias: map[int64]mediaModel.MediaDB{},
+	}
+}
+
+func (a *memoryStore) SaveMedia(ctx context.Context, media mediaModel.MediaDB) (*int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastID++
+	lastId := a.lastID
+	media.ID = lastId
+	media.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	a.medias[lastId] = media
+
+	return &lastId, nil
+}
+
+func (a *memoryStore) GetByID(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	media, ok := a.medias[id]
+	if !ok || media.ProductID != productID {
+		return nil, mediaModel.ErrorMediaNotFound
+	}
+	return &media, nil
+}
+
+func (a *memoryStore) ListByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.listByProduct(productID), nil
+}
+
+func (a *memoryStore) Delete(ctx context.Context, productID, id int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	media, ok := a.medias[id]
+	if !ok || media.ProductID != productID {
+		return mediaModel.ErrorMediaNotFound
+	}
+	delete(a.medias, id)
+	return nil
+}
+
+func (a *memoryStore) DeleteByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	medias := a.listByProduct(productID)
+	for _, media := range medias {
+		delete(a.medias, media.ID)
+	}
+	return medias, nil
+}
+
+func (a *memoryStore) listByProduct(productID int64) []*mediaModel.MediaDB {
+	medias := []*mediaModel.MediaDB{}
+	for _, media := range a.medias {
+		if media.ProductID == productID {
+			media := media
+			medias = append(medias, &media)
+		}
+	}
+	sort.Slice(medias, func(i, j int) bool { return medias[i].ID < medias[j].ID })
+	return medias
+}
@@ -0,0 +1,83 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore init a Media backed by postgres
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{
+		db: db,
+	}
+}
+
+func (a *postgresStore) SaveMedia(ctx context.Context, media mediaModel.MediaDB) (*int64, error) {
+	defer metrics.ObserveQuery("media", "SaveMedia", time.Now())
+
+	statement, args := insertStatement(media).Returning("id").Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.media.SaveMedia", statement)
+	defer span.End()
+
+	var lastId int64
+	err := tx.From(ctx, a.db).QueryRowContext(ctx, statement, args...).Scan(&lastId)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.media.postgres.SaveMedia.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *postgresStore) GetByID(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "GetByID", time.Now())
+
+	statement, args := selectStatement(productID, id).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.media.GetByID", statement)
+	defer span.End()
+
+	return first(query(ctx, tx.From(ctx, a.db), "store.media.postgres.GetByID", statement, args...))
+}
+
+func (a *postgresStore) ListByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "ListByProduct", time.Now())
+
+	statement, args := listStatement(productID).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.media.ListByProduct", statement)
+	defer span.End()
+
+	return query(ctx, tx.From(ctx, a.db), "store.media.postgres.ListByProduct", statement, args...)
+}
+
+func (a *postgresStore) Delete(ctx context.Context, productID, id int64) error {
+	defer metrics.ObserveQuery("media", "Delete", time.Now())
+
+	statement, args := deleteStatement(productID, id).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.media.Delete", statement)
+	defer span.End()
+
+	return deleteOne(ctx, tx.From(ctx, a.db), "store.media.postgres.Delete", statement, args...)
+}
+
+func (a *postgresStore) DeleteByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "DeleteByProduct", time.Now())
+
+	list, listArgs := listStatement(productID).Build(builder.Dollar)
+	statement, args := deleteByProductStatement(productID).Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.media.DeleteByProduct", statement)
+	defer span.End()
+
+	return deleteAll(ctx, tx.From(ctx, a.db), "store.media.postgres.DeleteByProduct", list, listArgs, statement, args)
+}
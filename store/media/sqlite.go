@@ -0,0 +1,88 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore init a Media backed by a sqlite file
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqliteStore{
+		db: db,
+	}
+}
+
+func (a *sqliteStore) SaveMedia(ctx context.Context, media mediaModel.MediaDB) (*int64, error) {
+	defer metrics.ObserveQuery("media", "SaveMedia", time.Now())
+
+	statement, args := insertStatement(media).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.media.SaveMedia", statement)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, statement, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.media.sqlite.SaveMedia.Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.media.sqlite.SaveMedia.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *sqliteStore) GetByID(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "GetByID", time.Now())
+
+	statement, args := selectStatement(productID, id).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.media.GetByID", statement)
+	defer span.End()
+
+	return first(query(ctx, tx.From(ctx, a.db), "store.media.sqlite.GetByID", statement, args...))
+}
+
+func (a *sqliteStore) ListByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "ListByProduct", time.Now())
+
+	statement, args := listStatement(productID).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.media.ListByProduct", statement)
+	defer span.End()
+
+	return query(ctx, tx.From(ctx, a.db), "store.media.sqlite.ListByProduct", statement, args...)
+}
+
+func (a *sqliteStore) Delete(ctx context.Context, productID, id int64) error {
+	defer metrics.ObserveQuery("media", "Delete", time.Now())
+
+	statement, args := deleteStatement(productID, id).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.media.Delete", statement)
+	defer span.End()
+
+	return deleteOne(ctx, tx.From(ctx, a.db), "store.media.sqlite.Delete", statement, args...)
+}
+
+func (a *sqliteStore) DeleteByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	defer metrics.ObserveQuery("media", "DeleteByProduct", time.Now())
+
+	list, listArgs := listStatement(productID).Build(builder.Question)
+	statement, args := deleteByProductStatement(productID).Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.media.DeleteByProduct", statement)
+	defer span.End()
+
+	return deleteAll(ctx, tx.From(ctx, a.db), "store.media.sqlite.DeleteByProduct", list, listArgs, statement, args)
+}
@@ -0,0 +1,61 @@
+package media
+
+import (
+	"context"
+
+	mediaModel "github.com/danilotadeu/products/model/media"
+	"github.com/danilotadeu/products/store/timeout"
+)
+
+// timeoutStore bounds every statement of another Store by the read or write timeout
+type timeoutStore struct {
+	store    Store
+	timeouts timeout.Timeouts
+}
+
+// NewTimeoutStore wraps store so the statements are cancelled after the timeouts
+func NewTimeoutStore(store Store, timeouts timeout.Timeouts) Store {
+	return &timeoutStore{
+		store:    store,
+		timeouts: timeouts,
+	}
+}
+
+func (a *timeoutStore) SaveMedia(ctx context.Context, media mediaModel.MediaDB) (*int64, error) {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	id, err := a.store.SaveMedia(ctx, media)
+	return id, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetByID(ctx context.Context, productID, id int64) (*mediaModel.MediaDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	media, err := a.store.GetByID(ctx, productID, id)
+	return media, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) ListByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	medias, err := a.store.ListByProduct(ctx, productID)
+	return medias, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) Delete(ctx context.Context, productID, id int64) error {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return timeout.Err(ctx, a.store.Delete(ctx, productID, id))
+}
+
+func (a *timeoutStore) DeleteByProduct(ctx context.Context, productID int64) ([]*mediaModel.MediaDB, error) {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	medias, err := a.store.DeleteByProduct(ctx, productID)
+	return medias, timeout.Err(ctx, err)
+}
@@ -0,0 +1,231 @@
+package product
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/timeout"
+	"github.com/danilotadeu/products/store/tx"
+	"golang.org/x/sync/singleflight"
+)
+
+// cachedStore is a read-through cache of GetOneByID and GetAll in front of
+// another Store, the other methods and the reads inside a transaction go straight to it
+type cachedStore struct {
+	Store
+	cache    *lru
+	group    singleflight.Group
+	timeouts timeout.Timeouts
+}
+
+// NewCachedStore wraps store with an LRU of size entries kept for ttl, every
+// write drops the cached entries and concurrent misses of a key share one query.
+// The shared query outlives the caller that started it, so it has its own deadline
+// of readTimeout, zero disables it
+func NewCachedStore(store Store, size int, ttl, readTimeout time.Duration) Store {
+	return &cachedStore{
+		Store:    store,
+		cache:    newLRU(size, ttl),
+		timeouts: timeout.Timeouts{Read: readTimeout},
+	}
+}
+
+func (a *cachedStore) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
+	defer a.invalidate(ctx)
+	return a.Store.SaveProduct(ctx, product)
+}
+
+func (a *cachedStore) Update(ctx context.Context, product productModel.ProductDB) error {
+	defer a.invalidate(ctx)
+	return a.Store.Update(ctx, product)
+}
+
+func (a *cachedStore) Delete(ctx context.Context, id int64) error {
+	defer a.invalidate(ctx)
+	return a.Store.Delete(ctx, id)
+}
+
+func (a *cachedStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	value, err := a.load(ctx, "GetOneByID", fmt.Sprintf("id:%d", id), func(ctx context.Context) (interface{}, error) {
+		return a.Store.GetOneByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	product := *value.(*productModel.ProductDB)
+	return &product, nil
+}
+
+func (a *cachedStore) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	value, err := a.load(ctx, "GetAll", fmt.Sprintf("list:%d:%d:%s", page, limit, name), func(ctx context.Context) (interface{}, error) {
+		return a.Store.GetAll(ctx, page, limit, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copyProducts(value.([]*productModel.ProductDB)), nil
+}
+
+// invalidate drops the entries now and, inside a transaction, once more after the
+// commit since other readers may cache the previous values until then
+func (a *cachedStore) invalidate(ctx context.Context) {
+	a.cache.Purge()
+	tx.AfterCommit(ctx, a.cache.Purge)
+}
+
+// load returns the cached value of key or runs query once for the concurrent misses,
+// the result is cached only if no write happened while the query was running
+func (a *cachedStore) load(ctx context.Context, operation, key string, query func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	// the transaction may see writes not committed yet
+	if tx.InTransaction(ctx) {
+		return query(ctx)
+	}
+
+	if value, ok := a.cache.Get(key); ok {
+		metrics.ObserveCache("product", operation, true)
+		return value, nil
+	}
+	metrics.ObserveCache("product", operation, false)
+
+	generation := a.cache.Generation()
+	result := a.group.DoChan(fmt.Sprintf("%d:%s", generation, key), func() (interface{}, error) {
+		// the other callers still wait for it when the first one goes away
+		shared, cancel := a.timeouts.ForRead(detached{ctx})
+		defer cancel()
+
+		value, err := query(shared)
+		if err != nil {
+			return nil, err
+		}
+		a.cache.Add(key, value, generation)
+		return value, nil
+	})
+
+	select {
+	case res := <-result:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detached keeps the values of the caller, as its span and locale, but not its deadline nor cancellation
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detached) Done() <-chan struct{}               { return nil }
+func (detached) Err() error                          { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+func copyProducts(products []*productModel.ProductDB) []*productModel.ProductDB {
+	if products == nil {
+		return nil
+	}
+
+	result := make([]*productModel.ProductDB, len(products))
+	for idx, product := range products {
+		product := *product
+		result[idx] = &product
+	}
+	return result
+}
+
+// lru keeps the most recently used entries up to size, each one for ttl
+type lru struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	generation uint64
+	entries    map[string]*list.Element
+	order      *list.List
+	now        func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value of key when it is cached and not expired
+func (c *lru) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Add caches value unless a Purge happened since generation was read
+func (c *lru) Add(key string, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Generation changes on every Purge
+func (c *lru) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// Purge drops every entry and the values being loaded
+func (c *lru) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
@@ -0,0 +1,239 @@
+package product
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/tx"
+	"gotest.tools/v3/assert"
+)
+
+// countingStore counts the reads that reach the wrapped store
+type countingStore struct {
+	Store
+	reads   atomic.Int64
+	release chan struct{}
+}
+
+func (s *countingStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	s.reads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Store.GetOneByID(ctx, id)
+}
+
+func (s *countingStore) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	s.reads.Add(1)
+	return s.Store.GetAll(ctx, page, limit, name)
+}
+
+func TestCachedStore(t *testing.T) {
+	cases := map[string]struct {
+		Run           func(t *testing.T, ctx context.Context, store Store, id int64)
+		ExpectedReads int64
+	}{
+		"should read a product once": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				for idx := 0; idx < 3; idx++ {
+					product, err := store.GetOneByID(ctx, id)
+					assert.NilError(t, err)
+					assert.Equal(t, "Product 1", product.Name)
+				}
+			},
+			ExpectedReads: 1,
+		},
+		"should cache each list page": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				for idx := 0; idx < 2; idx++ {
+					_, err := store.GetAll(ctx, 0, 10, "")
+					assert.NilError(t, err)
+					_, err = store.GetAll(ctx, 0, 10, "Product")
+					assert.NilError(t, err)
+				}
+			},
+			ExpectedReads: 2,
+		},
+		"should not cache the errors": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				for idx := 0; idx < 2; idx++ {
+					_, err := store.GetOneByID(ctx, 404)
+					assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+				}
+			},
+			ExpectedReads: 2,
+		},
+		"should drop the entries on update": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				_, err := store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+
+				assert.NilError(t, store.Update(ctx, productModel.ProductDB{ID: id, Name: "Product 2", Quantity: 1}))
+
+				product, err := store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+				assert.Equal(t, "Product 2", product.Name)
+			},
+			ExpectedReads: 2,
+		},
+		"should drop the entries on delete": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				_, err := store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+
+				assert.NilError(t, store.Delete(ctx, id))
+
+				_, err = store.GetOneByID(ctx, id)
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+			},
+			ExpectedReads: 2,
+		},
+		"should drop the list pages on save": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				products, err := store.GetAll(ctx, 0, 10, "")
+				assert.NilError(t, err)
+				assert.Equal(t, 1, len(products))
+
+				_, err = store.SaveProduct(ctx, productModel.ProductDB{Name: "Product 2", Quantity: 1})
+				assert.NilError(t, err)
+
+				products, err = store.GetAll(ctx, 0, 10, "")
+				assert.NilError(t, err)
+				assert.Equal(t, 2, len(products))
+			},
+			ExpectedReads: 2,
+		},
+		"should bypass the cache inside a transaction": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				err := tx.NewNopManager().WithinTransaction(ctx, func(ctx context.Context) error {
+					for idx := 0; idx < 2; idx++ {
+						if _, err := store.GetOneByID(ctx, id); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+				assert.NilError(t, err)
+			},
+			ExpectedReads: 2,
+		},
+		"should not share the cached product with the callers": {
+			Run: func(t *testing.T, ctx context.Context, store Store, id int64) {
+				product, err := store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+				product.Name = "changed"
+
+				product, err = store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+				assert.Equal(t, "Product 1", product.Name)
+			},
+			ExpectedReads: 1,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			counting := &countingStore{Store: NewMemoryStore()}
+			id, err := counting.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+			assert.NilError(t, err)
+
+			cs.Run(t, ctx, NewCachedStore(counting, 10, time.Minute, time.Second), *id)
+
+			assert.Equal(t, cs.ExpectedReads, counting.reads.Load())
+		})
+	}
+}
+
+func TestCachedStoreCollapsesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingStore{Store: NewMemoryStore(), release: make(chan struct{})}
+	id, err := counting.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+	assert.NilError(t, err)
+	store := NewCachedStore(counting, 10, time.Minute, time.Second)
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 10; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			product, err := store.GetOneByID(ctx, *id)
+			assert.Check(t, err)
+			assert.Check(t, product != nil && product.Name == "Product 1")
+		}()
+	}
+
+	// lets the callers pile up behind the first query
+	time.Sleep(50 * time.Millisecond)
+	close(counting.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), counting.reads.Load())
+}
+
+func TestCachedStoreOutlivesTheFirstCaller(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingStore{Store: NewMemoryStore(), release: make(chan struct{})}
+	id, err := counting.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+	assert.NilError(t, err)
+	store := NewCachedStore(counting, 10, time.Minute, time.Second)
+
+	first, cancel := context.WithCancel(ctx)
+	cancelled := make(chan error)
+	go func() {
+		_, err := store.GetOneByID(first, *id)
+		cancelled <- err
+	}()
+	// the second caller joins the query started by the first one
+	time.Sleep(50 * time.Millisecond)
+	waiting := make(chan *productModel.ProductDB)
+	go func() {
+		product, err := store.GetOneByID(ctx, *id)
+		assert.Check(t, err)
+		waiting <- product
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	close(counting.release)
+	product := <-waiting
+	assert.Assert(t, product != nil)
+	assert.Equal(t, "Product 1", product.Name)
+	assert.Equal(t, int64(1), counting.reads.Load())
+}
+
+func TestLRU(t *testing.T) {
+	now := time.Now()
+	cache := newLRU(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Add("a", 1, 0)
+	cache.Add("b", 2, 0)
+	_, ok := cache.Get("a")
+	assert.Assert(t, ok)
+
+	// b is the least recently used
+	cache.Add("c", 3, 0)
+	_, ok = cache.Get("b")
+	assert.Assert(t, !ok)
+	_, ok = cache.Get("a")
+	assert.Assert(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("a")
+	assert.Assert(t, !ok, "should expire after the ttl")
+
+	// a value loaded before a purge is stale
+	generation := cache.Generation()
+	cache.Purge()
+	cache.Add("d", 4, generation)
+	_, ok = cache.Get("d")
+	assert.Assert(t, !ok)
+}
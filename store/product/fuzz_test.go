@@ -0,0 +1,66 @@
+package product_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+
+	schema "github.com/danilotadeu/products/db"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/product"
+	"gotest.tools/v3/assert"
+)
+
+// FuzzNameRoundTrip saves, reads and renames products with arbitrary names, the
+// statements must keep them intact whatever quotes or SQL they carry
+func FuzzNameRoundTrip(f *testing.F) {
+	for _, name := range []string{
+		"O'Brien",
+		`Say "hi"`,
+		"'); DELETE FROM products; --",
+		"' OR '1'='1",
+		`back\slash`,
+		"100% _off_",
+		"? $1 :name",
+		"café ☕",
+	} {
+		f.Add(name)
+	}
+
+	db := open(f, "sqlite3", "file:"+filepath.Join(f.TempDir(), "products.db")+"?_foreign_keys=on", schema.SQLite)
+	db.SetMaxOpenConns(1)
+	store := product.NewSQLiteStore(db)
+
+	f.Fuzz(func(t *testing.T, name string) {
+		// the text columns only hold utf-8
+		if !utf8.ValidString(name) {
+			t.Skip()
+		}
+		ctx := context.Background()
+
+		id, err := store.SaveProduct(ctx, productModel.ProductDB{Name: name, Quantity: 1})
+		assert.NilError(t, err)
+		// the soft delete keeps the unique name, the row is removed so the input can repeat
+		defer db.Exec("DELETE FROM products WHERE id = ?", *id)
+
+		saved, err := store.GetOneByID(ctx, *id)
+		assert.NilError(t, err)
+		assert.Equal(t, name, saved.Name)
+
+		found, err := store.GetOne(ctx, name)
+		assert.NilError(t, err)
+		assert.Assert(t, found != nil)
+		assert.Equal(t, *id, found.ID)
+
+		renamed := name + "'"
+		assert.NilError(t, store.Update(ctx, productModel.ProductDB{ID: *id, Name: renamed, Quantity: 2}))
+		updated, err := store.GetOneByID(ctx, *id)
+		assert.NilError(t, err)
+		assert.Equal(t, renamed, updated.Name)
+
+		total, err := store.GetTotalProducts(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, int64(1), *total)
+	})
+}
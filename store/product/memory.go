@@ -0,0 +1,236 @@
+package product
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	productModel "github.com/danilotadeu/products/model/product"
+)
+
+type memoryStore struct {
+	mu       sync.RWMutex
+	lastID   int64
+	products map[int64]productModel.ProductDB
+}
+
+// NewMemoryStore init a Product kept in memory, with the semantics of the mysql table:
+// soft delete, unique names including the deleted rows, case insensitive LIKE and limit/offset
+func NewMemoryStore() Store {
+	return &memoryStore{
+		products: map[int64]productModel.ProductDB{},
+	}
+}
+
+func (a *memoryStore) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.nameTaken(product.Name, 0) {
+		return nil, productModel.ErrorProductAlreadyExists
+	}
+
+	a.lastID++
+	lastId := a.lastID
+	a.products[lastId] = productModel.ProductDB{
+		ID:        lastId,
+		Name:      product.Name,
+		Quantity:  product.Quantity,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	return &lastId, nil
+}
+
+func (a *memoryStore) Update(ctx context.Context, product productModel.ProductDB) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stored, ok := a.products[product.ID]
+	if !ok {
+		return nil
+	}
+	if a.nameTaken(product.Name, product.ID) {
+		return productModel.ErrorProductAlreadyExists
+	}
+
+	stored.Name = product.Name
+	stored.Quantity = product.Quantity
+	a.products[product.ID] = stored
+
+	return nil
+}
+
+func (a *memoryStore) GetOne(ctx context.Context, name string) (*productModel.ProductDB, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, product := range a.active() {
+		if strings.EqualFold(product.Name, name) {
+			return product, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (a *memoryStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	product, ok := a.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, productModel.ErrorProductNotFound
+	}
+
+	return &product, nil
+}
+
+func (a *memoryStore) GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var results []*productModel.ProductDB
+	for _, product := range a.active() {
+		if wanted[product.ID] {
+			results = append(results, product)
+		}
+	}
+
+	return results, nil
+}
+
+func (a *memoryStore) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var results []*productModel.ProductDB
+	for _, product := range a.active() {
+		if len(name) > 0 && !like(product.Name, "%"+name+"%") {
+			continue
+		}
+		results = append(results, product)
+	}
+
+	// the tables read a negative offset or limit as 0
+	if page < 0 {
+		page = 0
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if page >= int64(len(results)) {
+		return nil, nil
+	}
+	results = results[page:]
+	if limit < int64(len(results)) {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func (a *memoryStore) Delete(ctx context.Context, id int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	product, ok := a.products[id]
+	if !ok {
+		return nil
+	}
+
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	product.DeletedAt = &deletedAt
+	a.products[id] = product
+
+	return nil
+}
+
+func (a *memoryStore) GetTotalProducts(ctx context.Context) (*int64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	total := int64(len(a.active()))
+	return &total, nil
+}
+
+func (a *memoryStore) GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var stats productModel.StockStats
+	for _, product := range a.active() {
+		stats.Total++
+		stats.Units += product.Quantity
+		if product.Quantity < reorderPoint {
+			stats.BelowReorderPoint++
+		}
+	}
+
+	return &stats, nil
+}
+
+// active returns copies of the products not deleted, ordered by id
+func (a *memoryStore) active() []*productModel.ProductDB {
+	results := make([]*productModel.ProductDB, 0, len(a.products))
+	for _, product := range a.products {
+		if product.DeletedAt != nil {
+			continue
+		}
+		product := product
+		results = append(results, &product)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+// nameTaken follows the unique index, which also holds the deleted rows
+func (a *memoryStore) nameTaken(name string, exceptID int64) bool {
+	for id, product := range a.products {
+		if id != exceptID && strings.EqualFold(product.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// like matches value against a LIKE pattern, % is any sequence and _ any single character
+func like(value, pattern string) bool {
+	value, pattern = strings.ToLower(value), strings.ToLower(pattern)
+	v, p := []rune(value), []rune(pattern)
+
+	// backtracks to the last % when a character does not match
+	vi, pi := 0, 0
+	star, mark := -1, 0
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '_' || p[pi] == v[vi]):
+			vi++
+			pi++
+		case pi < len(p) && p[pi] == '%':
+			star, mark = pi, vi
+			pi++
+		case star != -1:
+			pi = star + 1
+			mark++
+			vi = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+
+	return pi == len(p)
+}
@@ -0,0 +1,52 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"gotest.tools/v3/assert"
+)
+
+func TestLike(t *testing.T) {
+	cases := map[string]struct {
+		Value    string
+		Pattern  string
+		Expected bool
+	}{
+		"should match a substring":           {Value: "Pineapple", Pattern: "%apple%", Expected: true},
+		"should ignore the case":             {Value: "Pineapple", Pattern: "%APPLE%", Expected: true},
+		"should match any single character":  {Value: "Apple", Pattern: "A_ple", Expected: true},
+		"should match the whole value":       {Value: "Apple", Pattern: "Appl", Expected: false},
+		"should backtrack after a mismatch":  {Value: "aaab", Pattern: "%ab", Expected: true},
+		"should match an empty value with %": {Value: "", Pattern: "%%", Expected: true},
+		"should not match a missing prefix":  {Value: "Banana", Pattern: "apple%", Expected: false},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, cs.Expected, like(cs.Value, cs.Pattern))
+		})
+	}
+}
+
+func TestMemoryStoreConcurrentSave(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 50; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			_, err := store.SaveProduct(ctx, productModel.ProductDB{Name: fmt.Sprintf("Product %d", idx), Quantity: 1})
+			assert.Check(t, err)
+		}(idx)
+	}
+	wg.Wait()
+
+	total, err := store.GetTotalProducts(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(50), *total)
+}
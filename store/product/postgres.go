@@ -0,0 +1,239 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// unique_violation is returned when the unique index of the name is violated
+const postgresUniqueViolation = "23505"
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore init a Product backed by postgres
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{
+		db: db,
+	}
+}
+
+func (a *postgresStore) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
+	defer metrics.ObserveQuery("product", "SaveProduct", time.Now())
+
+	// the driver has no LastInsertId, the id comes back with the insert
+	query, args := builder.Insert("products").
+		Value("name", product.Name).
+		Value("quantity", product.Quantity).
+		Returning("id").
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.SaveProduct", query)
+	defer span.End()
+
+	var lastId int64
+	if err := tx.From(ctx, a.db).QueryRowContext(ctx, query, args...).Scan(&lastId); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres.SaveProduct.Scan"}).WithError(err).Error(err)
+		return nil, postgresDuplicate(err)
+	}
+
+	return &lastId, nil
+}
+
+func (a *postgresStore) Update(ctx context.Context, product productModel.ProductDB) error {
+	defer metrics.ObserveQuery("product", "Update", time.Now())
+
+	query, args := builder.Update("products").
+		Set("name", product.Name).
+		Set("quantity", product.Quantity).
+		Where("id = ?", product.ID).
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.Update", query)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres.Update.Exec"}).WithError(err).Error(err)
+		return postgresDuplicate(err)
+	}
+
+	return nil
+}
+
+func (a *postgresStore) GetOne(ctx context.Context, name string) (*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetOne", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		Where("LOWER(name) = LOWER(?)", name).
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.GetOne", query)
+	defer span.End()
+
+	products, err := a.query(ctx, "GetOne", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+
+	return products[0], nil
+}
+
+func (a *postgresStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetOneByID", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		Where("id = ?", id).
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.GetOneByID", query)
+	defer span.End()
+
+	products, err := a.query(ctx, "GetOneByID", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, productModel.ErrorProductNotFound
+	}
+
+	return products[0], nil
+}
+
+func (a *postgresStore) GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetByIDs", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		WhereIn("id", int64Args(ids)...).
+		OrderBy("id").
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.GetByIDs", query)
+	defer span.End()
+
+	return a.query(ctx, "GetByIDs", query, args...)
+}
+
+func (a *postgresStore) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetAll", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		WhereIf(len(name) > 0, "name ILIKE ?", "%"+name+"%").
+		OrderBy("id").
+		Limit(limit, page).
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.GetAll", query)
+	defer span.End()
+
+	return a.query(ctx, "GetAll", query, args...)
+}
+
+func (a *postgresStore) Delete(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("product", "Delete", time.Now())
+
+	query, args := builder.Update("products").
+		SetExpr("deleted_at", "NOW()").
+		Where("id = ?", id).
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.Delete", query)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres.Delete.Exec"}).WithError(err).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *postgresStore) GetTotalProducts(ctx context.Context) (*int64, error) {
+	defer metrics.ObserveQuery("product", "GetTotalProducts", time.Now())
+
+	query, args := builder.Select("products", "COUNT(*)").
+		Where("deleted_at IS NULL").
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.GetTotalProducts", query)
+	defer span.End()
+
+	var total int64
+	if err := tx.From(ctx, a.db).QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres.GetTotalProducts.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &total, nil
+}
+
+func (a *postgresStore) GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error) {
+	defer metrics.ObserveQuery("product", "GetStockStats", time.Now())
+
+	query, args := builder.Select("products", "COUNT(*)", "COALESCE(SUM(CAST(quantity AS BIGINT)), 0)").
+		Column("COUNT(*) FILTER (WHERE CAST(quantity AS BIGINT) < ?)", reorderPoint).
+		Where("deleted_at IS NULL").
+		Build(builder.Dollar)
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.product.GetStockStats", query)
+	defer span.End()
+
+	var stats productModel.StockStats
+	err := tx.From(ctx, a.db).QueryRowContext(ctx, query, args...).Scan(
+		&stats.Total,
+		&stats.Units,
+		&stats.BelowReorderPoint,
+	)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres.GetStockStats.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// query runs a select of every product column and scans the rows
+func (a *postgresStore) query(ctx context.Context, method, query string, args ...interface{}) ([]*productModel.ProductDB, error) {
+	res, err := tx.From(ctx, a.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres." + method + ".Query"}).WithError(err).Error(err)
+		return nil, err
+	}
+	defer res.Close()
+
+	var results []*productModel.ProductDB
+	for res.Next() {
+		var Product productModel.ProductDB
+		err := res.Scan(
+			&Product.ID,
+			&Product.Name,
+			&Product.Quantity,
+			&Product.CreatedAt,
+			&Product.DeletedAt,
+		)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.postgres." + method + ".Scan"}).WithError(err).Error(err)
+			return nil, err
+		}
+		results = append(results, &Product)
+	}
+
+	return results, res.Err()
+}
+
+// postgresDuplicate maps the violation of the unique name to ErrorProductAlreadyExists
+func postgresDuplicate(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == postgresUniqueViolation {
+		return productModel.ErrorProductAlreadyExists
+	}
+	return err
+}
@@ -3,13 +3,24 @@ package product
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"time"
 
+	"github.com/danilotadeu/products/metrics"
 	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 )
 
+// ER_DUP_ENTRY is returned when the unique index of the name is violated
+const mysqlDuplicateEntry = 1062
+
+// columns are the product columns in the order the stores scan them
+var columns = []string{"id", "name", "quantity", "created_at", "deleted_at"}
+
 // Store is a contract to Product..
 //
 //go:generate mockgen -destination ../../mock/store/product/product_store_mock.go -package mockStoreProduct . Store
@@ -18,9 +29,12 @@ type Store interface {
 	Update(ctx context.Context, product productModel.ProductDB) error
 	GetOne(ctx context.Context, name string) (*productModel.ProductDB, error)
 	GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error)
+	// GetByIDs returns the products of ids that exist, ordered by id
+	GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error)
 	GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error)
 	Delete(ctx context.Context, id int64) error
 	GetTotalProducts(ctx context.Context) (*int64, error)
+	GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error)
 }
 
 type storeImpl struct {
@@ -35,18 +49,24 @@ func NewStore(db *sql.DB) Store {
 }
 
 func (a *storeImpl) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
-	query := fmt.Sprintf("INSERT INTO products(name, quantity) VALUES ('%s','%d')",
-		product.Name, product.Quantity)
-	res, err := a.db.Exec(query)
+	defer metrics.ObserveQuery("product", "SaveProduct", time.Now())
 
+	query, args := builder.Insert("products").
+		Value("name", product.Name).
+		Value("quantity", product.Quantity).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.SaveProduct", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.SaveProduct.Exec"}).Error(err)
-		return nil, err
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.SaveProduct.Exec"}).WithError(err).Error(err)
+		return nil, mysqlDuplicate(err)
 	}
 
 	lastId, err := res.LastInsertId()
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.SaveProduct.LastInsertId"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.SaveProduct.LastInsertId"}).WithError(err).Error(err)
 		return nil, err
 	}
 
@@ -54,92 +74,196 @@ func (a *storeImpl) SaveProduct(ctx context.Context, product productModel.Produc
 }
 
 func (a *storeImpl) Update(ctx context.Context, product productModel.ProductDB) error {
-	query := fmt.Sprintf("UPDATE products SET name = '%s', quantity = '%d' WHERE id = '%d'", product.Name, product.Quantity, product.ID)
-	res, err := a.db.Exec(query)
+	defer metrics.ObserveQuery("product", "Update", time.Now())
+
+	query, args := builder.Update("products").
+		Set("name", product.Name).
+		Set("quantity", product.Quantity).
+		Where("id = ?", product.ID).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.Update", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.Update.Exec"}).WithError(err).Error(err)
+		return mysqlDuplicate(err)
+	}
+	_, err = res.RowsAffected()
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.Delete.Exec_1"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.Update.RowsAffected"}).WithError(err).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *storeImpl) GetOne(ctx context.Context, name string) (*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetOne", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		Where("name = ?", name).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.GetOne", query)
+	defer span.End()
+
+	products, err := a.query(ctx, "GetOne", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+
+	return products[0], nil
+}
+
+func (a *storeImpl) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetOneByID", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		Where("id = ?", id).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.GetOneByID", query)
+	defer span.End()
+
+	products, err := a.query(ctx, "GetOneByID", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, productModel.ErrorProductNotFound
+	}
+
+	return products[0], nil
+}
+
+func (a *storeImpl) GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetByIDs", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		WhereIn("id", int64Args(ids)...).
+		OrderBy("id").
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.GetByIDs", query)
+	defer span.End()
+
+	return a.query(ctx, "GetByIDs", query, args...)
+}
+
+func (a *storeImpl) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetAll", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		WhereIf(len(name) > 0, "name LIKE ?", "%"+name+"%").
+		OrderBy("id").
+		Limit(limit, page).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.GetAll", query)
+	defer span.End()
+
+	return a.query(ctx, "GetAll", query, args...)
+}
+
+func (a *storeImpl) Delete(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("product", "Delete", time.Now())
+
+	query, args := builder.Update("products").
+		SetExpr("deleted_at", "NOW()").
+		Where("id = ?", id).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.Delete", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.Delete.Exec_1"}).WithError(err).Error(err)
 		return err
 
 	}
 	_, err = res.RowsAffected()
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.Delete.RowsAffected_1"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.Delete.RowsAffected_1"}).WithError(err).Error(err)
 		return err
 	}
 
 	return nil
 }
 
-func (a *storeImpl) GetOne(ctx context.Context, name string) (*productModel.ProductDB, error) {
-	res, err := a.db.Query("SELECT * FROM products WHERE deleted_at IS NULL and name = ?", name)
+func (a *storeImpl) GetTotalProducts(ctx context.Context) (*int64, error) {
+	defer metrics.ObserveQuery("product", "GetTotalProducts", time.Now())
+
+	query, args := builder.Select("products", "COUNT(*)").
+		Where("deleted_at IS NULL").
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.GetTotalProducts", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).QueryContext(ctx, query, args...)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.GetOne.Query"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.getTotalProducts.Query"}).WithError(err).Error(err)
 		return nil, err
 	}
 	defer res.Close()
 
 	if res.Next() {
-		var Product productModel.ProductDB
+		var Product productModel.ProductsTotal
 		err := res.Scan(
-			&Product.ID,
-			&Product.Name,
-			&Product.Quantity,
-			&Product.CreatedAt,
-			&Product.DeletedAt,
+			&Product.Total,
 		)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{"trace": "store.product.GetOne.Scan"}).Error(err)
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.getTotalProducts.Scan"}).WithError(err).Error(err)
 			return nil, err
 		}
 
-		return &Product, nil
+		return &Product.Total, nil
 	} else {
-		return nil, nil
+		return nil, productModel.ErrorProductNotFound
 	}
 }
 
-func (a *storeImpl) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
-	res, err := a.db.Query("SELECT * FROM products WHERE deleted_at IS NULL and id = ?", id)
+func (a *storeImpl) GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error) {
+	defer metrics.ObserveQuery("product", "GetStockStats", time.Now())
+
+	query, args := builder.Select("products", "COUNT(*)", "COALESCE(SUM(CAST(quantity AS SIGNED)), 0)").
+		Column("COALESCE(SUM(CAST(quantity AS SIGNED) < ?), 0)", reorderPoint).
+		Where("deleted_at IS NULL").
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.product.GetStockStats", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).QueryContext(ctx, query, args...)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.GetOneByID.Query"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.GetStockStats.Query"}).WithError(err).Error(err)
 		return nil, err
 	}
 	defer res.Close()
 
+	var stats productModel.StockStats
 	if res.Next() {
-		var Product productModel.ProductDB
 		err := res.Scan(
-			&Product.ID,
-			&Product.Name,
-			&Product.Quantity,
-			&Product.CreatedAt,
-			&Product.DeletedAt,
+			&stats.Total,
+			&stats.Units,
+			&stats.BelowReorderPoint,
 		)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{"trace": "store.product.GetOneByID.Scan"}).Error(err)
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.GetStockStats.Scan"}).WithError(err).Error(err)
 			return nil, err
 		}
-
-		return &Product, nil
-	} else {
-		return nil, productModel.ErrorProductNotFound
 	}
-}
 
-func (a *storeImpl) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
-	query := `SELECT * FROM products WHERE deleted_at IS NULL`
-	params := []interface{}{}
-	if len(name) > 0 {
-		params = append(params, "%"+name+"%")
-		query += ` AND name LIKE ? `
-	}
-
-	query += ` LIMIT ? OFFSET ?`
-	params = append(params, limit, page)
+	return &stats, nil
+}
 
-	res, err := a.db.Query(query, params...)
+// query runs a select of every product column and scans the rows
+func (a *storeImpl) query(ctx context.Context, method, query string, args ...interface{}) ([]*productModel.ProductDB, error) {
+	res, err := tx.From(ctx, a.db).QueryContext(ctx, query, args...)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.GetAll.Query"}).Error(err)
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product." + method + ".Query"}).WithError(err).Error(err)
 		return nil, err
 	}
 	defer res.Close()
@@ -155,52 +279,29 @@ func (a *storeImpl) GetAll(ctx context.Context, page, limit int64, name string)
 			&Product.DeletedAt,
 		)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{"trace": "store.product.GetAll.Scan"}).Error(err)
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product." + method + ".Scan"}).WithError(err).Error(err)
 			return nil, err
 		}
 		results = append(results, &Product)
 	}
 
-	return results, nil
+	return results, res.Err()
 }
 
-func (a *storeImpl) Delete(ctx context.Context, id int64) error {
-	query := fmt.Sprintf("UPDATE products SET deleted_at = '%s' WHERE id = '%d'", time.Now().Format("2006-01-02 15:04:05"), id)
-	res, err := a.db.Exec(query)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.Delete.Exec_1"}).Error(err)
-		return err
-
-	}
-	_, err = res.RowsAffected()
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.Delete.RowsAffected_1"}).Error(err)
-		return err
+// mysqlDuplicate maps the violation of the unique name to ErrorProductAlreadyExists
+func mysqlDuplicate(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return productModel.ErrorProductAlreadyExists
 	}
-
-	return nil
+	return err
 }
 
-func (a *storeImpl) GetTotalProducts(ctx context.Context) (*int64, error) {
-	res, err := a.db.Query("SELECT COUNT(*) FROM products WHERE deleted_at IS NULL")
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"trace": "store.product.getTotalProducts.Query"}).Error(err)
-		return nil, err
-	}
-	defer res.Close()
-
-	if res.Next() {
-		var Product productModel.ProductsTotal
-		err := res.Scan(
-			&Product.Total,
-		)
-		if err != nil {
-			logrus.WithFields(logrus.Fields{"trace": "store.product.getTotalProducts.Scan"}).Error(err)
-			return nil, err
-		}
-
-		return &Product.Total, nil
-	} else {
-		return nil, productModel.ErrorProductNotFound
+// int64Args converts ids to the args of a WhereIn
+func int64Args(ids []int64) []interface{} {
+	args := make([]interface{}, len(ids))
+	for idx, id := range ids {
+		args[idx] = id
 	}
+	return args
 }
@@ -0,0 +1,240 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/internal/builder"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore init a Product backed by a sqlite file
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqliteStore{
+		db: db,
+	}
+}
+
+func (a *sqliteStore) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
+	defer metrics.ObserveQuery("product", "SaveProduct", time.Now())
+
+	query, args := builder.Insert("products").
+		Value("name", product.Name).
+		Value("quantity", product.Quantity).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.SaveProduct", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite.SaveProduct.Exec"}).WithError(err).Error(err)
+		return nil, sqliteDuplicate(err)
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite.SaveProduct.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
+
+func (a *sqliteStore) Update(ctx context.Context, product productModel.ProductDB) error {
+	defer metrics.ObserveQuery("product", "Update", time.Now())
+
+	query, args := builder.Update("products").
+		Set("name", product.Name).
+		Set("quantity", product.Quantity).
+		Where("id = ?", product.ID).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.Update", query)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite.Update.Exec"}).WithError(err).Error(err)
+		return sqliteDuplicate(err)
+	}
+
+	return nil
+}
+
+func (a *sqliteStore) GetOne(ctx context.Context, name string) (*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetOne", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		Where("name = ? COLLATE NOCASE", name).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.GetOne", query)
+	defer span.End()
+
+	products, err := a.query(ctx, "GetOne", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+
+	return products[0], nil
+}
+
+func (a *sqliteStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetOneByID", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		Where("id = ?", id).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.GetOneByID", query)
+	defer span.End()
+
+	products, err := a.query(ctx, "GetOneByID", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, productModel.ErrorProductNotFound
+	}
+
+	return products[0], nil
+}
+
+func (a *sqliteStore) GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetByIDs", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		WhereIn("id", int64Args(ids)...).
+		OrderBy("id").
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.GetByIDs", query)
+	defer span.End()
+
+	return a.query(ctx, "GetByIDs", query, args...)
+}
+
+func (a *sqliteStore) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	defer metrics.ObserveQuery("product", "GetAll", time.Now())
+
+	query, args := builder.Select("products", columns...).
+		Where("deleted_at IS NULL").
+		WhereIf(len(name) > 0, "name LIKE ?", "%"+name+"%").
+		OrderBy("id").
+		Limit(limit, page).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.GetAll", query)
+	defer span.End()
+
+	return a.query(ctx, "GetAll", query, args...)
+}
+
+func (a *sqliteStore) Delete(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("product", "Delete", time.Now())
+
+	query, args := builder.Update("products").
+		Set("deleted_at", time.Now().UTC()).
+		Where("id = ?", id).
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.Delete", query)
+	defer span.End()
+
+	if _, err := tx.From(ctx, a.db).ExecContext(ctx, query, args...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite.Delete.Exec"}).WithError(err).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *sqliteStore) GetTotalProducts(ctx context.Context) (*int64, error) {
+	defer metrics.ObserveQuery("product", "GetTotalProducts", time.Now())
+
+	query, args := builder.Select("products", "COUNT(*)").
+		Where("deleted_at IS NULL").
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.GetTotalProducts", query)
+	defer span.End()
+
+	var total int64
+	if err := tx.From(ctx, a.db).QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite.GetTotalProducts.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &total, nil
+}
+
+func (a *sqliteStore) GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error) {
+	defer metrics.ObserveQuery("product", "GetStockStats", time.Now())
+
+	query, args := builder.Select("products", "COUNT(*)", "COALESCE(SUM(CAST(quantity AS INTEGER)), 0)").
+		Column("COALESCE(SUM(CAST(quantity AS INTEGER) < ?), 0)", reorderPoint).
+		Where("deleted_at IS NULL").
+		Build(builder.Question)
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.product.GetStockStats", query)
+	defer span.End()
+
+	var stats productModel.StockStats
+	err := tx.From(ctx, a.db).QueryRowContext(ctx, query, args...).Scan(
+		&stats.Total,
+		&stats.Units,
+		&stats.BelowReorderPoint,
+	)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite.GetStockStats.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// query runs a select of every product column and scans the rows
+func (a *sqliteStore) query(ctx context.Context, method, query string, args ...interface{}) ([]*productModel.ProductDB, error) {
+	res, err := tx.From(ctx, a.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite." + method + ".Query"}).WithError(err).Error(err)
+		return nil, err
+	}
+	defer res.Close()
+
+	var results []*productModel.ProductDB
+	for res.Next() {
+		var Product productModel.ProductDB
+		err := res.Scan(
+			&Product.ID,
+			&Product.Name,
+			&Product.Quantity,
+			&Product.CreatedAt,
+			&Product.DeletedAt,
+		)
+		if err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.product.sqlite." + method + ".Scan"}).WithError(err).Error(err)
+			return nil, err
+		}
+		results = append(results, &Product)
+	}
+
+	return results, res.Err()
+}
+
+// sqliteDuplicate maps the violation of the unique name to ErrorProductAlreadyExists
+func sqliteDuplicate(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return productModel.ErrorProductAlreadyExists
+	}
+	return err
+}
@@ -0,0 +1,318 @@
+// Package storetest is the conformance suite of product.Store, every
+// implementation must pass it to behave as the mysql table does
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/product"
+	"gotest.tools/v3/assert"
+)
+
+// NewStore builds an empty store, it is called once per case
+type NewStore func(t *testing.T) product.Store
+
+// Run checks the semantics shared by every backend: names are compared ignoring
+// the case, as the collation of mysql does, and the list is ordered by id
+func Run(t *testing.T, newStore NewStore) {
+	cases := map[string]struct {
+		Run func(t *testing.T, ctx context.Context, store product.Store)
+	}{
+		"should save and get a product by id": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+
+				product, err := store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+				assert.Equal(t, id, product.ID)
+				assert.Equal(t, "Product 1", product.Name)
+				assert.Equal(t, int64(10), product.Quantity)
+				assert.Assert(t, !product.CreatedAt.IsZero())
+				assert.Assert(t, product.DeletedAt == nil)
+			},
+		},
+		"should give a new id to each product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				first := save(t, ctx, store, "Product 1", 1)
+				second := save(t, ctx, store, "Product 2", 1)
+
+				assert.Assert(t, second > first)
+			},
+		},
+		"should get a product by name": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+				save(t, ctx, store, "Product 2", 10)
+
+				product, err := store.GetOne(ctx, "Product 1")
+				assert.NilError(t, err)
+				assert.Equal(t, id, product.ID)
+			},
+		},
+		"should return nil when the name does not exist": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				product, err := store.GetOne(ctx, "Product 1")
+				assert.NilError(t, err)
+				assert.Assert(t, product == nil)
+			},
+		},
+		"should throw error not found when the id does not exist": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				_, err := store.GetOneByID(ctx, 404)
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+			},
+		},
+		"should get the existing products of a batch of ids": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				banana := save(t, ctx, store, "Banana", 1)
+				apple := save(t, ctx, store, "Apple", 1)
+				deleted := save(t, ctx, store, "Cherry", 1)
+				assert.NilError(t, store.Delete(ctx, deleted))
+
+				products, err := store.GetByIDs(ctx, []int64{apple, 404, deleted, banana, apple})
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Banana", "Apple"}, names(products))
+
+				products, err = store.GetByIDs(ctx, nil)
+				assert.NilError(t, err)
+				assert.Equal(t, 0, len(products))
+			},
+		},
+		"should update a product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+
+				err := store.Update(ctx, productModel.ProductDB{ID: id, Name: "Product 2", Quantity: 3})
+				assert.NilError(t, err)
+
+				product, err := store.GetOneByID(ctx, id)
+				assert.NilError(t, err)
+				assert.Equal(t, "Product 2", product.Name)
+				assert.Equal(t, int64(3), product.Quantity)
+			},
+		},
+		"should keep the name when updating the same product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+
+				err := store.Update(ctx, productModel.ProductDB{ID: id, Name: "Product 1", Quantity: 3})
+				assert.NilError(t, err)
+			},
+		},
+		"should ignore the update of a missing product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				err := store.Update(ctx, productModel.ProductDB{ID: 404, Name: "Product 1", Quantity: 3})
+				assert.NilError(t, err)
+
+				total := total(t, ctx, store)
+				assert.Equal(t, int64(0), total)
+			},
+		},
+		"should throw error already exists when saving a taken name": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				save(t, ctx, store, "Product 1", 10)
+
+				_, err := store.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+			},
+		},
+		"should get a product by name ignoring the case": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+
+				product, err := store.GetOne(ctx, "PRODUCT 1")
+				assert.NilError(t, err)
+				assert.Equal(t, id, product.ID)
+			},
+		},
+		"should refuse a name differing only in case": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+				other := save(t, ctx, store, "Product 2", 10)
+
+				_, err := store.SaveProduct(ctx, productModel.ProductDB{Name: "product 1", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+				err = store.Update(ctx, productModel.ProductDB{ID: other, Name: "PRODUCT 1", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+				// the product itself may change the case of its name
+				assert.NilError(t, store.Update(ctx, productModel.ProductDB{ID: id, Name: "PRODUCT 1", Quantity: 1}))
+			},
+		},
+		"should throw error already exists when updating to a taken name": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				save(t, ctx, store, "Product 1", 10)
+				id := save(t, ctx, store, "Product 2", 10)
+
+				err := store.Update(ctx, productModel.ProductDB{ID: id, Name: "Product 1", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+			},
+		},
+		"should hide a deleted product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+				save(t, ctx, store, "Product 2", 5)
+
+				assert.NilError(t, store.Delete(ctx, id))
+
+				_, err := store.GetOneByID(ctx, id)
+				assert.ErrorIs(t, err, productModel.ErrorProductNotFound)
+
+				product, err := store.GetOne(ctx, "Product 1")
+				assert.NilError(t, err)
+				assert.Assert(t, product == nil)
+
+				products, err := store.GetAll(ctx, 0, 10, "")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Product 2"}, names(products))
+
+				assert.Equal(t, int64(1), total(t, ctx, store))
+
+				stats, err := store.GetStockStats(ctx, 10)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, productModel.StockStats{Total: 1, Units: 5, BelowReorderPoint: 1}, *stats)
+			},
+		},
+		"should keep the name of a deleted product taken": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				id := save(t, ctx, store, "Product 1", 10)
+				assert.NilError(t, store.Delete(ctx, id))
+
+				_, err := store.SaveProduct(ctx, productModel.ProductDB{Name: "Product 1", Quantity: 1})
+				assert.ErrorIs(t, err, productModel.ErrorProductAlreadyExists)
+			},
+		},
+		"should ignore the delete of a missing product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				assert.NilError(t, store.Delete(ctx, 404))
+			},
+		},
+		"should list every product ordered by id": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				save(t, ctx, store, "Banana", 1)
+				save(t, ctx, store, "Apple", 1)
+				save(t, ctx, store, "Cherry", 1)
+
+				products, err := store.GetAll(ctx, 0, 10, "")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Banana", "Apple", "Cherry"}, names(products))
+			},
+		},
+		"should page with limit and offset": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				for _, name := range []string{"Product 1", "Product 2", "Product 3", "Product 4", "Product 5"} {
+					save(t, ctx, store, name, 1)
+				}
+
+				products, err := store.GetAll(ctx, 0, 2, "")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Product 1", "Product 2"}, names(products))
+
+				products, err = store.GetAll(ctx, 2, 2, "")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Product 3", "Product 4"}, names(products))
+
+				products, err = store.GetAll(ctx, 4, 2, "")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Product 5"}, names(products))
+			},
+		},
+		"should return an empty page after the last product": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				save(t, ctx, store, "Product 1", 1)
+
+				products, err := store.GetAll(ctx, 5, 2, "")
+				assert.NilError(t, err)
+				assert.Equal(t, 0, len(products))
+			},
+		},
+		"should read a negative offset or limit as 0": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				save(t, ctx, store, "Product 1", 1)
+				save(t, ctx, store, "Product 2", 1)
+
+				products, err := store.GetAll(ctx, -1, 1, "")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Product 1"}, names(products))
+
+				products, err = store.GetAll(ctx, 0, -1, "")
+				assert.NilError(t, err)
+				assert.Equal(t, 0, len(products))
+			},
+		},
+		"should filter by a part of the name ignoring the case": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				save(t, ctx, store, "Apple", 1)
+				save(t, ctx, store, "Banana", 1)
+				save(t, ctx, store, "Pineapple", 1)
+
+				products, err := store.GetAll(ctx, 0, 10, "APPLE")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Apple", "Pineapple"}, names(products))
+
+				products, err = store.GetAll(ctx, 1, 10, "apple")
+				assert.NilError(t, err)
+				assert.DeepEqual(t, []string{"Pineapple"}, names(products))
+
+				products, err = store.GetAll(ctx, 0, 10, "cherry")
+				assert.NilError(t, err)
+				assert.Equal(t, 0, len(products))
+			},
+		},
+		"should count the products": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				assert.Equal(t, int64(0), total(t, ctx, store))
+
+				save(t, ctx, store, "Product 1", 1)
+				save(t, ctx, store, "Product 2", 1)
+
+				assert.Equal(t, int64(2), total(t, ctx, store))
+			},
+		},
+		"should sum the stock": {
+			Run: func(t *testing.T, ctx context.Context, store product.Store) {
+				stats, err := store.GetStockStats(ctx, 10)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, productModel.StockStats{}, *stats)
+
+				save(t, ctx, store, "Product 1", 5)
+				save(t, ctx, store, "Product 2", 10)
+				save(t, ctx, store, "Product 3", 20)
+
+				stats, err = store.GetStockStats(ctx, 10)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, productModel.StockStats{Total: 3, Units: 35, BelowReorderPoint: 1}, *stats)
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			cs.Run(t, context.Background(), newStore(t))
+		})
+	}
+}
+
+func save(t *testing.T, ctx context.Context, store product.Store, name string, quantity int64) int64 {
+	t.Helper()
+
+	id, err := store.SaveProduct(ctx, productModel.ProductDB{Name: name, Quantity: quantity})
+	assert.NilError(t, err)
+	return *id
+}
+
+func total(t *testing.T, ctx context.Context, store product.Store) int64 {
+	t.Helper()
+
+	total, err := store.GetTotalProducts(ctx)
+	assert.NilError(t, err)
+	return *total
+}
+
+func names(products []*productModel.ProductDB) []string {
+	result := []string{}
+	for _, product := range products {
+		result = append(result, product.Name)
+	}
+	return result
+}
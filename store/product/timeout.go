@@ -0,0 +1,93 @@
+package product
+
+import (
+	"context"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/timeout"
+)
+
+// timeoutStore bounds every statement of another Store by the read or write timeout
+type timeoutStore struct {
+	store    Store
+	timeouts timeout.Timeouts
+}
+
+// NewTimeoutStore wraps store so the statements are cancelled after the timeouts, the
+// interrupted ones return an error matching context.Canceled or context.DeadlineExceeded
+func NewTimeoutStore(store Store, timeouts timeout.Timeouts) Store {
+	return &timeoutStore{
+		store:    store,
+		timeouts: timeouts,
+	}
+}
+
+func (a *timeoutStore) SaveProduct(ctx context.Context, product productModel.ProductDB) (*int64, error) {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	id, err := a.store.SaveProduct(ctx, product)
+	return id, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) Update(ctx context.Context, product productModel.ProductDB) error {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return timeout.Err(ctx, a.store.Update(ctx, product))
+}
+
+func (a *timeoutStore) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	return timeout.Err(ctx, a.store.Delete(ctx, id))
+}
+
+func (a *timeoutStore) GetOne(ctx context.Context, name string) (*productModel.ProductDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	product, err := a.store.GetOne(ctx, name)
+	return product, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	product, err := a.store.GetOneByID(ctx, id)
+	return product, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetByIDs(ctx context.Context, ids []int64) ([]*productModel.ProductDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	products, err := a.store.GetByIDs(ctx, ids)
+	return products, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetAll(ctx context.Context, page, limit int64, name string) ([]*productModel.ProductDB, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	products, err := a.store.GetAll(ctx, page, limit, name)
+	return products, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetTotalProducts(ctx context.Context) (*int64, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	total, err := a.store.GetTotalProducts(ctx)
+	return total, timeout.Err(ctx, err)
+}
+
+func (a *timeoutStore) GetStockStats(ctx context.Context, reorderPoint int64) (*productModel.StockStats, error) {
+	ctx, cancel := a.timeouts.ForRead(ctx)
+	defer cancel()
+
+	stats, err := a.store.GetStockStats(ctx, reorderPoint)
+	return stats, timeout.Err(ctx, err)
+}
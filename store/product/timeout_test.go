@@ -0,0 +1,105 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	productModel "github.com/danilotadeu/products/model/product"
+	"github.com/danilotadeu/products/store/timeout"
+	"gotest.tools/v3/assert"
+)
+
+// blockingStore holds the statements until ctx is done and fails like the sqlite driver
+type blockingStore struct {
+	Store
+	deadline time.Time
+}
+
+func (s *blockingStore) GetOneByID(ctx context.Context, id int64) (*productModel.ProductDB, error) {
+	s.deadline, _ = ctx.Deadline()
+	<-ctx.Done()
+	return nil, errors.New("interrupted")
+}
+
+func (s *blockingStore) Delete(ctx context.Context, id int64) error {
+	s.deadline, _ = ctx.Deadline()
+	<-ctx.Done()
+	return errors.New("interrupted")
+}
+
+func TestTimeoutStore(t *testing.T) {
+	cases := map[string]struct {
+		Timeouts         timeout.Timeouts
+		Run              func(ctx context.Context, store Store) error
+		Cancel           bool
+		ExpectedErr      error
+		ExpectedDeadline time.Duration
+	}{
+		"should stop a read after the read timeout": {
+			Timeouts: timeout.Timeouts{Read: 10 * time.Millisecond, Write: time.Minute},
+			Run: func(ctx context.Context, store Store) error {
+				_, err := store.GetOneByID(ctx, 1)
+				return err
+			},
+			ExpectedErr:      context.DeadlineExceeded,
+			ExpectedDeadline: 10 * time.Millisecond,
+		},
+		"should stop a write after the write timeout": {
+			Timeouts: timeout.Timeouts{Read: time.Minute, Write: 10 * time.Millisecond},
+			Run: func(ctx context.Context, store Store) error {
+				return store.Delete(ctx, 1)
+			},
+			ExpectedErr:      context.DeadlineExceeded,
+			ExpectedDeadline: 10 * time.Millisecond,
+		},
+		"should report the cancellation of the request": {
+			Timeouts: timeout.Timeouts{Read: time.Minute, Write: time.Minute},
+			Run: func(ctx context.Context, store Store) error {
+				_, err := store.GetOneByID(ctx, 1)
+				return err
+			},
+			Cancel:           true,
+			ExpectedErr:      context.Canceled,
+			ExpectedDeadline: time.Minute,
+		},
+		"should run without deadline when the timeout is zero": {
+			Timeouts: timeout.Timeouts{},
+			Run: func(ctx context.Context, store Store) error {
+				return store.Delete(ctx, 1)
+			},
+			Cancel:      true,
+			ExpectedErr: context.Canceled,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if cs.Cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+
+			blocking := &blockingStore{}
+			start := time.Now()
+			err := cs.Run(ctx, NewTimeoutStore(blocking, cs.Timeouts))
+
+			assert.Assert(t, errors.Is(err, cs.ExpectedErr), "got %v", err)
+			assert.ErrorContains(t, err, "interrupted")
+			if cs.ExpectedDeadline == 0 {
+				assert.Assert(t, blocking.deadline.IsZero())
+				return
+			}
+			assert.Assert(t, blocking.deadline.Sub(start) >= cs.ExpectedDeadline)
+			assert.Assert(t, blocking.deadline.Sub(start) < cs.ExpectedDeadline+time.Second)
+		})
+	}
+}
+
+func TestTimeoutStoreKeepsErrors(t *testing.T) {
+	store := NewTimeoutStore(NewMemoryStore(), timeout.Timeouts{Read: time.Second, Write: time.Second})
+
+	_, err := store.GetOneByID(context.Background(), 1)
+	assert.Equal(t, productModel.ErrorProductNotFound, err)
+}
@@ -3,23 +3,92 @@ package store
 import (
 	"database/sql"
 
+	schema "github.com/danilotadeu/products/db"
+	"github.com/danilotadeu/products/store/apikey"
+	"github.com/danilotadeu/products/store/barcode"
+	"github.com/danilotadeu/products/store/blob"
+	"github.com/danilotadeu/products/store/media"
 	"github.com/danilotadeu/products/store/product"
+	"github.com/danilotadeu/products/store/timeout"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/store/user"
 	"github.com/sirupsen/logrus"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // Container ...
 type Container struct {
 	Product product.Store
+	APIKey  apikey.Store
+	User    user.Store
+	Media   media.Store
+	Barcode barcode.Store
+	// Blob keeps the content of the media, set by WithBlob
+	Blob blob.Storage
+	Tx   tx.Manager
 }
 
-// Register store container
-func Register(db *sql.DB) *Container {
+// NewMemory init a store container kept in memory, without a database
+func NewMemory() *Container {
 	container := &Container{
-		Product: product.NewStore(db),
+		Product: product.NewMemoryStore(),
+		APIKey:  apikey.NewMemoryStore(),
+		User:    user.NewMemoryStore(),
+		Media:   media.NewMemoryStore(),
+		Barcode: barcode.NewMemoryStore(),
+		Blob:    blob.NewMemoryStorage(),
+		Tx:      tx.NewNopManager(),
 	}
 
-	logrus.WithFields(logrus.Fields{"trace": "store"}).Infof("Registered - Store")
+	logrus.WithFields(logrus.Fields{"trace": "store", "driver": "memory"}).Infof("Registered - Store")
 	return container
 }
+
+// Register store container with the implementations of driver
+func Register(db *sql.DB, driver string) *Container {
+	container := &Container{}
+	switch driver {
+	case schema.SQLite:
+		container.Product = product.NewSQLiteStore(db)
+		container.APIKey = apikey.NewSQLiteStore(db)
+		container.User = user.NewSQLiteStore(db)
+		container.Media = media.NewSQLiteStore(db)
+		container.Barcode = barcode.NewSQLiteStore(db)
+	case schema.Postgres:
+		container.Product = product.NewPostgresStore(db)
+		container.APIKey = apikey.NewPostgresStore(db)
+		container.User = user.NewPostgresStore(db)
+		container.Media = media.NewPostgresStore(db)
+		container.Barcode = barcode.NewPostgresStore(db)
+	default:
+		container.Product = product.NewStore(db)
+		container.APIKey = apikey.NewStore(db)
+		container.User = user.NewStore(db)
+		container.Media = media.NewStore(db)
+		container.Barcode = barcode.NewStore(db)
+	}
+
+	container.Tx = tx.NewManager(db)
+
+	logrus.WithFields(logrus.Fields{"trace": "store", "driver": driver}).Infof("Registered - Store")
+	return container
+}
+
+// WithTimeouts bounds every statement of the stores by the read or write timeout
+func (c *Container) WithTimeouts(timeouts timeout.Timeouts) *Container {
+	c.Product = product.NewTimeoutStore(c.Product, timeouts)
+	c.APIKey = apikey.NewTimeoutStore(c.APIKey, timeouts)
+	c.User = user.NewTimeoutStore(c.User, timeouts)
+	c.Media = media.NewTimeoutStore(c.Media, timeouts)
+	c.Barcode = barcode.NewTimeoutStore(c.Barcode, timeouts)
+	return c
+}
+
+// WithBlob keeps the content of the media in storage
+func (c *Container) WithBlob(storage blob.Storage) *Container {
+	c.Blob = storage
+	return c
+}
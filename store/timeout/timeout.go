@@ -0,0 +1,51 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+)
+
+// Timeouts are the default deadlines of the statements by operation type, zero disables it
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// ForRead derives the context of a read statement, a shorter deadline already in ctx is kept
+func (t Timeouts) ForRead(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Read)
+}
+
+// ForWrite derives the context of a write statement, a shorter deadline already in ctx is kept
+func (t Timeouts) ForWrite(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Write)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Err wraps the error of a statement interrupted by ctx in ErrRequestCancelled or ErrQueryTimeout,
+// still matching context.Canceled or context.DeadlineExceeded. The drivers report it as their
+// own error (sqlite "interrupted", postgres "canceling statement due to user request")
+func Err(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	kind := errorsP.ErrQueryTimeout
+	if errors.Is(ctx.Err(), context.Canceled) {
+		kind = errorsP.ErrRequestCancelled
+	}
+	if !errors.Is(err, ctx.Err()) {
+		err = fmt.Errorf("%w: %v", ctx.Err(), err)
+	}
+	return kind.Wrap(err)
+}
@@ -0,0 +1,179 @@
+// Package tx runs several store calls in one *sql.Tx carried by the context
+package tx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/tracing"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MAX_ATTEMPTS runs a transaction again after a deadlock up to this many times
+	MAX_ATTEMPTS = 3
+
+	// retryBackoff is multiplied by the attempt before running it again
+	retryBackoff = 20 * time.Millisecond
+
+	// ER_LOCK_DEADLOCK
+	mysqlDeadlock = 1213
+	// deadlock_detected
+	postgresDeadlock = "40P01"
+)
+
+// DBTX is what the stores need from *sql.DB and *sql.Tx
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Manager runs fn inside a transaction, the stores called with the ctx given to fn
+// join it. fn must be safe to run again: it is retried when the database reports a deadlock
+//
+//go:generate mockgen -destination ../../mock/store/tx/tx_mock.go -package mockStoreTx . Manager
+type Manager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type state struct {
+	tx          *sql.Tx
+	afterCommit []func()
+}
+
+// From returns the transaction of ctx or db when there is none
+func From(ctx context.Context, db *sql.DB) DBTX {
+	if state, ok := ctx.Value(txKey{}).(*state); ok && state.tx != nil {
+		return state.tx
+	}
+	return db
+}
+
+// InTransaction reports whether ctx carries a transaction
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*state)
+	return ok
+}
+
+// AfterCommit runs hook once the transaction of ctx commits, or right away without one
+func AfterCommit(ctx context.Context, hook func()) {
+	if state, ok := ctx.Value(txKey{}).(*state); ok {
+		state.afterCommit = append(state.afterCommit, hook)
+		return
+	}
+	hook()
+}
+
+type manager struct {
+	db *sql.DB
+}
+
+// NewManager init a Manager of db
+func NewManager(db *sql.DB) Manager {
+	return &manager{
+		db: db,
+	}
+}
+
+func (m *manager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// a nested call joins the transaction already running
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, "store.transaction")
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil || attempt == MAX_ATTEMPTS || !isDeadlock(err) {
+			return err
+		}
+
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.tx.WithinTransaction.Deadlock", "attempt": attempt}).Warn(err)
+		select {
+		case <-time.After(time.Duration(attempt) * retryBackoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run commits when fn succeeds and rolls back when it fails or panics
+func (m *manager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	sqlTx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.tx.run.BeginTx"}).WithError(err).Error(err)
+		return err
+	}
+
+	current := &state{tx: sqlTx}
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, current)); err != nil {
+		if rollbackErr := sqlTx.Rollback(); rollbackErr != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.tx.run.Rollback"}).WithError(rollbackErr).Error(rollbackErr)
+		}
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.tx.run.Commit"}).WithError(err).Error(err)
+		return err
+	}
+
+	for _, hook := range current.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+func isDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == postgresDeadlock
+	}
+
+	return false
+}
+
+type nopManager struct{}
+
+// NewNopManager init a Manager for the memory store, fn runs without atomicity
+// but the AfterCommit hooks still run once it succeeds
+func NewNopManager() Manager {
+	return nopManager{}
+}
+
+func (nopManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	current := &state{}
+	if err := fn(context.WithValue(ctx, txKey{}, current)); err != nil {
+		return err
+	}
+
+	for _, hook := range current.afterCommit {
+		hook()
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package tx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"gotest.tools/v3/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestWithinTransaction(t *testing.T) {
+	cases := map[string]struct {
+		Fn                  func(ctx context.Context, db *sql.DB, calls int) error
+		ExpectedErr         bool
+		ExpectedPanic       bool
+		ExpectedRows        int
+		ExpectedCalls       int
+		ExpectedAfterCommit bool
+	}{
+		"should commit when fn succeeds": {
+			Fn: func(ctx context.Context, db *sql.DB, calls int) error {
+				return insert(ctx, db, "a", "b")
+			},
+			ExpectedRows:        2,
+			ExpectedCalls:       1,
+			ExpectedAfterCommit: true,
+		},
+		"should rollback when fn fails": {
+			Fn: func(ctx context.Context, db *sql.DB, calls int) error {
+				if err := insert(ctx, db, "a"); err != nil {
+					return err
+				}
+				return fmt.Errorf("error")
+			},
+			ExpectedErr:   true,
+			ExpectedCalls: 1,
+		},
+		"should rollback when fn panics": {
+			Fn: func(ctx context.Context, db *sql.DB, calls int) error {
+				if err := insert(ctx, db, "a"); err != nil {
+					return err
+				}
+				panic("boom")
+			},
+			ExpectedPanic: true,
+			ExpectedCalls: 1,
+		},
+		"should join the transaction of a nested call": {
+			Fn: func(ctx context.Context, db *sql.DB, calls int) error {
+				if err := insert(ctx, db, "a"); err != nil {
+					return err
+				}
+				err := NewManager(db).WithinTransaction(ctx, func(ctx context.Context) error {
+					return insert(ctx, db, "b")
+				})
+				if err != nil {
+					return err
+				}
+				return fmt.Errorf("error")
+			},
+			ExpectedErr:   true,
+			ExpectedCalls: 1,
+		},
+		"should run again after a deadlock": {
+			Fn: func(ctx context.Context, db *sql.DB, calls int) error {
+				if err := insert(ctx, db, "a"); err != nil {
+					return err
+				}
+				if calls == 1 {
+					return fmt.Errorf("insert: %w", &mysql.MySQLError{Number: mysqlDeadlock, Message: "Deadlock found"})
+				}
+				return nil
+			},
+			ExpectedRows:        1,
+			ExpectedCalls:       2,
+			ExpectedAfterCommit: true,
+		},
+		"should give up after the last attempt": {
+			Fn: func(ctx context.Context, db *sql.DB, calls int) error {
+				return &mysql.MySQLError{Number: mysqlDeadlock, Message: "Deadlock found"}
+			},
+			ExpectedErr:   true,
+			ExpectedCalls: MAX_ATTEMPTS,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			db := openDB(t)
+
+			calls := 0
+			afterCommit := false
+			run := func() error {
+				return NewManager(db).WithinTransaction(ctx, func(ctx context.Context) error {
+					calls++
+					AfterCommit(ctx, func() { afterCommit = true })
+					return cs.Fn(ctx, db, calls)
+				})
+			}
+
+			if cs.ExpectedPanic {
+				assert.Assert(t, panics(run))
+			} else {
+				err := run()
+				assert.Equal(t, cs.ExpectedErr, err != nil)
+			}
+
+			var rows int
+			assert.NilError(t, db.QueryRow("SELECT COUNT(*) FROM items").Scan(&rows))
+			assert.Equal(t, cs.ExpectedRows, rows)
+			assert.Equal(t, cs.ExpectedCalls, calls)
+			assert.Equal(t, cs.ExpectedAfterCommit, afterCommit)
+		})
+	}
+}
+
+func TestAfterCommitWithoutTransaction(t *testing.T) {
+	called := false
+	AfterCommit(context.Background(), func() { called = true })
+	assert.Assert(t, called)
+}
+
+// openDB has a single connection, a query outside the transaction would block
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "tx.db"))
+	assert.NilError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec("CREATE TABLE items (name VARCHAR(45) NOT NULL)")
+	assert.NilError(t, err)
+	return db
+}
+
+func insert(ctx context.Context, db *sql.DB, names ...string) error {
+	for _, name := range names {
+		if _, err := From(ctx, db).ExecContext(ctx, "INSERT INTO items(name) VALUES (?)", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func panics(fn func() error) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return false
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	userModel "github.com/danilotadeu/products/model/user"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	lastID int64
+	users  map[string]userModel.UserDB
+}
+
+// NewMemoryStore init an User kept in memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		users: map[string]userModel.UserDB{},
+	}
+}
+
+func (a *memoryStore) SaveUser(ctx context.Context, user userModel.UserDB) (*int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.users[user.Name]; ok {
+		return nil, userModel.ErrorUserAlreadyExists
+	}
+
+	a.lastID++
+	lastId := a.lastID
+	user.ID = lastId
+	user.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	a.users[user.Name] = user
+
+	return &lastId, nil
+}
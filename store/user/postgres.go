@@ -0,0 +1,48 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	userModel "github.com/danilotadeu/products/model/user"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const postgresUniqueViolation = "23505"
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore init an User backed by postgres
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{
+		db: db,
+	}
+}
+
+func (a *postgresStore) SaveUser(ctx context.Context, user userModel.UserDB) (*int64, error) {
+	defer metrics.ObserveQuery("user", "SaveUser", time.Now())
+
+	query := "INSERT INTO users(name) VALUES ($1) RETURNING id"
+	ctx, span := tracing.StartQuery(ctx, "postgresql", "store.user.SaveUser", query)
+	defer span.End()
+
+	var lastId int64
+	if err := tx.From(ctx, a.db).QueryRowContext(ctx, query, user.Name).Scan(&lastId); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == postgresUniqueViolation {
+			return nil, userModel.ErrorUserAlreadyExists
+		}
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.user.postgres.SaveUser.Scan"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
@@ -0,0 +1,52 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	userModel "github.com/danilotadeu/products/model/user"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore init an User backed by a sqlite file
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqliteStore{
+		db: db,
+	}
+}
+
+func (a *sqliteStore) SaveUser(ctx context.Context, user userModel.UserDB) (*int64, error) {
+	defer metrics.ObserveQuery("user", "SaveUser", time.Now())
+
+	query := "INSERT INTO users(name) VALUES (?)"
+	ctx, span := tracing.StartQuery(ctx, "sqlite", "store.user.SaveUser", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, user.Name)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return nil, userModel.ErrorUserAlreadyExists
+		}
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.user.sqlite.SaveUser.Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.user.sqlite.SaveUser.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
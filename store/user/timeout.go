@@ -0,0 +1,30 @@
+package user
+
+import (
+	"context"
+
+	userModel "github.com/danilotadeu/products/model/user"
+	"github.com/danilotadeu/products/store/timeout"
+)
+
+// timeoutStore bounds every statement of another Store by the write timeout
+type timeoutStore struct {
+	store    Store
+	timeouts timeout.Timeouts
+}
+
+// NewTimeoutStore wraps store so the statements are cancelled after the timeouts
+func NewTimeoutStore(store Store, timeouts timeout.Timeouts) Store {
+	return &timeoutStore{
+		store:    store,
+		timeouts: timeouts,
+	}
+}
+
+func (a *timeoutStore) SaveUser(ctx context.Context, user userModel.UserDB) (*int64, error) {
+	ctx, cancel := a.timeouts.ForWrite(ctx)
+	defer cancel()
+
+	id, err := a.store.SaveUser(ctx, user)
+	return id, timeout.Err(ctx, err)
+}
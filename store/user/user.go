@@ -0,0 +1,62 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danilotadeu/products/metrics"
+	userModel "github.com/danilotadeu/products/model/user"
+	"github.com/danilotadeu/products/store/tx"
+	"github.com/danilotadeu/products/tracing"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+const mysqlDuplicateEntry = 1062
+
+// Store is a contract to the users, the owners of the api keys
+//
+//go:generate mockgen -destination ../../mock/store/user/user_store_mock.go -package mockStoreUser . Store
+type Store interface {
+	// SaveUser returns ErrorUserAlreadyExists when the name is taken
+	SaveUser(ctx context.Context, user userModel.UserDB) (*int64, error)
+}
+
+type storeImpl struct {
+	db *sql.DB
+}
+
+// NewStore init an User
+func NewStore(db *sql.DB) Store {
+	return &storeImpl{
+		db: db,
+	}
+}
+
+func (a *storeImpl) SaveUser(ctx context.Context, user userModel.UserDB) (*int64, error) {
+	defer metrics.ObserveQuery("user", "SaveUser", time.Now())
+
+	query := "INSERT INTO users(name) VALUES (?)"
+	ctx, span := tracing.StartQuery(ctx, "mysql", "store.user.SaveUser", query)
+	defer span.End()
+
+	res, err := tx.From(ctx, a.db).ExecContext(ctx, query, user.Name)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, userModel.ErrorUserAlreadyExists
+		}
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.user.SaveUser.Exec"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{"trace": "store.user.SaveUser.LastInsertId"}).WithError(err).Error(err)
+		return nil, err
+	}
+
+	return &lastId, nil
+}
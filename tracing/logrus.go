@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"errors"
+	"net/http"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// logrusHook adds the trace and span ids to the entries logged with logrus.WithContext
+// and marks the span as failed when an error answered with a 5xx is logged
+type logrusHook struct{}
+
+// NewLogrusHook init the hook to correlate logs and traces
+func NewLogrusHook() logrus.Hook {
+	return &logrusHook{}
+}
+
+func (h *logrusHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logrusHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	span := trace.SpanFromContext(entry.Context)
+	spanContext := span.SpanContext()
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+
+	if entry.Level <= logrus.ErrorLevel {
+		err, ok := entry.Data[logrus.ErrorKey].(error)
+		if !ok {
+			err = errors.New(entry.Message)
+		}
+		span.RecordError(err)
+		// the errors of the clients, as a product not found, are logged but are no failure
+		if errorsP.From(err).Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, entry.Message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gotest.tools/v3/assert"
+)
+
+const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func TestMiddleware(t *testing.T) {
+	cases := map[string]struct {
+		Traceparent        string
+		ExpectedSameTrace  bool
+		ExpectedStatusCode int
+	}{
+		"should continue the incoming trace": {
+			Traceparent:        "00-" + traceID + "-00f067aa0ba902b7-01",
+			ExpectedSameTrace:  true,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		"should start a new trace without traceparent": {
+			ExpectedSameTrace:  false,
+			ExpectedStatusCode: http.StatusOK,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+
+			logger, hook := test.NewNullLogger()
+			logger.AddHook(NewLogrusHook())
+
+			app := fiber.New()
+			app.Use(Middleware())
+			app.Get("/products/:id", func(c *fiber.Ctx) error {
+				ctx, span := Start(c.UserContext(), "app.product.GetOneByID")
+				defer span.End()
+				logger.WithContext(ctx).Info("inside")
+				return c.SendStatus(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+			if cs.Traceparent != "" {
+				req.Header.Set("traceparent", cs.Traceparent)
+			}
+			resp, err := app.Test(req, -1)
+			assert.NilError(t, err)
+			assert.Equal(t, cs.ExpectedStatusCode, resp.StatusCode)
+
+			spans := recorder.Ended()
+			assert.Equal(t, 2, len(spans))
+			server, child := spans[1], spans[0]
+			assert.Equal(t, "GET /products/:id", server.Name())
+			assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+			assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+			assert.Equal(t, cs.ExpectedSameTrace, server.SpanContext().TraceID().String() == traceID)
+
+			entry := hook.LastEntry()
+			assert.Equal(t, child.SpanContext().TraceID().String(), entry.Data["trace_id"])
+			assert.Equal(t, child.SpanContext().SpanID().String(), entry.Data["span_id"])
+		})
+	}
+}
+
+func TestMiddlewareStatus(t *testing.T) {
+	cases := map[string]struct {
+		Err            error
+		ExpectedStatus int
+		ExpectedCode   codes.Code
+	}{
+		"should keep the span of an error of the client": {
+			Err:            errorsP.ErrProductNotFound,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedCode:   codes.Unset,
+		},
+		"should keep the span of a rate limited request": {
+			Err:            errorsP.ErrRateLimited,
+			ExpectedStatus: http.StatusTooManyRequests,
+			ExpectedCode:   codes.Unset,
+		},
+		"should mark the span of an unknown error": {
+			Err:            errors.New("connection refused"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedCode:   codes.Error,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+			app := fiber.New()
+			app.Use(Middleware())
+			app.Get("/products/:id", func(c *fiber.Ctx) error {
+				return cs.Err
+			})
+
+			_, err := app.Test(httptest.NewRequest(http.MethodGet, "/products/1", nil), -1)
+			assert.NilError(t, err)
+
+			spans := recorder.Ended()
+			assert.Equal(t, 1, len(spans))
+			assert.Equal(t, cs.ExpectedCode, spans[0].Status().Code)
+			for _, attr := range spans[0].Attributes() {
+				if attr.Key == "http.status_code" {
+					assert.Equal(t, int64(cs.ExpectedStatus), attr.Value.AsInt64())
+				}
+			}
+		})
+	}
+}
+
+func TestLogrusHookStatus(t *testing.T) {
+	cases := map[string]struct {
+		Err          error
+		ExpectedCode codes.Code
+	}{
+		"should keep the span when a not found is logged": {
+			Err:          fmt.Errorf("loading: %w", errorsP.ErrProductNotFound),
+			ExpectedCode: codes.Unset,
+		},
+		"should mark the span when an internal error is logged": {
+			Err:          errors.New("connection refused"),
+			ExpectedCode: codes.Error,
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			logger, _ := test.NewNullLogger()
+			logger.AddHook(NewLogrusHook())
+
+			ctx, span := Start(context.Background(), "app.product.GetOneByID")
+			logger.WithContext(ctx).WithError(cs.Err).Error(cs.Err)
+			span.End()
+
+			spans := recorder.Ended()
+			assert.Equal(t, 1, len(spans))
+			assert.Equal(t, cs.ExpectedCode, spans[0].Status().Code)
+			assert.Equal(t, 1, len(spans[0].Events()))
+		})
+	}
+}
+
+func TestLogrusHookWithoutSpan(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.AddHook(NewLogrusHook())
+
+	logger.WithContext(context.Background()).Error("without span")
+
+	_, ok := hook.LastEntry().Data["trace_id"]
+	assert.Equal(t, false, ok)
+}
+
+func TestInit(t *testing.T) {
+	cases := map[string]struct {
+		Exporter    string
+		ExpectedErr bool
+	}{
+		"should accept the stdout exporter": {Exporter: ExporterStdout},
+		"should accept the none exporter":   {Exporter: ExporterNone},
+		"should accept an empty exporter":   {Exporter: ""},
+		"should reject unknown exporters":   {Exporter: "xpto", ExpectedErr: true},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			shutdown, err := Init(cs.Exporter)
+			if cs.ExpectedErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.NilError(t, shutdown(context.Background()))
+		})
+	}
+}
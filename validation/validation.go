@@ -0,0 +1,134 @@
+// Package validation builds the validator of the models, with the custom rules, and turns
+// its errors into the invalid fields answered to the clients
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/danilotadeu/products/barcode"
+	"github.com/danilotadeu/products/i18n"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// RuleTrimmed rejects a string starting or ending with whitespace
+	RuleTrimmed = "trimmed"
+	// RuleProductName bounds a product name to the VARCHAR(45) of products.name
+	RuleProductName = "productname"
+	// RuleBarcode accepts a UPC-A, EAN-13 or GTIN-14 with its check digit
+	RuleBarcode = "barcode"
+
+	// ProductNameMaxLength is the length of the products.name column
+	ProductNameMaxLength = 45
+)
+
+// New returns a validator with the custom rules, reporting the fields by their json name
+func New() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(jsonName)
+	validate.RegisterValidation(RuleTrimmed, trimmed)
+	validate.RegisterAlias(RuleProductName, fmt.Sprintf("max=%d", ProductNameMaxLength))
+	validate.RegisterValidation(RuleBarcode, validBarcode)
+	return validate
+}
+
+// Error turns the failures of validator.Struct into ErrValidation listing every invalid
+// field in the locale of ctx, any other error is returned as is
+func Error(ctx context.Context, err error) error {
+	fields := Fields(ctx, err)
+	if fields == nil {
+		return err
+	}
+	return errorsP.ErrValidation.WithDetail("detail.invalid_fields", len(fields)).WithFields(fields)
+}
+
+// Fields lists the invalid fields of the failures of validator.Struct, explained in the
+// locale of ctx, nil for other errors
+func Fields(ctx context.Context, err error) []errorsP.FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fields := make([]errorsP.FieldError, len(validationErrs))
+	for idx, fieldErr := range validationErrs {
+		field := fieldName(fieldErr)
+		fields[idx] = errorsP.FieldError{
+			Field:   field,
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: message(ctx, field, fieldErr),
+		}
+	}
+	return fields
+}
+
+// Message joins the messages of the invalid fields of err, or is err.Error() for other errors
+func Message(ctx context.Context, err error) string {
+	fields := Fields(ctx, err)
+	if fields == nil {
+		return err.Error()
+	}
+
+	messages := make([]string, len(fields))
+	for idx, field := range fields {
+		messages[idx] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// message explains the rule broken by fieldErr, the rules measuring a string have their
+// own ".string" message and the ones counting the items of a list their ".list" message
+func message(ctx context.Context, field string, fieldErr validator.FieldError) string {
+	key := "validation." + fieldErr.Tag()
+	param := fieldErr.Param()
+	switch fieldErr.Tag() {
+	case "max", "min":
+		switch fieldErr.Kind() {
+		case reflect.String:
+			key += ".string"
+		case reflect.Slice:
+			key += ".list"
+		}
+	case "required", "gte", "gt", RuleTrimmed, RuleProductName, RuleBarcode:
+	default:
+		return i18n.T(ctx, "validation.default", field, fieldErr.Tag())
+	}
+	if param == "" {
+		return i18n.T(ctx, key, field)
+	}
+	return i18n.T(ctx, key, field, param)
+}
+
+// fieldName is the path of the field from the root of the body, without the struct name
+func fieldName(fieldErr validator.FieldError) string {
+	if _, path, ok := strings.Cut(fieldErr.Namespace(), "."); ok {
+		return path
+	}
+	return fieldErr.Field()
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+func trimmed(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	return strings.TrimSpace(value) == value
+}
+
+func validBarcode(fl validator.FieldLevel) bool {
+	return barcode.Valid(fl.Field().String())
+}
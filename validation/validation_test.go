@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danilotadeu/products/i18n"
+	errorsP "github.com/danilotadeu/products/model/errors_handler"
+	productModel "github.com/danilotadeu/products/model/product"
+	"gotest.tools/v3/assert"
+)
+
+func TestFields(t *testing.T) {
+	cases := map[string]struct {
+		Locale         string
+		Input          productModel.ProductDB
+		ExpectedFields []errorsP.FieldError
+	}{
+		"should accept a valid product": {
+			Input: productModel.ProductDB{Name: "Product 1", Quantity: 0},
+		},
+		"should require the name": {
+			Input: productModel.ProductDB{Quantity: 1},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "name", Rule: "required", Message: "name é obrigatório"},
+			},
+		},
+		"should reject the whitespace around the name": {
+			Input: productModel.ProductDB{Name: "Product 1 ", Quantity: 1},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "name", Rule: RuleTrimmed, Message: "name não pode começar nem terminar com espaços"},
+			},
+		},
+		"should bound the name to the column length": {
+			Input: productModel.ProductDB{Name: strings.Repeat("ç", ProductNameMaxLength+1), Quantity: 1},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "name", Rule: RuleProductName, Param: "45", Message: "name deve ter no máximo 45 caracteres"},
+			},
+		},
+		"should accept a name as long as the column": {
+			Input: productModel.ProductDB{Name: strings.Repeat("ç", ProductNameMaxLength), Quantity: 1},
+		},
+		"should explain the fields in the locale": {
+			Locale: i18n.English,
+			Input:  productModel.ProductDB{Name: strings.Repeat("ç", ProductNameMaxLength+1), Quantity: -1},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "name", Rule: RuleProductName, Param: "45", Message: "name must have at most 45 characters"},
+				{Field: "quantity", Rule: "gte", Param: "0", Message: "quantity must be greater than or equal to 0"},
+			},
+		},
+		"should accept the barcodes": {
+			Input: productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: []string{"036000291452", "4006381333931", "10012345000017"}},
+		},
+		"should reject a barcode with a wrong check digit": {
+			Input: productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: []string{"036000291452", "036000291453"}},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "barcodes[1]", Rule: RuleBarcode, Message: "barcodes[1] não é um UPC-A, EAN-13 ou GTIN-14 válido"},
+			},
+		},
+		"should bound the number of barcodes": {
+			Input: productModel.ProductDB{Name: "Product 1", Quantity: 1, Barcodes: make([]string, 11)},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "barcodes", Rule: "max", Param: "10", Message: "barcodes deve ter no máximo 10 itens"},
+			},
+		},
+		"should list every invalid field": {
+			Input: productModel.ProductDB{Quantity: -1},
+			ExpectedFields: []errorsP.FieldError{
+				{Field: "name", Rule: "required", Message: "name é obrigatório"},
+				{Field: "quantity", Rule: "gte", Param: "0", Message: "quantity deve ser maior ou igual a 0"},
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if cs.Locale != "" {
+				ctx = i18n.WithLocale(ctx, cs.Locale)
+			}
+			err := New().Struct(cs.Input)
+			assert.DeepEqual(t, cs.ExpectedFields, Fields(ctx, err))
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	ctx := context.Background()
+	err := Error(ctx, New().Struct(productModel.ProductDB{Quantity: -1}))
+	assert.ErrorIs(t, err, errorsP.ErrValidation)
+
+	var validationErr *errorsP.Error
+	assert.Assert(t, errors.As(err, &validationErr))
+	assert.Equal(t, 2, len(validationErr.Fields))
+
+	other := errors.New("other")
+	assert.Equal(t, other, Error(ctx, other))
+}
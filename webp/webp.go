@@ -0,0 +1,386 @@
+// Package webp encodes lossless WebP (VP8L) images in pure Go. It only uses the subtract green
+// and predictor transforms with one prefix code per channel, without backward references nor
+// color cache, so the files are larger than the ones of libwebp. Being lossless, a photo is
+// usually larger than its jpeg too, it pays off for the images with transparency or few colors
+package webp
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	// maxSize is the largest width or height of a VP8L image, they are stored in 14 bits
+	maxSize = 1 << 14
+	// predictorBits is the log-2 side of the blocks of the predictor, the largest one as every
+	// block uses the same mode
+	predictorBits = 9
+	// predictorAverage is the mode predicting a pixel with the average of its left and top ones
+	predictorAverage = 7
+	// maxCodeLength is the longest prefix code and maxCodeLengthCodeLength the longest code of
+	// the code lengths, as their lengths are written in 3 bits
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+)
+
+const (
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+)
+
+// ErrInvalidSize is returned for an image without pixels or past 16384 pixels on a side
+var ErrInvalidSize = errors.New("webp: the width and height must be from 1 to 16384")
+
+// codeLengthCodeOrder is the order the lengths of the code lengths are written in
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Encode writes img to w as a lossless WebP
+func Encode(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxSize || height > maxSize {
+		return ErrInvalidSize
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	bits := &bitWriter{}
+	bits.write(0x2f, 8)
+	bits.write(uint32(width-1), 14)
+	bits.write(uint32(height-1), 14)
+	bits.write(boolBit(hasAlpha(nrgba.Pix)), 1)
+	bits.write(0, 3)
+
+	// the transforms are undone in the reverse order, so the prediction is made over the
+	// pixels without the green
+	subtractGreen(nrgba.Pix)
+	bits.write(1, 1)
+	bits.write(transformSubtractGreen, 2)
+
+	bits.write(1, 1)
+	bits.write(transformPredictor, 2)
+	bits.write(predictorBits-2, 3)
+	modes := make([]byte, 4*blocks(width)*blocks(height))
+	for idx := 1; idx < len(modes); idx += 4 {
+		modes[idx] = predictorAverage
+	}
+	bits.writeImage(modes, false)
+	bits.write(0, 1)
+
+	bits.writeImage(residuals(nrgba.Pix, width), true)
+	data := bits.bytes()
+
+	// the chunks are padded to an even size
+	padding := len(data) % 2
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+padding))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+func hasAlpha(pix []byte) bool {
+	for idx := 3; idx < len(pix); idx += 4 {
+		if pix[idx] != 0xff {
+			return true
+		}
+	}
+	return false
+}
+
+func blocks(size int) int {
+	return (size + 1<<predictorBits - 1) >> predictorBits
+}
+
+func subtractGreen(pix []byte) {
+	for idx := 0; idx < len(pix); idx += 4 {
+		pix[idx] -= pix[idx+1]
+		pix[idx+2] -= pix[idx+1]
+	}
+}
+
+// residuals is what is left of each pixel after the prediction: opaque black for the first
+// one, the left pixel on the first row, the top one on the first column and the average of
+// both elsewhere
+func residuals(pix []byte, width int) []byte {
+	result := make([]byte, len(pix))
+	stride := 4 * width
+	for p := 0; p < len(pix); p += 4 {
+		x := (p % stride) / 4
+		for c := 0; c < 4; c++ {
+			var predicted byte
+			switch {
+			case p == 0:
+				if c == 3 {
+					predicted = 0xff
+				}
+			case p < stride:
+				predicted = pix[p-4+c]
+			case x == 0:
+				predicted = pix[p-stride+c]
+			default:
+				predicted = byte((int(pix[p-4+c]) + int(pix[p-stride+c])) / 2)
+			}
+			result[p+c] = pix[p+c] - predicted
+		}
+	}
+	return result
+}
+
+func boolBit(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// bitWriter packs the values from the least significant bit, as VP8L reads them
+type bitWriter struct {
+	buf   []byte
+	bits  uint64
+	nBits uint
+}
+
+func (w *bitWriter) write(value uint32, n uint) {
+	w.bits |= uint64(value) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits, w.nBits = 0, 0
+	}
+	return w.buf
+}
+
+// writeImage writes the RGBA pixels of pix as an entropy coded image, the main one is the
+// top level one that could have a meta prefix code
+func (w *bitWriter) writeImage(pix []byte, topLevel bool) {
+	w.write(0, 1) // no color cache
+	if topLevel {
+		w.write(0, 1) // a single group of prefix codes
+	}
+
+	// green also holds the lengths of the backward references, which are never used
+	green, red, blue, alpha := make([]uint32, 256+24), make([]uint32, 256), make([]uint32, 256), make([]uint32, 256)
+	for p := 0; p < len(pix); p += 4 {
+		red[pix[p]]++
+		green[pix[p+1]]++
+		blue[pix[p+2]]++
+		alpha[pix[p+3]]++
+	}
+	codes := []*prefixCode{
+		w.writeCode(green),
+		w.writeCode(red),
+		w.writeCode(blue),
+		w.writeCode(alpha),
+		w.writeCode(make([]uint32, 40)),
+	}
+
+	for p := 0; p < len(pix); p += 4 {
+		codes[0].write(w, int(pix[p+1]))
+		codes[1].write(w, int(pix[p]))
+		codes[2].write(w, int(pix[p+2]))
+		codes[3].write(w, int(pix[p+3]))
+	}
+}
+
+// writeCode writes the prefix code of histogram, as a simple code when up to two symbols are used
+func (w *bitWriter) writeCode(histogram []uint32) *prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		w.write(1, 1)
+		w.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			w.write(0, 1)
+			w.write(uint32(used[0]), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(used[0]), 8)
+		}
+		lengths := make([]uint8, len(histogram))
+		if len(used) == 2 {
+			w.write(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return newPrefixCode(lengths)
+	}
+
+	lengths := codeLengths(histogram, maxCodeLength)
+	lengthsHistogram := make([]uint32, len(codeLengthCodeOrder))
+	for _, length := range lengths {
+		lengthsHistogram[length]++
+	}
+	lengthsCode := newPrefixCode(codeLengths(lengthsHistogram, maxCodeLengthCodeLength))
+
+	count := 4
+	for idx, symbol := range codeLengthCodeOrder {
+		if lengthsCode.lengths[symbol] > 0 && idx+1 > count {
+			count = idx + 1
+		}
+	}
+	w.write(0, 1)
+	w.write(uint32(count-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:count] {
+		w.write(uint32(lengthsCode.lengths[symbol]), 3)
+	}
+	w.write(0, 1) // every symbol has a length
+	for _, length := range lengths {
+		lengthsCode.write(w, int(length))
+	}
+	return newPrefixCode(lengths)
+}
+
+// prefixCode is a canonical Huffman code, a code of a single symbol takes no bits
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+	single  bool
+}
+
+func newPrefixCode(lengths []uint8) *prefixCode {
+	code := &prefixCode{lengths: lengths, codes: make([]uint16, len(lengths))}
+
+	var count [maxCodeLength + 1]int
+	used := 0
+	for _, length := range lengths {
+		if length > 0 {
+			count[length]++
+			used++
+		}
+	}
+	code.single = used <= 1
+
+	var next [maxCodeLength + 1]int
+	for length, current := 1, 0; length <= maxCodeLength; length++ {
+		current = (current + count[length-1]) << 1
+		next[length] = current
+	}
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		// the codes are read from their most significant bit
+		value := next[length]
+		next[length]++
+		reversed := 0
+		for idx := 0; idx < int(length); idx++ {
+			reversed = reversed<<1 | (value>>idx)&1
+		}
+		code.codes[symbol] = uint16(reversed)
+	}
+	return code
+}
+
+func (c *prefixCode) write(w *bitWriter, symbol int) {
+	if c.single {
+		return
+	}
+	w.write(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+}
+
+// codeLengths are the lengths of the Huffman code of histogram up to limit: while the tree
+// is too deep the rare symbols are counted as more frequent, which flattens it
+func codeLengths(histogram []uint32, limit int) []uint8 {
+	lengths := make([]uint8, len(histogram))
+	for minCount := uint32(1); ; minCount *= 2 {
+		nodes := nodeHeap{}
+		var tree []node
+		for symbol, count := range histogram {
+			if count == 0 {
+				continue
+			}
+			if count < minCount {
+				count = minCount
+			}
+			tree = append(tree, node{count: count, symbol: symbol, parent: -1})
+			nodes.indexes = append(nodes.indexes, len(tree)-1)
+		}
+		if len(tree) == 1 {
+			lengths[tree[0].symbol] = 1
+			return lengths
+		}
+
+		nodes.tree = &tree
+		heap.Init(&nodes)
+		for nodes.Len() > 1 {
+			first, second := heap.Pop(&nodes).(int), heap.Pop(&nodes).(int)
+			tree = append(tree, node{count: tree[first].count + tree[second].count, symbol: -1, parent: -1})
+			tree[first].parent, tree[second].parent = len(tree)-1, len(tree)-1
+			heap.Push(&nodes, len(tree)-1)
+		}
+
+		deepest := 0
+		for idx := range tree {
+			if tree[idx].symbol < 0 {
+				continue
+			}
+			depth := 0
+			for parent := tree[idx].parent; parent >= 0; parent = tree[parent].parent {
+				depth++
+			}
+			lengths[tree[idx].symbol] = uint8(depth)
+			if depth > deepest {
+				deepest = depth
+			}
+		}
+		if deepest <= limit {
+			return lengths
+		}
+	}
+}
+
+type node struct {
+	count  uint32
+	symbol int
+	parent int
+}
+
+// nodeHeap orders the indexes of the tree by the count of their nodes
+type nodeHeap struct {
+	indexes []int
+	tree    *[]node
+}
+
+func (h nodeHeap) Len() int { return len(h.indexes) }
+func (h nodeHeap) Less(i, j int) bool {
+	return (*h.tree)[h.indexes[i]].count < (*h.tree)[h.indexes[j]].count
+}
+func (h nodeHeap) Swap(i, j int)       { h.indexes[i], h.indexes[j] = h.indexes[j], h.indexes[i] }
+func (h *nodeHeap) Push(x interface{}) { h.indexes = append(h.indexes, x.(int)) }
+func (h *nodeHeap) Pop() interface{} {
+	last := h.indexes[len(h.indexes)-1]
+	h.indexes = h.indexes[:len(h.indexes)-1]
+	return last
+}
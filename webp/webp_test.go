@@ -0,0 +1,112 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+	"gotest.tools/v3/assert"
+)
+
+func TestEncode(t *testing.T) {
+	cases := map[string]struct {
+		Image func() image.Image
+	}{
+		"should encode a single pixel": {
+			Image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+				img.Set(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+				return img
+			},
+		},
+		"should encode a plain image": {
+			Image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 7, 3))
+				for idx := 0; idx < len(img.Pix); idx += 4 {
+					copy(img.Pix[idx:], []byte{10, 20, 30, 255})
+				}
+				return img
+			},
+		},
+		"should encode a gradient past a block of the predictor": {
+			Image: func() image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 600, 130))
+				for y := 0; y < 130; y++ {
+					for x := 0; x < 600; x++ {
+						img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y * 2), B: uint8(x + y), A: 255})
+					}
+				}
+				return img
+			},
+		},
+		"should encode the noise with transparency": {
+			Image: func() image.Image {
+				random := rand.New(rand.NewSource(1))
+				img := image.NewNRGBA(image.Rect(0, 0, 97, 61))
+				random.Read(img.Pix)
+				return img
+			},
+		},
+		"should encode an image off the origin": {
+			Image: func() image.Image {
+				img := image.NewRGBA(image.Rect(5, 5, 20, 12))
+				for y := 5; y < 12; y++ {
+					for x := 5; x < 20; x++ {
+						img.Set(x, y, color.RGBA{R: uint8(x * 10), G: uint8(y * 10), B: 0, A: 255})
+					}
+				}
+				return img
+			},
+		},
+	}
+	for name, cs := range cases {
+		t.Run(name, func(t *testing.T) {
+			img := cs.Image()
+
+			var content bytes.Buffer
+			assert.NilError(t, Encode(&content, img))
+			assert.Equal(t, 0, content.Len()%2)
+
+			decoded, err := xwebp.Decode(&content)
+			assert.NilError(t, err)
+
+			bounds := img.Bounds()
+			assert.Equal(t, bounds.Dx(), decoded.Bounds().Dx())
+			assert.Equal(t, bounds.Dy(), decoded.Bounds().Dy())
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					expected := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+					assert.Equal(t, expected, color.NRGBAModel.Convert(decoded.At(x, y)), "pixel %d,%d", x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeInvalidSize(t *testing.T) {
+	var content bytes.Buffer
+	assert.ErrorIs(t, Encode(&content, image.NewNRGBA(image.Rect(0, 0, 0, 10))), ErrInvalidSize)
+	assert.ErrorIs(t, Encode(&content, image.NewNRGBA(image.Rect(0, 0, maxSize+1, 1))), ErrInvalidSize)
+}
+
+func TestCodeLengths(t *testing.T) {
+	// the fibonacci counts make the deepest Huffman tree, far past the limit
+	histogram := make([]uint32, 30)
+	histogram[0], histogram[1] = 1, 1
+	for idx := 2; idx < len(histogram); idx++ {
+		histogram[idx] = histogram[idx-1] + histogram[idx-2]
+	}
+
+	lengths := codeLengths(histogram, maxCodeLength)
+
+	// a complete code has a kraft sum of exactly 1
+	kraft := 0
+	for _, length := range lengths {
+		assert.Assert(t, length >= 1 && length <= maxCodeLength, "length %d", length)
+		kraft += 1 << (maxCodeLength - length)
+	}
+	assert.Equal(t, 1<<maxCodeLength, kraft)
+}